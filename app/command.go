@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Commands reading or writing the database, run by runDatabaseCommand once connected
+var databaseCommands = map[string]bool{
+	"rebuild-daily-stats": true,
+	"detect-anomalies":    true,
+	"backfill-locations":  true,
+}
+
+// runCommand runs the command given after the flags of the application, when it doesn't need the database
+//
+// @param params - the parameters of the application, holding the command and its arguments
+// @param splitter - the splitter of journey files
+// @param exporters - the registry of the journey exporters
+func runCommand(params *configuration.Parameters, splitter domain.JourneySplitter, exporters domain.JourneyExporterRegistry) error {
+	switch params.Command {
+	case "split":
+		return runSplit(params.CommandArgs, splitter, exporters)
+	default:
+		return fmt.Errorf("unknown command '%s' (available: split, rebuild-daily-stats, detect-anomalies, backfill-locations)", params.Command)
+	}
+}
+
+// runDatabaseCommand runs the command given after the flags of the application, when it needs the database
+//
+// @param params - the parameters of the application, holding the command and its arguments
+// @param journeyRepo - the repository of the journeys
+// @param dailyStatsRepo - the repository of the daily counters of the journeys
+// @param journeyUsecase - the usecase of the journeys
+func runDatabaseCommand(params *configuration.Parameters, journeyRepo domain.JourneyRepositoryInterface, dailyStatsRepo domain.DailyStatsRepositoryInterface, journeyUsecase domain.JourneyUsecase) error {
+	switch params.Command {
+	case "rebuild-daily-stats":
+		return runRebuildDailyStats(params.CommandArgs, dailyStatsRepo)
+	case "detect-anomalies":
+		return runDetectAnomalies(params.CommandArgs, journeyUsecase)
+	case "backfill-locations":
+		return runBackfillLocations(params.CommandArgs, journeyRepo)
+	default:
+		return fmt.Errorf("unknown command '%s' (available: rebuild-daily-stats, detect-anomalies, backfill-locations)", params.Command)
+	}
+}
+
+// runRebuildDailyStats recomputes the daily counters of the journeys from the stored journeys.
+//
+// Usage: rebuild-daily-stats
+func runRebuildDailyStats(args []string, dailyStatsRepo domain.DailyStatsRepositoryInterface) error {
+	if len(args) > 0 {
+		return fmt.Errorf("rebuild-daily-stats takes no argument")
+	}
+
+	nbCounters, err := dailyStatsRepo.Rebuild(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d daily counters rebuilt\n", nbCounters)
+	return nil
+}
+
+// runBackfillLocations sets the locations of the journeys stored before the geographic criteria,
+// which can't find them otherwise.
+//
+// Usage: backfill-locations
+func runBackfillLocations(args []string, journeyRepo domain.JourneyRepositoryInterface) error {
+	if len(args) > 0 {
+		return fmt.Errorf("backfill-locations takes no argument")
+	}
+
+	nbLocations, err := journeyRepo.BackfillLocations(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d locations set\n", nbLocations)
+	return nil
+}
+
+// runDetectAnomalies checks the stored journeys of a period, alone and compared to each other, and stores their anomalies.
+//
+// Usage: detect-anomalies [-from <YYYY-MM-DD>] [-to <YYYY-MM-DD>]
+func runDetectAnomalies(args []string, journeyUsecase domain.JourneyUsecase) error {
+	flags := flag.NewFlagSet("detect-anomalies", flag.ContinueOnError)
+	from := flags.String("from", "", "first day of the checked journeys, YYYY-MM-DD")
+	to := flags.String("to", "", "last day of the checked journeys, YYYY-MM-DD")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("detect-anomalies takes no argument besides its flags")
+	}
+
+	filter := &domain.JourneyFilter{}
+	var err error
+	if *from != "" {
+		if filter.From, err = time.Parse(time.DateOnly, *from); err != nil {
+			return err
+		}
+	}
+	if *to != "" {
+		if filter.To, err = time.Parse(time.DateOnly, *to); err != nil {
+			return err
+		}
+	}
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	summary, err := journeyUsecase.DetectAnomalies(&gin.Context{}, filter)
+	if err != nil {
+		return err
+	}
+	for _, anomaly := range domain.Anomalies {
+		fmt.Printf("%s\t%d\n", anomaly, summary.ByAnomaly[anomaly])
+	}
+	fmt.Printf("%d journeys checked, %d flagged\n", summary.NbJourneys, summary.NbFlagged)
+	return nil
+}
+
+// runSplit splits a journey file into a zip archive holding a file per value of a dimension.
+//
+// Usage: split -by <dimension> [-format <format>] [-output <archive>] <file>
+func runSplit(args []string, splitter domain.JourneySplitter, exporters domain.JourneyExporterRegistry) error {
+	flags := flag.NewFlagSet("split", flag.ContinueOnError)
+	dimension := flags.String("by", "", fmt.Sprintf("dimension of the split: %s, %s, %s or %s",
+		domain.JourneySplitStartDepartment, domain.JourneySplitEndDepartment, domain.JourneySplitMonth, domain.JourneySplitOperatorClass))
+	format := flags.String("format", domain.JourneyFormatCSV, "format of the split files")
+	output := flags.String("output", "", "path of the zip archive, next to the split file by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("split needs exactly one file to split")
+	}
+
+	if err := domain.ValidateJourneySplitDimension(*dimension); err != nil {
+		return err
+	}
+	exporter, err := exporters.Get(*format)
+	if err != nil {
+		return err
+	}
+
+	path := flags.Arg(0)
+	if *output == "" {
+		*output = path[:len(path)-len(filepath.Ext(path))] + "-" + *dimension + ".zip"
+	}
+
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	archive, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	summary, err := splitter.Split(&domain.JourneyFile{
+		Name:   filepath.Base(path),
+		Reader: source,
+	}, *dimension, exporter, archive)
+	if err != nil {
+		archive.Close()
+		os.Remove(*output)
+		return err
+	}
+
+	for _, part := range summary.Parts {
+		fmt.Printf("%s\t%d\n", part.Filename, part.NbJourneys)
+	}
+	fmt.Printf("%d journeys split into %d files of %s (%d errors)\n", summary.NbJourneys, len(summary.Parts), *output, len(summary.Errors))
+	return archive.Close()
+}
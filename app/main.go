@@ -34,7 +34,44 @@ func main() {
 
 	newLogger, _ := zap.NewProduction()
 	logger = *newLogger.Sugar()
-	r := gin.Default()
+
+	// Services
+	journeySchemas, err := service.LoadJourneySchemas(
+		&logger,
+		cfg,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	journeyParsers := service.NewJourneyParserRegistry(
+		&logger,
+		cfg,
+		journeySchemas,
+	)
+
+	journeyExporters := service.NewJourneyExporterRegistry(
+		&logger,
+		cfg,
+		journeySchemas,
+	)
+
+	tripExporters := service.NewTripExporterRegistry(
+		&logger,
+	)
+
+	journeySplitter := service.NewJourneySplitter(
+		&logger,
+		journeyParsers,
+	)
+
+	// Most commands don't need the database
+	if params.Command != "" && !databaseCommands[params.Command] {
+		if err := runCommand(params, journeySplitter, journeyExporters); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	// Repositories
 	// ** DB Connections **
@@ -46,26 +83,61 @@ func main() {
 	}
 
 	mongoDB := mongoClient.Database(configMongo.DbName)
-	journeyRepo := repo.NewDbJourneyMongoRepository(
+	journeyRepo, err := repo.NewDbJourneyMongoRepository(
+		&logger,
+		cfg,
+		mongoDB,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dailyStatsRepo, err := repo.NewDbDailyStatsMongoRepository(
+		&logger,
+		cfg,
+		mongoDB,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	zoneRepo := repo.NewDbZoneMongoRepository(
 		&logger,
 		cfg,
 		mongoDB,
 	)
 
-	// Services
-	journeyParser := service.NewJourneyCsvParser(
+	journeyProcessors, err := service.NewJourneyProcessorChain(
 		&logger,
 		cfg,
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Usecases
-	journeyUC := usecase.NewJourneyUsecase(
+	journeyUC, err := usecase.NewJourneyUsecase(
 		&logger,
 		cfg,
 		journeyRepo,
-		journeyParser,
+		dailyStatsRepo,
+		zoneRepo,
+		journeyParsers,
+		journeyExporters,
+		tripExporters,
+		journeySplitter,
+		journeyProcessors,
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
+	if params.Command != "" {
+		if err := runDatabaseCommand(params, journeyRepo, dailyStatsRepo, journeyUC); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	r := gin.Default()
 	router.NewJourneyRouter(
 		&logger,
 		cfg,
@@ -3,6 +3,9 @@ package configuration
 
 import (
 	"os"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
 
 	"gopkg.in/yaml.v3"
 )
@@ -16,17 +19,74 @@ type Config struct {
 
 	Journey struct {
 		Import struct {
-			MaxUploadFile int64 `yaml:"max-upload-file-size"`
+			MaxUploadFile int64                           `yaml:"max-upload-file-size"`
+			Filters       map[string]domain.JourneyFilter `yaml:"filters"`
 		}
 
 		Parser struct {
-			WorkerPoolSize int `yaml:"worker-pool-size"`
+			WorkerPoolSize  int    `yaml:"worker-pool-size"`
+			SchemaDirectory string `yaml:"schema-directory"`
 		}
 
 		Insertion struct {
 			WorkerPoolSize int `yaml:"worker-pool-size"`
 			BulkInsertSize int `yaml:"bulk-insert-size"`
 		}
+
+		Processing struct {
+			Steps []string `yaml:"steps"`
+		}
+
+		Stats struct {
+			Timezone string `yaml:"timezone"`
+		}
+
+		Anomalies domain.AnomalyThresholds `yaml:"anomalies"`
+
+		Co2 struct {
+			Version string                            `yaml:"version"`
+			Factors map[string]domain.EmissionFactors `yaml:"factors"`
+		}
+
+		Export struct {
+			Parquet struct {
+				RowGroupSize int `yaml:"row-group-size"`
+			}
+
+			GeoJSON struct {
+				MaxFeatures int64 `yaml:"max-features"`
+			}
+		}
+
+		Heatmap struct {
+			MaxCells int64 `yaml:"max-cells"`
+		}
+
+		Tiles struct {
+			ClusterDepth int           `yaml:"cluster-depth"`
+			MaxJourneys  int64         `yaml:"max-journeys"`
+			CacheSize    int           `yaml:"cache-size"`
+			CacheTTL     time.Duration `yaml:"cache-ttl"`
+		}
+
+		Referential struct {
+			Insee struct {
+				File string `yaml:"file"`
+				Mode string `yaml:"mode"`
+			}
+
+			Boundaries struct {
+				File               string  `yaml:"file"`
+				InseeProperty      string  `yaml:"insee-property"`
+				DepartmentProperty string  `yaml:"department-property"`
+				TownProperty       string  `yaml:"town-property"`
+				Tolerance          float64 `yaml:"tolerance"`
+			}
+
+			SchoolCalendar struct {
+				File string `yaml:"file"`
+			} `yaml:"school-calendar"`
+		}
 	}
 
 	Database struct {
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -26,7 +28,31 @@ func TestLoadingConfiguration(t *testing.T) {
 		assert.Equal(t, 100, config.Journey.Insertion.WorkerPoolSize)
 		assert.Equal(t, 10, config.Journey.Insertion.BulkInsertSize)
 		assert.Equal(t, int64(1000000), config.Journey.Import.MaxUploadFile)
+		assert.Equal(t, []string{"22", "29", "35", "56"}, config.Journey.Import.Filters["brittany"].Departments)
+		assert.Equal(t, []string{"B", "C"}, config.Journey.Import.Filters["brittany"].OperatorClasses)
 		assert.Equal(t, 10, config.Journey.Parser.WorkerPoolSize)
+		assert.Equal(t, "./resource/schemas", config.Journey.Parser.SchemaDirectory)
+		assert.Equal(t, []string{"reverse-geocoding", "insee"}, config.Journey.Processing.Steps)
+		assert.Equal(t, "Europe/Paris", config.Journey.Stats.Timezone)
+		assert.Equal(t, domain.AnomalyThresholds{MaxSpeed: 150, MinDistanceRatio: 0.9, MaxDistanceRatio: 4, BurstSize: 5, BurstWindow: 10}, config.Journey.Anomalies)
+		assert.Equal(t, "2023", config.Journey.Co2.Version)
+		assert.Equal(t, domain.EmissionFactors{CarEmission: 0.218, ModalShift: 1}, config.Journey.Co2.Factors["2022"])
+		assert.Equal(t, domain.EmissionFactors{CarEmission: 0.193, ModalShift: 0.8}, config.Journey.Co2.Factors["2023"])
+		assert.Equal(t, 50000, config.Journey.Export.Parquet.RowGroupSize)
+		assert.Equal(t, int64(200000), config.Journey.Export.GeoJSON.MaxFeatures)
+		assert.Equal(t, int64(20000), config.Journey.Heatmap.MaxCells)
+		assert.Equal(t, 6, config.Journey.Tiles.ClusterDepth)
+		assert.Equal(t, int64(200000), config.Journey.Tiles.MaxJourneys)
+		assert.Equal(t, 500, config.Journey.Tiles.CacheSize)
+		assert.Equal(t, 5*time.Minute, config.Journey.Tiles.CacheTTL)
+		assert.Equal(t, "./resource/referential/v_commune_2023.csv", config.Journey.Referential.Insee.File)
+		assert.Equal(t, "flag", config.Journey.Referential.Insee.Mode)
+		assert.Equal(t, "./resource/referential/communes.geojson", config.Journey.Referential.Boundaries.File)
+		assert.Equal(t, "code", config.Journey.Referential.Boundaries.InseeProperty)
+		assert.Equal(t, "departement", config.Journey.Referential.Boundaries.DepartmentProperty)
+		assert.Equal(t, "nom", config.Journey.Referential.Boundaries.TownProperty)
+		assert.Equal(t, 1000.0, config.Journey.Referential.Boundaries.Tolerance)
+		assert.Equal(t, "./resource/referential/school-calendar.yaml", config.Journey.Referential.SchoolCalendar.File)
 
 		assert.Equal(t, "user", config.Database.Mongo.Username)
 		assert.Equal(t, "pwd", config.Database.Mongo.Password)
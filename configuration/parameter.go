@@ -11,6 +11,9 @@ import (
 // Parameters struct defines all available arguments of the application
 type Parameters struct {
 	ConfigFilePath string
+	// Command to run instead of the server, with its own arguments. Empty to run the server
+	Command     string
+	CommandArgs []string
 }
 
 // ParseFlag parses command line arguments. The program name must be passed as the first argument to this function.
@@ -35,6 +38,11 @@ func ParseFlag(progname string, args []string) (*Parameters, string, error) {
 		return nil, buf.String(), err
 	}
 
+	if flags.NArg() > 0 {
+		params.Command = flags.Arg(0)
+		params.CommandArgs = flags.Args()[1:]
+	}
+
 	return &params, buf.String(), nil
 }
 
@@ -22,6 +22,15 @@ func TestParseParameter(t *testing.T) {
 			&configuration.Parameters{ConfigFilePath: "./testdata/application-dev.yaml"},
 			false,
 		},
+		{
+			[]string{"-config", "./testdata/application-dev.yaml", "split", "-by", "month", "journeys.csv"},
+			&configuration.Parameters{
+				ConfigFilePath: "./testdata/application-dev.yaml",
+				Command:        "split",
+				CommandArgs:    []string{"-by", "month", "journeys.csv"},
+			},
+			false,
+		},
 		{
 			[]string{"-config"},
 			nil,
@@ -0,0 +1,271 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+)
+
+// Anomalies detected on journeys
+const (
+	// The distance and the duration of the journey imply an impossible speed
+	AnomalySpeed = "speed"
+	// The distance of the journey is far from the straight-line distance between its coordinates
+	AnomalyDistance = "distance"
+	// Another journey of the same trip is at the same time at a place out of reach
+	AnomalyTripOverlap = "trip-overlap"
+	// Identical journeys of different trips start within a few minutes
+	AnomalyBurst = "burst"
+)
+
+// Anomalies, in the order of the listings
+var Anomalies = []string{AnomalySpeed, AnomalyDistance, AnomalyTripOverlap, AnomalyBurst}
+
+// Weights of the anomalies in the score of a journey
+var anomalyWeights = map[string]float64{
+	AnomalySpeed:       1,
+	AnomalyDistance:    0.5,
+	AnomalyTripOverlap: 1,
+	AnomalyBurst:       1,
+}
+
+// Straight-line distance, in meters, under which the distance of a journey isn't compared to it
+const anomalyMinStraightDistance = 1000
+
+// Thresholds of the anomaly detection
+type AnomalyThresholds struct {
+	// Highest plausible speed, in km/h
+	MaxSpeed float64 `yaml:"max-speed"`
+	// Lowest and highest plausible ratios between the distance of a journey and the straight-line distance between its coordinates
+	MinDistanceRatio float64 `yaml:"min-distance-ratio"`
+	MaxDistanceRatio float64 `yaml:"max-distance-ratio"`
+	// Number of identical journeys of different trips making a burst
+	BurstSize int `yaml:"burst-size"`
+	// Duration, in minutes, in which the journeys of a burst start
+	BurstWindow int `yaml:"burst-window"`
+}
+
+// ValidateAnomaly checks that an anomaly is known
+//
+// @param anomaly - Name of the anomaly
+func ValidateAnomaly(anomaly string) error {
+	for _, known := range Anomalies {
+		if anomaly == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown anomaly '%s' (available: %s, %s, %s, %s)", anomaly, AnomalySpeed, AnomalyDistance, AnomalyTripOverlap, AnomalyBurst)
+}
+
+// AnomalyScore returns the score of a journey from its anomalies, 0 for a journey without anomalies
+//
+// @param anomalies - Anomalies of the journey
+func AnomalyScore(anomalies []string) float64 {
+	score := 0.0
+	for _, anomaly := range anomalies {
+		score += anomalyWeights[anomaly]
+	}
+	return score
+}
+
+// DetectJourneyAnomalies returns the anomalies which can be detected on a journey alone: speed and distance
+//
+// @param journey - Journey to check
+// @param thresholds - Thresholds of the detection
+func DetectJourneyAnomalies(journey *Journey, thresholds *AnomalyThresholds) []string {
+	anomalies := []string{}
+
+	hours := float64(journey.JourneyDuration) / 60
+	if hours <= 0 && !journey.JourneyStartDatetime.IsZero() && journey.JourneyEndDatetime.After(journey.JourneyStartDatetime) {
+		hours = journey.JourneyEndDatetime.Sub(journey.JourneyStartDatetime).Hours()
+	}
+	if thresholds.MaxSpeed > 0 && hours > 0 && float64(journey.JourneyDistance)/1000/hours > thresholds.MaxSpeed {
+		anomalies = append(anomalies, AnomalySpeed)
+	}
+
+	start, startOk := journeyStart(journey)
+	end, endOk := journeyEnd(journey)
+	if startOk && endOk && journey.JourneyDistance > 0 {
+		straight := geo.Distance(start, end)
+		if straight >= anomalyMinStraightDistance {
+			ratio := float64(journey.JourneyDistance) / straight
+			if (thresholds.MinDistanceRatio > 0 && ratio < thresholds.MinDistanceRatio) ||
+				(thresholds.MaxDistanceRatio > 0 && ratio > thresholds.MaxDistanceRatio) {
+				anomalies = append(anomalies, AnomalyDistance)
+			}
+		}
+	}
+	return anomalies
+}
+
+func journeyStart(journey *Journey) (orb.Point, bool) {
+	point := orb.Point{journey.JourneyStartLon, journey.JourneyStartLat}
+	return point, !point.Equal(orb.Point{})
+}
+
+func journeyEnd(journey *Journey) (orb.Point, bool) {
+	point := orb.Point{journey.JourneyEndLon, journey.JourneyEndLat}
+	return point, !point.Equal(orb.Point{})
+}
+
+// Anomalies of a journey
+type JourneyAnomalies struct {
+	JourneyId int64
+	Anomalies []string
+	Score     float64
+}
+
+// Summary of a detection of anomalies
+type AnomalySummary struct {
+	NbJourneys int64
+	NbFlagged  int64
+	ByAnomaly  map[string]int64
+}
+
+// anomalyCandidate holds what the detection needs to know of a journey
+type anomalyCandidate struct {
+	journeyId  int64
+	tripId     uuid.UUID
+	start      time.Time
+	end        time.Time
+	startPoint orb.Point
+	located    bool
+	burstKey   string
+	anomalies  []string
+}
+
+// Detector of the anomalies of journeys, alone and compared to each other
+type AnomalyDetector struct {
+	thresholds *AnomalyThresholds
+	candidates []*anomalyCandidate
+}
+
+// NewAnomalyDetector creates a detector. The journeys are kept until the end of the detection
+//
+// @param thresholds - Thresholds of the detection
+func NewAnomalyDetector(thresholds *AnomalyThresholds) *AnomalyDetector {
+	return &AnomalyDetector{thresholds: thresholds}
+}
+
+// Add checks a journey alone and keeps it to compare it with the other ones
+//
+// @param journey - Journey to check
+func (d *AnomalyDetector) Add(journey *Journey) {
+	candidate := &anomalyCandidate{
+		journeyId: journey.JourneyId,
+		tripId:    journey.TripId,
+		start:     journey.JourneyStartDatetime,
+		end:       journey.JourneyEndDatetime,
+		anomalies: DetectJourneyAnomalies(journey, d.thresholds),
+	}
+	if candidate.end.IsZero() && !candidate.start.IsZero() {
+		candidate.end = candidate.start.Add(time.Duration(journey.JourneyDuration) * time.Minute)
+	}
+	candidate.startPoint, candidate.located = journeyStart(journey)
+	if end, ok := journeyEnd(journey); candidate.located && ok {
+		candidate.burstKey = fmt.Sprintf("%.3f,%.3f>%.3f,%.3f/%d/%s", candidate.startPoint.Lon(), candidate.startPoint.Lat(),
+			end.Lon(), end.Lat(), journey.JourneyDistance, journey.OperatorClass)
+	}
+	d.candidates = append(d.candidates, candidate)
+}
+
+// Result compares the journeys and returns the anomalies of every journey, with or without anomalies, sorted by journey id
+func (d *AnomalyDetector) Result() []JourneyAnomalies {
+	d.detectTripOverlaps()
+	d.detectBursts()
+
+	result := make([]JourneyAnomalies, 0, len(d.candidates))
+	for _, candidate := range d.candidates {
+		anomalies := []string{}
+		for _, anomaly := range Anomalies {
+			if Contains(candidate.anomalies, anomaly) {
+				anomalies = append(anomalies, anomaly)
+			}
+		}
+		result = append(result, JourneyAnomalies{
+			JourneyId: candidate.journeyId,
+			Anomalies: anomalies,
+			Score:     AnomalyScore(anomalies),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].JourneyId < result[j].JourneyId })
+	return result
+}
+
+// detectTripOverlaps flags the journeys of a trip overlapping in time whose starts can't be reached from each other in time
+func (d *AnomalyDetector) detectTripOverlaps() {
+	if d.thresholds.MaxSpeed <= 0 {
+		return
+	}
+	byTrip := map[uuid.UUID][]*anomalyCandidate{}
+	for _, candidate := range d.candidates {
+		if candidate.located && !candidate.start.IsZero() {
+			byTrip[candidate.tripId] = append(byTrip[candidate.tripId], candidate)
+		}
+	}
+
+	for _, journeys := range byTrip {
+		for i := 0; i < len(journeys); i++ {
+			for j := i + 1; j < len(journeys); j++ {
+				a, b := journeys[i], journeys[j]
+				if a.start.After(b.end) || b.start.After(a.end) {
+					continue
+				}
+				reachable := math.Abs(a.start.Sub(b.start).Hours()) * d.thresholds.MaxSpeed * 1000
+				if geo.Distance(a.startPoint, b.startPoint) > reachable+anomalyMinStraightDistance {
+					a.flag(AnomalyTripOverlap)
+					b.flag(AnomalyTripOverlap)
+				}
+			}
+		}
+	}
+}
+
+// detectBursts flags the identical journeys of different trips starting within the window of a burst
+func (d *AnomalyDetector) detectBursts() {
+	if d.thresholds.BurstSize <= 1 {
+		return
+	}
+	window := time.Duration(d.thresholds.BurstWindow) * time.Minute
+	byKey := map[string][]*anomalyCandidate{}
+	for _, candidate := range d.candidates {
+		if candidate.burstKey != "" && !candidate.start.IsZero() {
+			byKey[candidate.burstKey] = append(byKey[candidate.burstKey], candidate)
+		}
+	}
+
+	for _, journeys := range byKey {
+		if len(journeys) < d.thresholds.BurstSize {
+			continue
+		}
+		sort.Slice(journeys, func(i, j int) bool { return journeys[i].start.Before(journeys[j].start) })
+
+		first := 0
+		trips := map[uuid.UUID]int{}
+		for last, journey := range journeys {
+			trips[journey.tripId]++
+			for journey.start.Sub(journeys[first].start) > window {
+				if trips[journeys[first].tripId]--; trips[journeys[first].tripId] == 0 {
+					delete(trips, journeys[first].tripId)
+				}
+				first++
+			}
+			if len(trips) >= d.thresholds.BurstSize {
+				for _, inBurst := range journeys[first : last+1] {
+					inBurst.flag(AnomalyBurst)
+				}
+			}
+		}
+	}
+}
+
+func (c *anomalyCandidate) flag(anomaly string) {
+	if !Contains(c.anomalies, anomaly) {
+		c.anomalies = append(c.anomalies, anomaly)
+	}
+}
@@ -0,0 +1,134 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var anomalyThresholds = domain.AnomalyThresholds{
+	MaxSpeed:         150,
+	MinDistanceRatio: 0.9,
+	MaxDistanceRatio: 4,
+	BurstSize:        3,
+	BurstWindow:      10,
+}
+
+// rennesToNantes returns a journey from Rennes to Nantes, about 100 km apart
+func rennesToNantes(journeyId int64, tripId uuid.UUID, start time.Time) domain.Journey {
+	return domain.Journey{
+		JourneyId:            journeyId,
+		TripId:               tripId,
+		JourneyStartDatetime: start,
+		JourneyEndDatetime:   start.Add(90 * time.Minute),
+		JourneyStartLon:      -1.6778,
+		JourneyStartLat:      48.1173,
+		JourneyEndLon:        -1.5536,
+		JourneyEndLat:        47.2184,
+		JourneyDistance:      110000,
+		JourneyDuration:      90,
+		OperatorClass:        "C",
+	}
+}
+
+func TestDetectJourneyAnomalies(t *testing.T) {
+	start := time.Date(2023, 1, 2, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		update    func(journey *domain.Journey)
+		anomalies []string
+	}{
+		{"plausible", func(journey *domain.Journey) {}, []string{}},
+		{"too fast", func(journey *domain.Journey) { journey.JourneyDuration = 20 }, []string{domain.AnomalySpeed}},
+		{"too fast from the datetimes", func(journey *domain.Journey) {
+			journey.JourneyDuration = 0
+			journey.JourneyEndDatetime = start.Add(20 * time.Minute)
+		}, []string{domain.AnomalySpeed}},
+		{"too long", func(journey *domain.Journey) {
+			journey.JourneyDistance = 500000
+			journey.JourneyDuration = 300
+		}, []string{domain.AnomalyDistance}},
+		{"shorter than the straight line", func(journey *domain.Journey) { journey.JourneyDistance = 50000 }, []string{domain.AnomalyDistance}},
+		{"without coordinates", func(journey *domain.Journey) {
+			journey.JourneyEndLon = 0
+			journey.JourneyEndLat = 0
+			journey.JourneyDistance = 500000
+			journey.JourneyDuration = 300
+		}, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			journey := rennesToNantes(1, uuid.New(), start)
+			test.update(&journey)
+
+			assert.Equal(t, test.anomalies, domain.DetectJourneyAnomalies(&journey, &anomalyThresholds))
+		})
+	}
+}
+
+func TestAnomalyScore(t *testing.T) {
+	assert.Equal(t, 0.0, domain.AnomalyScore([]string{}))
+	assert.Equal(t, 1.5, domain.AnomalyScore([]string{domain.AnomalySpeed, domain.AnomalyDistance}))
+}
+
+func TestValidateAnomaly(t *testing.T) {
+	assert.NoError(t, domain.ValidateAnomaly(domain.AnomalyTripOverlap))
+	assert.EqualError(t, domain.ValidateAnomaly("teleport"), "unknown anomaly 'teleport' (available: speed, distance, trip-overlap, burst)")
+}
+
+func TestAnomalyDetector_tripOverlap(t *testing.T) {
+	start := time.Date(2023, 1, 2, 8, 0, 0, 0, time.UTC)
+	tripId := uuid.New()
+	fromRennes := rennesToNantes(1, tripId, start)
+	fromNantes := rennesToNantes(2, tripId, start.Add(10*time.Minute))
+	fromNantes.JourneyStartLon, fromNantes.JourneyStartLat = fromNantes.JourneyEndLon, fromNantes.JourneyEndLat
+	fromNantes.JourneyEndLon, fromNantes.JourneyEndLat = fromRennes.JourneyStartLon, fromRennes.JourneyStartLat
+	sameStart := rennesToNantes(3, tripId, start.Add(5*time.Minute))
+	otherTrip := rennesToNantes(4, uuid.New(), start.Add(10*time.Minute))
+
+	detector := domain.NewAnomalyDetector(&anomalyThresholds)
+	for _, journey := range []domain.Journey{fromNantes, sameStart, fromRennes, otherTrip} {
+		detector.Add(&journey)
+	}
+
+	assert.Equal(t, []domain.JourneyAnomalies{
+		{JourneyId: 1, Anomalies: []string{domain.AnomalyTripOverlap}, Score: 1},
+		{JourneyId: 2, Anomalies: []string{domain.AnomalyTripOverlap}, Score: 1},
+		{JourneyId: 3, Anomalies: []string{domain.AnomalyTripOverlap}, Score: 1},
+		{JourneyId: 4, Anomalies: []string{}, Score: 0},
+	}, detector.Result())
+}
+
+func TestAnomalyDetector_burst(t *testing.T) {
+	start := time.Date(2023, 1, 2, 8, 0, 0, 0, time.UTC)
+	tripId := uuid.New()
+	journeys := []domain.Journey{
+		rennesToNantes(1, uuid.New(), start),
+		rennesToNantes(2, uuid.New(), start.Add(4*time.Minute)),
+		rennesToNantes(3, uuid.New(), start.Add(8*time.Minute)),
+		// Passengers of a same trip are a single driver
+		rennesToNantes(4, tripId, start.Add(30*time.Minute)),
+		rennesToNantes(5, tripId, start.Add(30*time.Minute)),
+		rennesToNantes(6, uuid.New(), start.Add(45*time.Minute)),
+	}
+	journeys[1].JourneyDuration = 20
+
+	detector := domain.NewAnomalyDetector(&anomalyThresholds)
+	for i := range journeys {
+		detector.Add(&journeys[i])
+	}
+
+	assert.Equal(t, []domain.JourneyAnomalies{
+		{JourneyId: 1, Anomalies: []string{domain.AnomalyBurst}, Score: 1},
+		{JourneyId: 2, Anomalies: []string{domain.AnomalySpeed, domain.AnomalyBurst}, Score: 2},
+		{JourneyId: 3, Anomalies: []string{domain.AnomalyBurst}, Score: 1},
+		{JourneyId: 4, Anomalies: []string{}, Score: 0},
+		{JourneyId: 5, Anomalies: []string{}, Score: 0},
+		{JourneyId: 6, Anomalies: []string{}, Score: 0},
+	}, detector.Result())
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Departments of Alsace-Moselle, which keep Good Friday and St Stephen's Day as public holidays
+var alsaceMoselleDepartments = map[string]bool{"57": true, "67": true, "68": true}
+
+// Calendar of the school holidays of the zones
+type SchoolCalendar interface {
+	// Zone returns the school zone of a department, empty when the department has no zone
+	Zone(department string) string
+	// IsHoliday tells whether a day is in the school holidays of a zone
+	IsHoliday(zone string, day time.Time) bool
+}
+
+// Easter returns the Easter Sunday of a year of the Gregorian calendar
+//
+// @param year - Year of the Easter Sunday
+func Easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// FrenchPublicHoliday returns the name of the French public holiday of a day, if any.
+// The departments of Alsace-Moselle also get Good Friday and St Stephen's Day.
+//
+// @param day - Day to check, only its date is read
+// @param department - Department of the day. Can be empty
+func FrenchPublicHoliday(day time.Time, department string) (string, bool) {
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	easter := Easter(date.Year())
+
+	switch {
+	case date.Month() == time.January && date.Day() == 1:
+		return "Jour de l'an", true
+	case date.Equal(easter.AddDate(0, 0, 1)):
+		return "Lundi de Pâques", true
+	case date.Month() == time.May && date.Day() == 1:
+		return "Fête du travail", true
+	case date.Month() == time.May && date.Day() == 8:
+		return "Victoire 1945", true
+	case date.Equal(easter.AddDate(0, 0, 39)):
+		return "Ascension", true
+	case date.Equal(easter.AddDate(0, 0, 50)):
+		return "Lundi de Pentecôte", true
+	case date.Month() == time.July && date.Day() == 14:
+		return "Fête nationale", true
+	case date.Month() == time.August && date.Day() == 15:
+		return "Assomption", true
+	case date.Month() == time.November && date.Day() == 1:
+		return "Toussaint", true
+	case date.Month() == time.November && date.Day() == 11:
+		return "Armistice 1918", true
+	case date.Month() == time.December && date.Day() == 25:
+		return "Noël", true
+	}
+
+	if alsaceMoselleDepartments[department] {
+		switch {
+		case date.Equal(easter.AddDate(0, 0, -2)):
+			return "Vendredi saint", true
+		case date.Month() == time.December && date.Day() == 26:
+			return "Saint-Étienne", true
+		}
+	}
+	return "", false
+}
+
+// IsoWeek returns the ISO 8601 week of a day, such as 2023-W01
+//
+// @param day - Day to read
+func IsoWeek(day time.Time) string {
+	year, week := day.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// IsoWeekday returns the ISO 8601 day of the week of a day, from 1 for Monday to 7 for Sunday
+//
+// @param day - Day to read
+func IsoWeekday(day time.Time) int64 {
+	if day.Weekday() == time.Sunday {
+		return 7
+	}
+	return int64(day.Weekday())
+}
@@ -0,0 +1,52 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEaster(t *testing.T) {
+	assert.Equal(t, time.Date(2019, 4, 21, 0, 0, 0, 0, time.UTC), domain.Easter(2019))
+	assert.Equal(t, time.Date(2023, 4, 9, 0, 0, 0, 0, time.UTC), domain.Easter(2023))
+	assert.Equal(t, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), domain.Easter(2024))
+}
+
+func TestFrenchPublicHoliday(t *testing.T) {
+	tests := []struct {
+		day        time.Time
+		department string
+		name       string
+	}{
+		{time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), "35", "Jour de l'an"},
+		{time.Date(2023, 4, 10, 8, 30, 0, 0, time.UTC), "35", "Lundi de Pâques"},
+		{time.Date(2023, 5, 18, 0, 0, 0, 0, time.UTC), "35", "Ascension"},
+		{time.Date(2023, 5, 29, 0, 0, 0, 0, time.UTC), "35", "Lundi de Pentecôte"},
+		{time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC), "", "Fête nationale"},
+		{time.Date(2023, 4, 7, 0, 0, 0, 0, time.UTC), "67", "Vendredi saint"},
+		{time.Date(2023, 4, 7, 0, 0, 0, 0, time.UTC), "35", ""},
+		{time.Date(2023, 12, 26, 0, 0, 0, 0, time.UTC), "57", "Saint-Étienne"},
+		{time.Date(2023, 12, 26, 0, 0, 0, 0, time.UTC), "35", ""},
+		{time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC), "35", ""},
+	}
+
+	for _, test := range tests {
+		name, ok := domain.FrenchPublicHoliday(test.day, test.department)
+		assert.Equal(t, test.name, name, test.day)
+		assert.Equal(t, test.name != "", ok, test.day)
+	}
+}
+
+func TestIsoWeek(t *testing.T) {
+	assert.Equal(t, "2022-W52", domain.IsoWeek(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2023-W01", domain.IsoWeek(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2020-W53", domain.IsoWeek(time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestIsoWeekday(t *testing.T) {
+	assert.Equal(t, int64(1), domain.IsoWeekday(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, int64(7), domain.IsoWeekday(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
@@ -0,0 +1,77 @@
+package domain
+
+import "fmt"
+
+// Emission factors of the estimates of the emissions avoided by carpooling
+type EmissionFactors struct {
+	// Emission of a car, in kg of CO2 per vehicle-km
+	CarEmission float64 `yaml:"car-emission"`
+	// Share of the passengers who would have driven alone without carpooling
+	ModalShift float64 `yaml:"modal-shift"`
+}
+
+// VehicleKmAvoided estimates the vehicle-km avoided by passenger-km carpooled
+//
+// @param passengerKm - Distance travelled by the passengers, in km
+func (f *EmissionFactors) VehicleKmAvoided(passengerKm float64) float64 {
+	return passengerKm * f.ModalShift
+}
+
+// Co2Saved estimates the kg of CO2 saved by passenger-km carpooled
+//
+// @param passengerKm - Distance travelled by the passengers, in km
+func (f *EmissionFactors) Co2Saved(passengerKm float64) float64 {
+	return f.VehicleKmAvoided(passengerKm) * f.CarEmission
+}
+
+// JourneyPassengerKm returns the distance travelled by the passengers of a journey, in km
+//
+// @param journey - Journey to read
+func JourneyPassengerKm(journey *Journey) float64 {
+	return float64(journey.JourneyDistance) / 1000 * float64(journey.PassengerSeats)
+}
+
+// Dimensions along which the CO2 estimates can be aggregated, besides the total
+var Co2Dimensions = []string{JourneySplitStartDepartment, JourneySplitEndDepartment, JourneySplitMonth, JourneySplitOperatorClass}
+
+// ValidateCo2Dimension checks that a dimension is known. An empty dimension gives the total
+//
+// @param dimension - Dimension of the aggregation
+func ValidateCo2Dimension(dimension string) error {
+	if dimension == "" {
+		return nil
+	}
+	for _, known := range Co2Dimensions {
+		if dimension == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown CO2 dimension '%s' (available: %s, %s, %s, %s)", dimension,
+		JourneySplitStartDepartment, JourneySplitEndDepartment, JourneySplitMonth, JourneySplitOperatorClass)
+}
+
+// Estimates of the emissions avoided by the journeys of a bucket
+type Co2Bucket struct {
+	Key              string
+	NbJourneys       int64
+	PassengerKm      float64
+	VehicleKmAvoided float64
+	Co2Saved         float64
+}
+
+// Estimates of the emissions avoided, computed with a version of the emission factors
+type Co2Report struct {
+	Version string
+	Factors EmissionFactors
+	Buckets []Co2Bucket
+}
+
+// Estimate fills the vehicle-km avoided and the CO2 saved of buckets holding their passenger-km
+//
+// @param buckets - Buckets to fill
+func (f *EmissionFactors) Estimate(buckets []Co2Bucket) {
+	for i := range buckets {
+		buckets[i].VehicleKmAvoided = f.VehicleKmAvoided(buckets[i].PassengerKm)
+		buckets[i].Co2Saved = f.Co2Saved(buckets[i].PassengerKm)
+	}
+}
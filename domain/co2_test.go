@@ -0,0 +1,30 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmissionFactors(t *testing.T) {
+	factors := domain.EmissionFactors{CarEmission: 0.2, ModalShift: 0.5}
+	passengerKm := domain.JourneyPassengerKm(&domain.Journey{JourneyDistance: 12500, PassengerSeats: 2})
+
+	assert.Equal(t, 25.0, passengerKm)
+	assert.Equal(t, 12.5, factors.VehicleKmAvoided(passengerKm))
+	assert.Equal(t, 2.5, factors.Co2Saved(passengerKm))
+
+	buckets := []domain.Co2Bucket{{Key: "35", NbJourneys: 2, PassengerKm: 100}}
+	factors.Estimate(buckets)
+	assert.Equal(t, []domain.Co2Bucket{{Key: "35", NbJourneys: 2, PassengerKm: 100, VehicleKmAvoided: 50, Co2Saved: 10}}, buckets)
+}
+
+func TestValidateCo2Dimension(t *testing.T) {
+	assert.NoError(t, domain.ValidateCo2Dimension(""))
+	for _, dimension := range domain.Co2Dimensions {
+		assert.NoError(t, domain.ValidateCo2Dimension(dimension))
+	}
+	assert.Error(t, domain.ValidateCo2Dimension("week"))
+}
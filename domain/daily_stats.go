@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Counters of the journeys of a day, by start department, end department and operator class
+type DailyStats struct {
+	DailyStatsKey
+	NbJourneys      int64
+	PassengerSeats  int64
+	TotalDistance   int64
+	TotalDuration   int64
+	NbWithIncentive int64
+}
+
+// Key of the counters of a day
+type DailyStatsKey struct {
+	Day             string
+	StartDepartment string
+	EndDepartment   string
+	OperatorClass   string
+}
+
+// Repository of the daily counters of the journeys, kept up to date by the imports
+type DailyStatsRepositoryInterface interface {
+	// Increment adds stored journeys to the counters of their days
+	Increment(ctx context.Context, journeys []Journey) error
+	// Rebuild recomputes all the counters from the stored journeys and returns the number of counters
+	Rebuild(ctx context.Context) (int64, error)
+	// Stats sums the counters by bucket of one of the DailyStatsStats
+	Stats(ctx context.Context, stat string) ([]StatBucket, error)
+}
+
+// Statistics which can be summed from the daily counters, when the journeys aren't filtered
+var DailyStatsStats = []string{
+	StatPerDay,
+	StatPerWeek,
+	StatPerMonth,
+	StatPerOperatorClass,
+}
+
+// IsDailyStatsStat tells whether a statistic can be summed from the daily counters
+//
+// @param stat - Name of a statistic
+func IsDailyStatsStat(stat string) bool {
+	return Contains(DailyStatsStats, stat)
+}
+
+// JourneyDay returns the day of a journey in a location: the day of its start datetime, or its start date when the datetime is unknown.
+// An empty day is returned when both are unknown.
+//
+// @param journey - Journey to read
+// @param location - Location of the day
+func JourneyDay(journey *Journey, location *time.Location) string {
+	if !journey.JourneyStartDatetime.IsZero() {
+		return journey.JourneyStartDatetime.In(location).Format(time.DateOnly)
+	}
+	if !journey.JourneyStartDate.IsZero() {
+		return journey.JourneyStartDate.Format(time.DateOnly)
+	}
+	return ""
+}
+
+// AggregateDailyStats sums journeys into the counters of their days, sorted by key
+//
+// @param journeys - Journeys to sum
+// @param location - Location of the days
+func AggregateDailyStats(journeys []Journey, location *time.Location) []DailyStats {
+	byKey := map[DailyStatsKey]*DailyStats{}
+	for i := range journeys {
+		journey := &journeys[i]
+		key := DailyStatsKey{
+			Day:             JourneyDay(journey, location),
+			StartDepartment: journey.JourneyStartDepartment,
+			EndDepartment:   journey.JourneyEndDepartment,
+			OperatorClass:   journey.OperatorClass,
+		}
+		stats, ok := byKey[key]
+		if !ok {
+			stats = &DailyStats{DailyStatsKey: key}
+			byKey[key] = stats
+		}
+		stats.NbJourneys++
+		stats.PassengerSeats += int64(journey.PassengerSeats)
+		stats.TotalDistance += journey.JourneyDistance
+		stats.TotalDuration += journey.JourneyDuration
+		if journey.HasIncentive {
+			stats.NbWithIncentive++
+		}
+	}
+
+	dailyStats := make([]DailyStats, 0, len(byKey))
+	for _, stats := range byKey {
+		dailyStats = append(dailyStats, *stats)
+	}
+	sort.Slice(dailyStats, func(i, j int) bool {
+		a, b := dailyStats[i].DailyStatsKey, dailyStats[j].DailyStatsKey
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.StartDepartment != b.StartDepartment {
+			return a.StartDepartment < b.StartDepartment
+		}
+		if a.EndDepartment != b.EndDepartment {
+			return a.EndDepartment < b.EndDepartment
+		}
+		return a.OperatorClass < b.OperatorClass
+	})
+	return dailyStats
+}
@@ -0,0 +1,60 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJourneyDay(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+
+	assert.Equal(t, "2023-01-02", domain.JourneyDay(&domain.Journey{
+		JourneyStartDatetime: time.Date(2023, 1, 1, 23, 30, 0, 0, time.UTC),
+	}, paris))
+	assert.Equal(t, "2023-01-01", domain.JourneyDay(&domain.Journey{
+		JourneyStartDatetime: time.Date(2023, 1, 1, 23, 30, 0, 0, time.UTC),
+	}, time.UTC))
+	assert.Equal(t, "2023-03-04", domain.JourneyDay(&domain.Journey{
+		JourneyStartDate: time.Date(2023, 3, 4, 0, 0, 0, 0, time.UTC),
+	}, paris))
+	assert.Equal(t, "", domain.JourneyDay(&domain.Journey{}, paris))
+}
+
+func TestAggregateDailyStats(t *testing.T) {
+	day := time.Date(2023, 1, 2, 8, 0, 0, 0, time.UTC)
+	journeys := []domain.Journey{
+		{JourneyStartDatetime: day.AddDate(0, 0, 1), JourneyStartDepartment: "69", JourneyEndDepartment: "69", OperatorClass: "C", PassengerSeats: 1},
+		{JourneyStartDatetime: day, JourneyStartDepartment: "69", JourneyEndDepartment: "01", OperatorClass: "C", PassengerSeats: 2, JourneyDistance: 1000, JourneyDuration: 10, HasIncentive: true},
+		{JourneyStartDatetime: day, JourneyStartDepartment: "69", JourneyEndDepartment: "01", OperatorClass: "C", PassengerSeats: 1, JourneyDistance: 3000, JourneyDuration: 20},
+	}
+
+	dailyStats := domain.AggregateDailyStats(journeys, time.UTC)
+
+	assert.Equal(t, []domain.DailyStats{
+		{
+			DailyStatsKey:   domain.DailyStatsKey{Day: "2023-01-02", StartDepartment: "69", EndDepartment: "01", OperatorClass: "C"},
+			NbJourneys:      2,
+			PassengerSeats:  3,
+			TotalDistance:   4000,
+			TotalDuration:   30,
+			NbWithIncentive: 1,
+		},
+		{
+			DailyStatsKey:  domain.DailyStatsKey{Day: "2023-01-03", StartDepartment: "69", EndDepartment: "69", OperatorClass: "C"},
+			NbJourneys:     1,
+			PassengerSeats: 1,
+		},
+	}, dailyStats)
+	assert.Empty(t, domain.AggregateDailyStats(nil, time.UTC))
+}
+
+func TestIsDailyStatsStat(t *testing.T) {
+	assert.True(t, domain.IsDailyStatsStat(domain.StatPerWeek))
+	assert.True(t, domain.IsDailyStatsStat(domain.StatPerOperatorClass))
+	assert.False(t, domain.IsDailyStatsStat(domain.StatPerHour))
+	assert.False(t, domain.IsDailyStatsStat(domain.StatPerStartZone))
+}
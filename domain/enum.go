@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Enumerated field: a closed set of canonical values, each one accepting several tokens
+type Enum struct {
+	name   string
+	tokens map[string]string
+	values []string
+}
+
+// Values accepted for a journey operator class
+var OperatorClassEnum = NewEnum("operator_class", map[string][]string{
+	"A": {"A"},
+	"B": {"B"},
+	"C": {"C"},
+})
+
+// Canonical values of a boolean field in the open data files
+const (
+	BooleanTrue  = "OUI"
+	BooleanFalse = "NON"
+)
+
+// Tokens accepted for a boolean field
+var BooleanEnum = NewEnum("boolean", map[string][]string{
+	BooleanTrue:  {"OUI", "O", "YES", "Y", "TRUE", "VRAI", "1"},
+	BooleanFalse: {"NON", "N", "NO", "FALSE", "FAUX", "0"},
+})
+
+// NewEnum creates an enumerated field description.
+//
+// @param name - Name of the enumeration, used in error messages
+// @param values - Canonical values associated with the tokens they accept. Tokens are case insensitive
+func NewEnum(name string, values map[string][]string) *Enum {
+	enum := &Enum{
+		name:   name,
+		tokens: map[string]string{},
+	}
+
+	for value, tokens := range values {
+		enum.values = append(enum.values, value)
+		enum.tokens[normalizeToken(value)] = value
+		for _, token := range tokens {
+			enum.tokens[normalizeToken(token)] = value
+		}
+	}
+	sort.Strings(enum.values)
+
+	return enum
+}
+
+// Name returns the name of the enumeration
+func (e *Enum) Name() string {
+	return e.name
+}
+
+// Values returns the canonical values of the enumeration, sorted
+func (e *Enum) Values() []string {
+	return e.values
+}
+
+// Parse normalizes a raw value and returns the canonical value it stands for.
+//
+// @param raw - Value read from the file
+//
+// @return the canonical value, or an error listing the accepted tokens if the value is unknown
+func (e *Enum) Parse(raw string) (string, error) {
+	if value, ok := e.tokens[normalizeToken(raw)]; ok {
+		return value, nil
+	}
+
+	tokens := make([]string, 0, len(e.tokens))
+	for token := range e.tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+
+	return "", fmt.Errorf("unknown %s value %q (accepted: %s)", e.name, raw, strings.Join(tokens, ", "))
+}
+
+// ParseBool parses a raw value with a boolean enumeration.
+//
+// @param raw - Value read from the file
+//
+// @return true if the value stands for BooleanTrue, an error if it is unknown
+func (e *Enum) ParseBool(raw string) (bool, error) {
+	value, err := e.Parse(raw)
+	if err != nil {
+		return false, err
+	}
+
+	return value == BooleanTrue, nil
+}
+
+func normalizeToken(token string) string {
+	return strings.ToUpper(strings.TrimSpace(token))
+}
@@ -0,0 +1,57 @@
+// Package domain_test tests the application model
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnumParse(t *testing.T) {
+	var tests = []struct {
+		enum            *domain.Enum
+		raw             string
+		expected        string
+		shouldHaveError bool
+	}{
+		{domain.OperatorClassEnum, "A", "A", false},
+		{domain.OperatorClassEnum, " c ", "C", false},
+		{domain.OperatorClassEnum, "D", "", true},
+		{domain.OperatorClassEnum, "", "", true},
+		{domain.BooleanEnum, "OUI", domain.BooleanTrue, false},
+		{domain.BooleanEnum, "oui", domain.BooleanTrue, false},
+		{domain.BooleanEnum, "1", domain.BooleanTrue, false},
+		{domain.BooleanEnum, "Non", domain.BooleanFalse, false},
+		{domain.BooleanEnum, "0", domain.BooleanFalse, false},
+		{domain.BooleanEnum, "PEUT-ETRE", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.enum.Name()+"_"+test.raw, func(t *testing.T) {
+			value, err := test.enum.Parse(test.raw)
+
+			if test.shouldHaveError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), test.enum.Name())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, value)
+			}
+		})
+	}
+}
+
+func TestEnumParseBool(t *testing.T) {
+	value, err := domain.BooleanEnum.ParseBool("Oui")
+	assert.NoError(t, err)
+	assert.True(t, value)
+
+	value, err = domain.BooleanEnum.ParseBool("NON")
+	assert.NoError(t, err)
+	assert.False(t, value)
+
+	_, err = domain.BooleanEnum.ParseBool("")
+	assert.Error(t, err)
+}
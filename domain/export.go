@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"fmt"
+	"io"
+)
+
+// Writer of journeys into an export file
+type JourneyExporter interface {
+	// ContentType returns the MIME type of the exported files
+	ContentType() string
+	// Extension returns the extension of the exported files, with its leading dot
+	Extension() string
+	// Export writes the journeys received from the channel until it is closed and returns the number of exported journeys
+	Export(writer io.Writer, journeyChan <-chan *Journey) (int64, error)
+}
+
+// Registry of the journey exporters, by format
+type JourneyExporterRegistry interface {
+	Get(format string) (JourneyExporter, error)
+}
+
+// Formats of the GeoJSON exports, by geometry of the journey features
+const (
+	JourneyFormatGeoJSONStart = "geojson-start"
+	JourneyFormatGeoJSONEnd   = "geojson-end"
+	JourneyFormatGeoJSONLine  = "geojson-line"
+)
+
+// Exporter refusing the selections holding too many journeys
+type LimitedJourneyExporter interface {
+	JourneyExporter
+	// MaxJourneys returns the maximum number of journeys of an export, 0 meaning no limit
+	MaxJourneys() int64
+}
+
+// Error of an export whose selection holds more journeys than its exporter accepts
+type ExportTooLargeError struct {
+	NbJourneys  int64
+	MaxJourneys int64
+}
+
+func (e *ExportTooLargeError) Error() string {
+	return fmt.Sprintf("too many journeys to export (selected: %d - max: %d), narrow the filter", e.NbJourneys, e.MaxJourneys)
+}
+
+// Formats of the CSV exports, by layout of the official files
+const (
+	JourneyFormatCSV27 = "csv-27"
+	JourneyFormatCSV29 = "csv-29"
+)
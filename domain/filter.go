@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Criteria selecting journeys. Empty criteria select every journey.
+type JourneyFilter struct {
+	// Departments where journeys start or end
+	Departments      []string `form:"department" yaml:"departments"`
+	StartDepartments []string `form:"start-department" yaml:"start-departments"`
+	EndDepartments   []string `form:"end-department" yaml:"end-departments"`
+	OperatorClasses  []string `form:"operator-class" yaml:"operator-classes"`
+	// First and last days of the journeys, both included
+	From         time.Time `form:"from" time_format:"2006-01-02" time_utc:"1" yaml:"from"`
+	To           time.Time `form:"to" time_format:"2006-01-02" time_utc:"1" yaml:"to"`
+	MinDistance  int64     `form:"min-distance" yaml:"min-distance"`
+	MaxDistance  int64     `form:"max-distance" yaml:"max-distance"`
+	HasIncentive *bool     `form:"has-incentive" yaml:"has-incentive"`
+	// Areas where journeys start or end, given in JSON in the query string: discs as [lon, lat, radius in meters],
+	// bounding boxes as [min lon, min lat, max lon, max lat] and polygons as GeoJSON geometries
+	StartNear   *GeoCircle  `form:"start-near" yaml:"start-near"`
+	EndNear     *GeoCircle  `form:"end-near" yaml:"end-near"`
+	StartBbox   *GeoBound   `form:"start-bbox" yaml:"start-bbox"`
+	EndBbox     *GeoBound   `form:"end-bbox" yaml:"end-bbox"`
+	StartWithin *GeoPolygon `form:"start-within" yaml:"start-within"`
+	EndWithin   *GeoPolygon `form:"end-within" yaml:"end-within"`
+}
+
+// Validate checks the criteria and normalizes the operator classes
+func (f *JourneyFilter) Validate() error {
+	if len(f.OperatorClasses) > 0 {
+		operatorClasses := make([]string, 0, len(f.OperatorClasses))
+		for _, operatorClass := range f.OperatorClasses {
+			value, err := OperatorClassEnum.Parse(operatorClass)
+			if err != nil {
+				return err
+			}
+			operatorClasses = append(operatorClasses, value)
+		}
+		f.OperatorClasses = operatorClasses
+	}
+
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		return fmt.Errorf("the end of the period (%s) is before its beginning (%s)", f.To.Format(time.DateOnly), f.From.Format(time.DateOnly))
+	}
+
+	if f.MaxDistance > 0 && f.MaxDistance < f.MinDistance {
+		return fmt.Errorf("the maximal distance (%d) is lower than the minimal distance (%d)", f.MaxDistance, f.MinDistance)
+	}
+
+	return nil
+}
+
+// IsEmpty checks if the filter has no criteria
+func (f *JourneyFilter) IsEmpty() bool {
+	return len(f.Departments) == 0 &&
+		len(f.StartDepartments) == 0 &&
+		len(f.EndDepartments) == 0 &&
+		len(f.OperatorClasses) == 0 &&
+		f.From.IsZero() &&
+		f.To.IsZero() &&
+		f.MinDistance == 0 &&
+		f.MaxDistance == 0 &&
+		f.HasIncentive == nil &&
+		f.StartNear == nil &&
+		f.EndNear == nil &&
+		f.StartBbox == nil &&
+		f.EndBbox == nil &&
+		f.StartWithin == nil &&
+		f.EndWithin == nil
+}
+
+// Override returns a copy of the filter where the criteria set in another filter replace the current ones
+//
+// @param other - Filter whose criteria prevail
+func (f JourneyFilter) Override(other *JourneyFilter) JourneyFilter {
+	if len(other.Departments) > 0 {
+		f.Departments = other.Departments
+	}
+	if len(other.StartDepartments) > 0 {
+		f.StartDepartments = other.StartDepartments
+	}
+	if len(other.EndDepartments) > 0 {
+		f.EndDepartments = other.EndDepartments
+	}
+	if len(other.OperatorClasses) > 0 {
+		f.OperatorClasses = other.OperatorClasses
+	}
+	if !other.From.IsZero() {
+		f.From = other.From
+	}
+	if !other.To.IsZero() {
+		f.To = other.To
+	}
+	if other.MinDistance != 0 {
+		f.MinDistance = other.MinDistance
+	}
+	if other.MaxDistance != 0 {
+		f.MaxDistance = other.MaxDistance
+	}
+	if other.HasIncentive != nil {
+		f.HasIncentive = other.HasIncentive
+	}
+	if other.StartNear != nil {
+		f.StartNear = other.StartNear
+	}
+	if other.EndNear != nil {
+		f.EndNear = other.EndNear
+	}
+	if other.StartBbox != nil {
+		f.StartBbox = other.StartBbox
+	}
+	if other.EndBbox != nil {
+		f.EndBbox = other.EndBbox
+	}
+	if other.StartWithin != nil {
+		f.StartWithin = other.StartWithin
+	}
+	if other.EndWithin != nil {
+		f.EndWithin = other.EndWithin
+	}
+	return f
+}
+
+// Match checks if a journey meets all the criteria
+//
+// @param journey - Journey to check
+func (f *JourneyFilter) Match(journey *Journey) bool {
+	if len(f.Departments) > 0 && !Contains(f.Departments, journey.JourneyStartDepartment) && !Contains(f.Departments, journey.JourneyEndDepartment) {
+		return false
+	}
+	if len(f.StartDepartments) > 0 && !Contains(f.StartDepartments, journey.JourneyStartDepartment) {
+		return false
+	}
+	if len(f.EndDepartments) > 0 && !Contains(f.EndDepartments, journey.JourneyEndDepartment) {
+		return false
+	}
+	if len(f.OperatorClasses) > 0 && !Contains(f.OperatorClasses, journey.OperatorClass) {
+		return false
+	}
+	if !f.From.IsZero() && journey.JourneyStartDate.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && journey.JourneyStartDate.After(f.To) {
+		return false
+	}
+	if f.MinDistance > 0 && journey.JourneyDistance < f.MinDistance {
+		return false
+	}
+	if f.MaxDistance > 0 && journey.JourneyDistance > f.MaxDistance {
+		return false
+	}
+	if f.HasIncentive != nil && journey.HasIncentive != *f.HasIncentive {
+		return false
+	}
+	start, startOk := journeyStart(journey)
+	if (f.StartNear != nil || f.StartBbox != nil || f.StartWithin != nil) && !startOk {
+		return false
+	}
+	if (f.StartNear != nil && !f.StartNear.Contains(start)) ||
+		(f.StartBbox != nil && !f.StartBbox.Contains(start)) ||
+		(f.StartWithin != nil && !f.StartWithin.Contains(start)) {
+		return false
+	}
+	end, endOk := journeyEnd(journey)
+	if (f.EndNear != nil || f.EndBbox != nil || f.EndWithin != nil) && !endOk {
+		return false
+	}
+	if (f.EndNear != nil && !f.EndNear.Contains(end)) ||
+		(f.EndBbox != nil && !f.EndBbox.Contains(end)) ||
+		(f.EndWithin != nil && !f.EndWithin.Contains(end)) {
+		return false
+	}
+	return true
+}
+
+// Contains tells whether a value is one of the values
+//
+// @param values - Values to look into
+// @param value - Value to look for
+func Contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
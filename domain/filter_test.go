@@ -0,0 +1,76 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJourneyFilterMatch(t *testing.T) {
+	yes := true
+	journey := &domain.Journey{
+		JourneyStartDepartment: "35",
+		JourneyEndDepartment:   "56",
+		JourneyStartDate:       time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC),
+		OperatorClass:          "C",
+		JourneyDistance:        12000,
+		HasIncentive:           false,
+		JourneyStartLon:        -1.6778,
+		JourneyStartLat:        48.1173,
+		JourneyEndLon:          -2.7603,
+		JourneyEndLat:          47.6586,
+	}
+	rennes := &domain.GeoCircle{Center: orb.Point{-1.68, 48.11}, Radius: 5000}
+	morbihan := &domain.GeoBound{Bound: orb.Bound{Min: orb.Point{-3.7, 47.3}, Max: orb.Point{-2.0, 48.2}}}
+	vannes := &domain.GeoPolygon{Geometry: orb.Polygon{{{-2.8, 47.6}, {-2.7, 47.6}, {-2.7, 47.7}, {-2.8, 47.7}, {-2.8, 47.6}}}}
+
+	var tests = []struct {
+		name     string
+		filter   domain.JourneyFilter
+		expected bool
+	}{
+		{"empty", domain.JourneyFilter{}, true},
+		{"department_start", domain.JourneyFilter{Departments: []string{"35"}}, true},
+		{"department_end", domain.JourneyFilter{Departments: []string{"56", "22"}}, true},
+		{"department_other", domain.JourneyFilter{Departments: []string{"22"}}, false},
+		{"start_department", domain.JourneyFilter{StartDepartments: []string{"56"}}, false},
+		{"end_department", domain.JourneyFilter{EndDepartments: []string{"56"}}, true},
+		{"operator_class", domain.JourneyFilter{OperatorClasses: []string{"A", "B"}}, false},
+		{"period", domain.JourneyFilter{From: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC)}, true},
+		{"period_after", domain.JourneyFilter{From: time.Date(2023, 1, 16, 0, 0, 0, 0, time.UTC)}, false},
+		{"distance", domain.JourneyFilter{MinDistance: 5000, MaxDistance: 12000}, true},
+		{"distance_too_short", domain.JourneyFilter{MinDistance: 15000}, false},
+		{"incentive", domain.JourneyFilter{HasIncentive: &yes}, false},
+		{"start_near", domain.JourneyFilter{StartNear: rennes}, true},
+		{"end_near", domain.JourneyFilter{EndNear: rennes}, false},
+		{"end_bbox", domain.JourneyFilter{EndBbox: morbihan}, true},
+		{"start_bbox", domain.JourneyFilter{StartBbox: morbihan}, false},
+		{"end_within", domain.JourneyFilter{StartNear: rennes, EndWithin: vannes}, true},
+		{"start_within", domain.JourneyFilter{StartWithin: vannes}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.filter.Match(journey))
+		})
+	}
+
+	unlocated := &domain.Journey{}
+	assert.False(t, (&domain.JourneyFilter{EndBbox: &domain.GeoBound{Bound: orb.Bound{Min: orb.Point{-1, -1}, Max: orb.Point{1, 1}}}}).Match(unlocated))
+}
+
+func TestJourneyFilterValidate(t *testing.T) {
+	filter := domain.JourneyFilter{OperatorClasses: []string{"a", " B"}}
+	assert.NoError(t, filter.Validate())
+	assert.Equal(t, []string{"A", "B"}, filter.OperatorClasses)
+
+	filter = domain.JourneyFilter{OperatorClasses: []string{"Z"}}
+	assert.Error(t, filter.Validate())
+
+	filter = domain.JourneyFilter{MinDistance: 100, MaxDistance: 10}
+	assert.Error(t, filter.Validate())
+}
@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/planar"
+	"gopkg.in/yaml.v3"
+)
+
+// Disc around a point, given as [lon, lat, radius] with the radius in meters
+type GeoCircle struct {
+	Center orb.Point `form:"-"`
+	Radius float64   `form:"-"`
+}
+
+// UnmarshalJSON reads a disc given as [lon, lat, radius]
+func (c *GeoCircle) UnmarshalJSON(data []byte) error {
+	var values []float64
+	if err := json.Unmarshal(data, &values); err != nil || len(values) != 3 {
+		return fmt.Errorf("a disc must be given as [lon, lat, radius in meters]: %s", data)
+	}
+	c.Center = orb.Point{values[0], values[1]}
+	c.Radius = values[2]
+	if err := validatePoint(c.Center); err != nil {
+		return err
+	}
+	if c.Radius <= 0 {
+		return fmt.Errorf("the radius of a disc must be positive: %s", data)
+	}
+	return nil
+}
+
+// UnmarshalYAML reads a disc given as [lon, lat, radius]
+func (c *GeoCircle) UnmarshalYAML(value *yaml.Node) error {
+	return unmarshalYAMLAsJSON(value, c)
+}
+
+// Contains checks if a point is in the disc
+//
+// @param point - Point to check
+func (c *GeoCircle) Contains(point orb.Point) bool {
+	return geo.Distance(c.Center, point) <= c.Radius
+}
+
+// Bounding box, given as [min lon, min lat, max lon, max lat]
+type GeoBound struct {
+	orb.Bound `form:"-"`
+}
+
+// UnmarshalJSON reads a bounding box given as [min lon, min lat, max lon, max lat]
+func (b *GeoBound) UnmarshalJSON(data []byte) error {
+	var values []float64
+	if err := json.Unmarshal(data, &values); err != nil || len(values) != 4 {
+		return fmt.Errorf("a bounding box must be given as [min lon, min lat, max lon, max lat]: %s", data)
+	}
+	b.Min = orb.Point{values[0], values[1]}
+	b.Max = orb.Point{values[2], values[3]}
+	if err := validatePoint(b.Min); err != nil {
+		return err
+	}
+	if err := validatePoint(b.Max); err != nil {
+		return err
+	}
+	if b.Max.Lon() < b.Min.Lon() || b.Max.Lat() < b.Min.Lat() {
+		return fmt.Errorf("the minimal corner of a bounding box must be before its maximal corner: %s", data)
+	}
+	return nil
+}
+
+// UnmarshalYAML reads a bounding box given as [min lon, min lat, max lon, max lat]
+func (b *GeoBound) UnmarshalYAML(value *yaml.Node) error {
+	return unmarshalYAMLAsJSON(value, b)
+}
+
+// Area given as a GeoJSON Polygon or MultiPolygon geometry
+type GeoPolygon struct {
+	Geometry orb.Geometry `form:"-"`
+}
+
+// UnmarshalJSON reads a GeoJSON Polygon or MultiPolygon geometry
+func (p *GeoPolygon) UnmarshalJSON(data []byte) error {
+	geometry, err := geojson.UnmarshalGeometry(data)
+	if err != nil {
+		return fmt.Errorf("an area must be given as a GeoJSON geometry: %w", err)
+	}
+	switch g := geometry.Geometry().(type) {
+	case orb.Polygon, orb.MultiPolygon:
+		p.Geometry = g
+	default:
+		return fmt.Errorf("an area must be a Polygon or a MultiPolygon, not a %s", geometry.Type)
+	}
+	return nil
+}
+
+// UnmarshalYAML reads a GeoJSON Polygon or MultiPolygon geometry written in YAML
+func (p *GeoPolygon) UnmarshalYAML(value *yaml.Node) error {
+	return unmarshalYAMLAsJSON(value, p)
+}
+
+// Contains checks if a point is in the area
+//
+// @param point - Point to check
+func (p *GeoPolygon) Contains(point orb.Point) bool {
+	switch g := p.Geometry.(type) {
+	case orb.Polygon:
+		return planar.PolygonContains(g, point)
+	case orb.MultiPolygon:
+		return planar.MultiPolygonContains(g, point)
+	default:
+		return false
+	}
+}
+
+func validatePoint(point orb.Point) error {
+	if point.Lon() < -180 || point.Lon() > 180 || point.Lat() < -90 || point.Lat() > 90 {
+		return fmt.Errorf("coordinates out of range: [%g, %g]", point.Lon(), point.Lat())
+	}
+	return nil
+}
+
+// unmarshalYAMLAsJSON decodes a YAML value with the JSON decoding of its destination
+func unmarshalYAMLAsJSON(value *yaml.Node, destination json.Unmarshaler) error {
+	var content interface{}
+	if err := value.Decode(&content); err != nil {
+		return err
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	return destination.UnmarshalJSON(data)
+}
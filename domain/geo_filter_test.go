@@ -0,0 +1,66 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestGeoCircle_unmarshal(t *testing.T) {
+	var circle domain.GeoCircle
+	assert.NoError(t, json.Unmarshal([]byte("[-1.68, 48.11, 5000]"), &circle))
+	assert.Equal(t, domain.GeoCircle{Center: orb.Point{-1.68, 48.11}, Radius: 5000}, circle)
+
+	assert.Error(t, json.Unmarshal([]byte("[-1.68, 48.11]"), &circle))
+	assert.Error(t, json.Unmarshal([]byte("[-1.68, 48.11, 0]"), &circle))
+	assert.Error(t, json.Unmarshal([]byte("[48.11, -191.68, 5000]"), &circle))
+}
+
+func TestGeoBound_unmarshal(t *testing.T) {
+	var bound domain.GeoBound
+	assert.NoError(t, json.Unmarshal([]byte("[-3.7, 47.3, -2.0, 48.2]"), &bound))
+	assert.Equal(t, orb.Bound{Min: orb.Point{-3.7, 47.3}, Max: orb.Point{-2.0, 48.2}}, bound.Bound)
+	assert.True(t, bound.Contains(orb.Point{-2.76, 47.66}))
+
+	assert.Error(t, json.Unmarshal([]byte("[-2.0, 48.2, -3.7, 47.3]"), &bound))
+	assert.Error(t, json.Unmarshal([]byte(`{"min": [-3.7, 47.3]}`), &bound))
+}
+
+func TestGeoPolygon_unmarshal(t *testing.T) {
+	var polygon domain.GeoPolygon
+	assert.NoError(t, json.Unmarshal([]byte(`{"type": "Polygon", "coordinates": [[[-2.8, 47.6], [-2.7, 47.6], [-2.7, 47.7], [-2.8, 47.6]]]}`), &polygon))
+	assert.IsType(t, orb.Polygon{}, polygon.Geometry)
+	assert.True(t, polygon.Contains(orb.Point{-2.71, 47.61}))
+	assert.False(t, polygon.Contains(orb.Point{-2.79, 47.69}))
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"type": "MultiPolygon", "coordinates": [[[[-2.8, 47.6], [-2.7, 47.6], [-2.7, 47.7], [-2.8, 47.6]]]]}`), &polygon))
+	assert.True(t, polygon.Contains(orb.Point{-2.71, 47.61}))
+
+	assert.EqualError(t, json.Unmarshal([]byte(`{"type": "Point", "coordinates": [-2.8, 47.6]}`), &polygon),
+		"an area must be a Polygon or a MultiPolygon, not a Point")
+	assert.Error(t, json.Unmarshal([]byte(`"Vannes"`), &polygon))
+}
+
+func TestJourneyFilter_yamlAreas(t *testing.T) {
+	content := `
+start-near: [-1.68, 48.11, 5000]
+end-bbox: [-3.7, 47.3, -2.0, 48.2]
+end-within:
+  type: Polygon
+  coordinates: [[[-2.8, 47.6], [-2.7, 47.6], [-2.7, 47.7], [-2.8, 47.6]]]
+`
+	var filter domain.JourneyFilter
+	assert.NoError(t, yaml.Unmarshal([]byte(content), &filter))
+	assert.Equal(t, &domain.GeoCircle{Center: orb.Point{-1.68, 48.11}, Radius: 5000}, filter.StartNear)
+	assert.Equal(t, orb.Point{-2.0, 48.2}, filter.EndBbox.Max)
+	assert.IsType(t, orb.Polygon{}, filter.EndWithin.Geometry)
+	assert.Nil(t, filter.EndNear)
+	assert.False(t, filter.IsEmpty())
+
+	assert.Error(t, yaml.Unmarshal([]byte("start-near: [-1.68, 48.11]"), &filter))
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/paulmach/orb"
+)
+
+// Grids of a heatmap
+const (
+	HeatmapGridSquare  = "square"
+	HeatmapGridHex     = "hex"
+	HeatmapGridGeohash = "geohash"
+)
+
+// HeatmapGrids lists the grids of a heatmap
+var HeatmapGrids = []string{HeatmapGridSquare, HeatmapGridHex, HeatmapGridGeohash}
+
+// Longest geohash of a heatmap
+const MaxGeohashPrecision = 12
+
+// Binning of the starts or ends of the journeys into the cells of a grid, over a bounding box
+type HeatmapRequest struct {
+	// One of the HeatmapGrid constants
+	Grid string
+	// LocationStart or LocationEnd
+	Side string
+	// Size of the square and hexagonal cells in meters, or number of characters of the geohashes
+	Resolution float64
+	Bound      orb.Bound
+}
+
+// Number of journeys starting or ending in a cell of a heatmap
+type HeatmapCell struct {
+	Cell       string
+	Polygon    orb.Polygon
+	NbJourneys int64
+}
+
+// Error of a heatmap whose bounding box holds more cells than allowed
+type HeatmapTooLargeError struct {
+	NbCells  int64
+	MaxCells int64
+}
+
+func (e *HeatmapTooLargeError) Error() string {
+	return fmt.Sprintf("too many cells in the heatmap (requested: %d - max: %d), lower the resolution or narrow the bounding box", e.NbCells, e.MaxCells)
+}
+
+// Validate checks the grid, the side and the resolution of the heatmap
+func (r *HeatmapRequest) Validate() error {
+	switch r.Grid {
+	case HeatmapGridSquare, HeatmapGridHex:
+		if r.Resolution <= 0 {
+			return fmt.Errorf("the resolution of a %s grid must be a positive size in meters", r.Grid)
+		}
+	case HeatmapGridGeohash:
+		if r.Resolution != float64(int(r.Resolution)) || r.Resolution < 1 || r.Resolution > MaxGeohashPrecision {
+			return fmt.Errorf("the resolution of a geohash grid must be a precision from 1 to %d", MaxGeohashPrecision)
+		}
+	default:
+		return fmt.Errorf("unknown grid '%s' (available: %s, %s, %s)", r.Grid, HeatmapGridSquare, HeatmapGridHex, HeatmapGridGeohash)
+	}
+	if r.Side != LocationStart && r.Side != LocationEnd {
+		return fmt.Errorf("unknown side '%s' (available: %s, %s)", r.Side, LocationStart, LocationEnd)
+	}
+	if r.Bound.IsEmpty() {
+		return fmt.Errorf("the bounding box of a heatmap must not be empty")
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeatmapRequest_validate(t *testing.T) {
+	bound := orb.Bound{Min: orb.Point{-1.8, 48}, Max: orb.Point{-1.5, 48.2}}
+
+	assert.NoError(t, (&domain.HeatmapRequest{Grid: domain.HeatmapGridSquare, Side: domain.LocationStart, Resolution: 500, Bound: bound}).Validate())
+	assert.NoError(t, (&domain.HeatmapRequest{Grid: domain.HeatmapGridHex, Side: domain.LocationEnd, Resolution: 500, Bound: bound}).Validate())
+	assert.NoError(t, (&domain.HeatmapRequest{Grid: domain.HeatmapGridGeohash, Side: domain.LocationEnd, Resolution: 6, Bound: bound}).Validate())
+
+	assert.Error(t, (&domain.HeatmapRequest{Grid: "triangle", Side: domain.LocationStart, Resolution: 500, Bound: bound}).Validate())
+	assert.Error(t, (&domain.HeatmapRequest{Grid: domain.HeatmapGridSquare, Side: "middle", Resolution: 500, Bound: bound}).Validate())
+	assert.Error(t, (&domain.HeatmapRequest{Grid: domain.HeatmapGridSquare, Side: domain.LocationStart, Resolution: -1, Bound: bound}).Validate())
+	assert.Error(t, (&domain.HeatmapRequest{Grid: domain.HeatmapGridGeohash, Side: domain.LocationStart, Resolution: 6.5, Bound: bound}).Validate())
+	assert.Error(t, (&domain.HeatmapRequest{Grid: domain.HeatmapGridGeohash, Side: domain.LocationStart, Resolution: 13, Bound: bound}).Validate())
+	assert.Error(t, (&domain.HeatmapRequest{Grid: domain.HeatmapGridSquare, Side: domain.LocationStart, Resolution: 500, Bound: orb.Bound{Min: orb.Point{1, 1}, Max: orb.Point{0, 0}}}).Validate())
+}
+
+func TestJourneyLocation_point(t *testing.T) {
+	location := domain.JourneyLocation{Start: &orb.Point{-1.68, 48.11}}
+
+	assert.Equal(t, &orb.Point{-1.68, 48.11}, location.Point(domain.LocationStart))
+	assert.Nil(t, location.Point(domain.LocationEnd))
+}
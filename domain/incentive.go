@@ -0,0 +1,351 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Band of distance of an incentive policy, with the amount given to its journeys
+type IncentiveDistanceBand struct {
+	// Shortest distance of the band in meters, included
+	MinDistance int64 `json:"min-distance"`
+	// Longest distance of the band in meters, excluded. No limit when 0
+	MaxDistance int64 `json:"max-distance"`
+	// Fixed amount of a journey, in euros
+	Fixed float64 `json:"fixed"`
+	// Amount by km of a journey, in euros
+	PerKm float64 `json:"per-km"`
+}
+
+// Time window of an incentive policy, on the start of the journeys
+type IncentiveTimeWindow struct {
+	// ISO days of the week, from 1 (Monday) to 7 (Sunday). Every day when empty
+	Weekdays []int `json:"weekdays"`
+	// Start of the window as HH:MM, included. Midnight when empty
+	Start string `json:"start"`
+	// End of the window as HH:MM, excluded. Midnight when empty
+	End string `json:"end"`
+}
+
+// Geographic perimeter of an incentive policy. Journeys everywhere are eligible when empty
+type IncentivePerimeter struct {
+	Insees      []int64  `json:"insees"`
+	Departments []string `json:"departments"`
+	// Whether both the start and the end of the journeys must be inside, one of them is enough otherwise
+	Both bool `json:"both"`
+}
+
+// Rules of an incentive policy
+type IncentivePolicy struct {
+	Name string `json:"name"`
+	// Bands of distance of the eligible journeys, the first band containing a journey gives its amount
+	DistanceBands []IncentiveDistanceBand `json:"distance-bands"`
+	// Time windows of the eligible journeys. Every time is eligible when empty
+	TimeWindows []IncentiveTimeWindow `json:"time-windows"`
+	Perimeter   IncentivePerimeter    `json:"perimeter"`
+	// Maximum number of paid journeys of a trip by day and by month. No cap when 0.
+	// The journeys of a trip start on the same day, so the monthly cap only matters below the daily cap
+	DailyCap   int64 `json:"daily-cap"`
+	MonthlyCap int64 `json:"monthly-cap"`
+}
+
+// Validate checks the rules of a policy
+func (p *IncentivePolicy) Validate() error {
+	if len(p.DistanceBands) == 0 {
+		return fmt.Errorf("an incentive policy needs at least one distance band")
+	}
+	for _, band := range p.DistanceBands {
+		if band.MinDistance < 0 || band.MaxDistance < 0 || (band.MaxDistance > 0 && band.MaxDistance <= band.MinDistance) {
+			return fmt.Errorf("wrong distance band [%d, %d[", band.MinDistance, band.MaxDistance)
+		}
+		if band.Fixed < 0 || band.PerKm < 0 {
+			return fmt.Errorf("the amounts of the distance band [%d, %d[ can't be negative", band.MinDistance, band.MaxDistance)
+		}
+	}
+	for _, window := range p.TimeWindows {
+		for _, weekday := range window.Weekdays {
+			if weekday < 1 || weekday > 7 {
+				return fmt.Errorf("wrong weekday %d (from 1 for Monday to 7 for Sunday)", weekday)
+			}
+		}
+		if _, err := windowMinutes(window.Start); err != nil {
+			return err
+		}
+		if _, err := windowMinutes(window.End); err != nil {
+			return err
+		}
+	}
+	if p.DailyCap < 0 || p.MonthlyCap < 0 {
+		return fmt.Errorf("the caps of an incentive policy can't be negative")
+	}
+	if p.DailyCap > 0 && p.MonthlyCap >= p.DailyCap {
+		return fmt.Errorf("the monthly cap %d must be lower than the daily cap %d: the journeys of a trip start on the same day", p.MonthlyCap, p.DailyCap)
+	}
+	return nil
+}
+
+// Amount returns the amount given to a journey, before the caps, and false when the journey isn't eligible
+//
+// @param journey - Journey to check
+// @param location - Location of the time windows
+func (p *IncentivePolicy) Amount(journey *Journey, location *time.Location) (float64, bool) {
+	if !p.insidePerimeter(journey) || !p.insideTimeWindows(journey, location) {
+		return 0, false
+	}
+	for _, band := range p.DistanceBands {
+		if journey.JourneyDistance >= band.MinDistance && (band.MaxDistance == 0 || journey.JourneyDistance < band.MaxDistance) {
+			amount := band.Fixed + band.PerKm*float64(journey.JourneyDistance)/1000
+			return math.Round(amount*100) / 100, true
+		}
+	}
+	return 0, false
+}
+
+func (p *IncentivePolicy) insidePerimeter(journey *Journey) bool {
+	perimeter := &p.Perimeter
+	if len(perimeter.Insees) == 0 && len(perimeter.Departments) == 0 {
+		return true
+	}
+	inside := func(insee int64, department string) bool {
+		for _, i := range perimeter.Insees {
+			if i == insee {
+				return true
+			}
+		}
+		return Contains(perimeter.Departments, department)
+	}
+
+	start := inside(journey.JourneyStartInsee, journey.JourneyStartDepartment)
+	end := inside(journey.JourneyEndInsee, journey.JourneyEndDepartment)
+	if perimeter.Both {
+		return start && end
+	}
+	return start || end
+}
+
+func (p *IncentivePolicy) insideTimeWindows(journey *Journey, location *time.Location) bool {
+	if len(p.TimeWindows) == 0 {
+		return true
+	}
+	if journey.JourneyStartDatetime.IsZero() {
+		return false
+	}
+
+	start := journey.JourneyStartDatetime.In(location)
+	weekday := int(start.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	minutes := start.Hour()*60 + start.Minute()
+	for _, window := range p.TimeWindows {
+		if len(window.Weekdays) > 0 && !containsInt(window.Weekdays, weekday) {
+			continue
+		}
+		from, _ := windowMinutes(window.Start)
+		to, _ := windowMinutes(window.End)
+		if to == 0 {
+			to = 24 * 60
+		}
+		if minutes >= from && minutes < to {
+			return true
+		}
+	}
+	return false
+}
+
+// windowMinutes gives the number of minutes since midnight of a HH:MM time, 0 when empty
+func windowMinutes(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("wrong time '%s' of a time window (expected: HH:MM)", value)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Journey paid by an incentive policy
+type IncentiveJourney struct {
+	JourneyId     int64
+	TripId        uuid.UUID
+	StartDatetime time.Time
+	Day           string
+	Commune       string
+	Amount        float64
+}
+
+// Number of paid journeys and cost of a bucket of a simulation
+type IncentiveBreakdown struct {
+	Key        string
+	NbJourneys int64
+	Cost       float64
+}
+
+// Result of the simulation of an incentive policy
+type IncentiveSimulationResult struct {
+	NbJourneys int64
+	NbEligible int64
+	NbCapped   int64
+	NbPaid     int64
+	TotalCost  float64
+	ByMonth    []IncentiveBreakdown
+	ByCommune  []IncentiveBreakdown
+	// Page of the paid journeys, in the order of their starts
+	Journeys []IncentiveJourney
+}
+
+// Number of paid journeys of a trip during a day or a month
+type tripPeriod struct {
+	tripId uuid.UUID
+	period string
+}
+
+// Simulation of an incentive policy over journeys
+type IncentiveSimulation struct {
+	policy   *IncentivePolicy
+	location *time.Location
+	offset   int64
+	limit    int64
+	result   IncentiveSimulationResult
+	cost     float64
+	months   map[string]*IncentiveBreakdown
+	communes map[string]*IncentiveBreakdown
+	// Paid journeys of the trips, by day and by month. Periods before the current one are dropped
+	byDay        map[tripPeriod]int64
+	byMonth      map[tripPeriod]int64
+	currentDay   string
+	currentMonth string
+}
+
+// NewIncentiveSimulation starts the simulation of a policy.
+// Only the counters of the current day and month and the requested page of the paid journeys are kept.
+//
+// @param policy - Validated policy to simulate
+// @param location - Location of the time windows, of the days and of the months
+// @param offset - Number of paid journeys skipped before the page kept in the result
+// @param limit - Number of paid journeys of the page kept in the result, 0 keeping none
+func NewIncentiveSimulation(policy *IncentivePolicy, location *time.Location, offset int64, limit int64) *IncentiveSimulation {
+	return &IncentiveSimulation{
+		policy:   policy,
+		location: location,
+		offset:   offset,
+		limit:    limit,
+		result:   IncentiveSimulationResult{Journeys: []IncentiveJourney{}},
+		months:   map[string]*IncentiveBreakdown{},
+		communes: map[string]*IncentiveBreakdown{},
+		byDay:    map[tripPeriod]int64{},
+		byMonth:  map[tripPeriod]int64{},
+	}
+}
+
+// Add checks a journey against the rules of the policy, then against its caps. The caps go to the earliest journeys,
+// so journeys must be added in the order of their starts, then of their ids, those without a start time first.
+// The journey is returned when it is paid.
+//
+// @param journey - Journey to check
+func (s *IncentiveSimulation) Add(journey *Journey) (*IncentiveJourney, bool) {
+	s.result.NbJourneys++
+	amount, ok := s.policy.Amount(journey, s.location)
+	if !ok {
+		return nil, false
+	}
+	s.result.NbEligible++
+
+	commune := JourneySplitUnknownKey
+	if journey.JourneyStartInsee != 0 {
+		commune = strconv.FormatInt(journey.JourneyStartInsee, 10)
+	}
+	paid := IncentiveJourney{
+		JourneyId:     journey.JourneyId,
+		TripId:        journey.TripId,
+		StartDatetime: journey.JourneyStartDatetime,
+		Day:           JourneyDay(journey, s.location),
+		Commune:       commune,
+		Amount:        amount,
+	}
+	month := JourneySplitUnknownKey
+	if len(paid.Day) >= 7 {
+		month = paid.Day[:7]
+	}
+	// The journeys without a start time come first, with days in any order
+	if !paid.StartDatetime.IsZero() {
+		s.currentDay = dropPastPeriods(s.byDay, s.currentDay, paid.Day)
+		s.currentMonth = dropPastPeriods(s.byMonth, s.currentMonth, month)
+	}
+
+	day := tripPeriod{paid.TripId, paid.Day}
+	tripMonth := tripPeriod{paid.TripId, month}
+	if (s.policy.DailyCap > 0 && s.byDay[day] >= s.policy.DailyCap) ||
+		(s.policy.MonthlyCap > 0 && s.byMonth[tripMonth] >= s.policy.MonthlyCap) {
+		s.result.NbCapped++
+		return nil, false
+	}
+	s.byDay[day]++
+	s.byMonth[tripMonth]++
+
+	if s.result.NbPaid >= s.offset && s.result.NbPaid < s.offset+s.limit {
+		s.result.Journeys = append(s.result.Journeys, paid)
+	}
+	s.result.NbPaid++
+	s.cost += paid.Amount
+	addIncentiveBreakdown(s.months, month, paid.Amount)
+	addIncentiveBreakdown(s.communes, paid.Commune, paid.Amount)
+	return &paid, true
+}
+
+// dropPastPeriods removes the counters of the periods before a new current period, and returns the current period
+func dropPastPeriods(counters map[tripPeriod]int64, current string, period string) string {
+	if period <= current {
+		return current
+	}
+	for key := range counters {
+		if key.period < period {
+			delete(counters, key)
+		}
+	}
+	return period
+}
+
+// Result sums the cost of the paid journeys by month and by commune of start
+func (s *IncentiveSimulation) Result() *IncentiveSimulationResult {
+	result := s.result
+	result.TotalCost = math.Round(s.cost*100) / 100
+	result.ByMonth = sortedIncentiveBreakdowns(s.months)
+	result.ByCommune = sortedIncentiveBreakdowns(s.communes)
+	return &result
+}
+
+func addIncentiveBreakdown(breakdowns map[string]*IncentiveBreakdown, key string, amount float64) {
+	breakdown, ok := breakdowns[key]
+	if !ok {
+		breakdown = &IncentiveBreakdown{Key: key}
+		breakdowns[key] = breakdown
+	}
+	breakdown.NbJourneys++
+	breakdown.Cost += amount
+}
+
+func sortedIncentiveBreakdowns(breakdowns map[string]*IncentiveBreakdown) []IncentiveBreakdown {
+	sorted := make([]IncentiveBreakdown, 0, len(breakdowns))
+	for _, breakdown := range breakdowns {
+		breakdown.Cost = math.Round(breakdown.Cost*100) / 100
+		sorted = append(sorted, *breakdown)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	return sorted
+}
@@ -0,0 +1,164 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// policy of 2€ + 0.10€/km for journeys between 5 and 50 km, capped at 2 per day
+func incentivePolicy() *domain.IncentivePolicy {
+	return &domain.IncentivePolicy{
+		Name:          "test",
+		DistanceBands: []domain.IncentiveDistanceBand{{MinDistance: 5000, MaxDistance: 50000, Fixed: 2, PerKm: 0.1}},
+		DailyCap:      2,
+	}
+}
+
+func TestIncentivePolicy_Validate(t *testing.T) {
+	assert.NoError(t, incentivePolicy().Validate())
+
+	var tests = []struct {
+		name   string
+		change func(policy *domain.IncentivePolicy)
+	}{
+		{"no_band", func(p *domain.IncentivePolicy) { p.DistanceBands = nil }},
+		{"empty_band", func(p *domain.IncentivePolicy) { p.DistanceBands[0].MaxDistance = 5000 }},
+		{"negative_amount", func(p *domain.IncentivePolicy) { p.DistanceBands[0].PerKm = -1 }},
+		{"wrong_weekday", func(p *domain.IncentivePolicy) {
+			p.TimeWindows = []domain.IncentiveTimeWindow{{Weekdays: []int{0}}}
+		}},
+		{"wrong_time", func(p *domain.IncentivePolicy) {
+			p.TimeWindows = []domain.IncentiveTimeWindow{{Start: "7h"}}
+		}},
+		{"negative_cap", func(p *domain.IncentivePolicy) { p.MonthlyCap = -1 }},
+		{"monthly_cap_not_below_daily_cap", func(p *domain.IncentivePolicy) { p.MonthlyCap = 2 }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy := incentivePolicy()
+			test.change(policy)
+			assert.Error(t, policy.Validate())
+		})
+	}
+}
+
+func TestIncentivePolicy_Amount(t *testing.T) {
+	monday := time.Date(2023, 1, 2, 8, 0, 0, 0, time.UTC)
+	policy := incentivePolicy()
+	policy.TimeWindows = []domain.IncentiveTimeWindow{{Weekdays: []int{1, 2, 3, 4, 5}, Start: "07:00", End: "09:30"}}
+	policy.Perimeter = domain.IncentivePerimeter{Insees: []int64{35238}, Departments: []string{"22"}}
+
+	var tests = []struct {
+		name           string
+		journey        domain.Journey
+		expectedAmount float64
+		expectedOk     bool
+	}{
+		{"eligible", domain.Journey{JourneyDistance: 12345, JourneyStartInsee: 35238, JourneyStartDatetime: monday}, 3.23, true},
+		{"end_in_perimeter", domain.Journey{JourneyDistance: 5000, JourneyEndDepartment: "22", JourneyStartDatetime: monday}, 2.5, true},
+		{"outside_perimeter", domain.Journey{JourneyDistance: 12345, JourneyStartInsee: 35047, JourneyStartDatetime: monday}, 0, false},
+		{"too_short", domain.Journey{JourneyDistance: 4999, JourneyStartInsee: 35238, JourneyStartDatetime: monday}, 0, false},
+		{"too_long", domain.Journey{JourneyDistance: 50000, JourneyStartInsee: 35238, JourneyStartDatetime: monday}, 0, false},
+		{"too_late", domain.Journey{JourneyDistance: 12345, JourneyStartInsee: 35238, JourneyStartDatetime: monday.Add(90 * time.Minute)}, 0, false},
+		{"sunday", domain.Journey{JourneyDistance: 12345, JourneyStartInsee: 35238, JourneyStartDatetime: monday.AddDate(0, 0, -1)}, 0, false},
+		{"unknown_start", domain.Journey{JourneyDistance: 12345, JourneyStartInsee: 35238}, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			amount, ok := policy.Amount(&test.journey, time.UTC)
+			assert.Equal(t, test.expectedOk, ok)
+			assert.Equal(t, test.expectedAmount, amount)
+		})
+	}
+
+	policy.Perimeter.Both = true
+	_, ok := policy.Amount(&domain.Journey{JourneyDistance: 5000, JourneyEndDepartment: "22", JourneyStartDatetime: monday}, time.UTC)
+	assert.False(t, ok)
+}
+
+func TestIncentiveSimulation(t *testing.T) {
+	trip := uuid.New()
+	otherTrip := uuid.New()
+	start := time.Date(2023, 1, 31, 8, 0, 0, 0, time.UTC)
+	// In the order of their starts
+	journeys := []domain.Journey{
+		{JourneyId: 1, TripId: trip, JourneyDistance: 10000, JourneyStartInsee: 35238, JourneyStartDatetime: start},
+		{JourneyId: 2, TripId: trip, JourneyDistance: 20000, JourneyStartInsee: 35238, JourneyStartDatetime: start},
+		{JourneyId: 5, TripId: otherTrip, JourneyDistance: 1000, JourneyStartDatetime: start},
+		{JourneyId: 3, TripId: trip, JourneyDistance: 10000, JourneyStartInsee: 35238, JourneyStartDatetime: start.Add(time.Hour)},
+		{JourneyId: 4, TripId: otherTrip, JourneyDistance: 10000, JourneyStartDatetime: start.AddDate(0, 0, 1)},
+	}
+
+	simulation := domain.NewIncentiveSimulation(incentivePolicy(), time.UTC, 0, 10)
+	nbPaid := 0
+	for i := range journeys {
+		if _, ok := simulation.Add(&journeys[i]); ok {
+			nbPaid++
+		}
+	}
+	result := simulation.Result()
+
+	assert.Equal(t, 3, nbPaid)
+	assert.Equal(t, int64(5), result.NbJourneys)
+	assert.Equal(t, int64(4), result.NbEligible)
+	assert.Equal(t, int64(1), result.NbCapped)
+	assert.Equal(t, int64(3), result.NbPaid)
+	assert.Equal(t, 10.0, result.TotalCost)
+	assert.Equal(t, []domain.IncentiveBreakdown{
+		{Key: "2023-01", NbJourneys: 2, Cost: 7},
+		{Key: "2023-02", NbJourneys: 1, Cost: 3},
+	}, result.ByMonth)
+	assert.Equal(t, []domain.IncentiveBreakdown{
+		{Key: "35238", NbJourneys: 2, Cost: 7},
+		{Key: domain.JourneySplitUnknownKey, NbJourneys: 1, Cost: 3},
+	}, result.ByCommune)
+
+	paid := []int64{}
+	for _, journey := range result.Journeys {
+		paid = append(paid, journey.JourneyId)
+	}
+	assert.Equal(t, []int64{1, 2, 4}, paid)
+}
+
+func TestIncentiveSimulation_page(t *testing.T) {
+	start := time.Date(2023, 1, 31, 8, 0, 0, 0, time.UTC)
+	simulation := domain.NewIncentiveSimulation(incentivePolicy(), time.UTC, 1, 2)
+	for i := int64(1); i <= 4; i++ {
+		simulation.Add(&domain.Journey{JourneyId: i, TripId: uuid.New(), JourneyDistance: 10000, JourneyStartDatetime: start})
+	}
+	result := simulation.Result()
+
+	assert.Equal(t, int64(4), result.NbPaid)
+	assert.Equal(t, 12.0, result.TotalCost)
+	assert.Len(t, result.Journeys, 2)
+	assert.Equal(t, int64(2), result.Journeys[0].JourneyId)
+	assert.Equal(t, int64(3), result.Journeys[1].JourneyId)
+}
+
+func TestIncentiveSimulation_capsAcrossDays(t *testing.T) {
+	trip := uuid.New()
+	start := time.Date(2023, 1, 30, 8, 0, 0, 0, time.UTC)
+	policy := incentivePolicy()
+	policy.DailyCap = 0
+	policy.MonthlyCap = 2
+	simulation := domain.NewIncentiveSimulation(policy, time.UTC, 0, 10)
+	// Without a start time, then day after day: the monthly cap is kept over the days and reset by the next month
+	simulation.Add(&domain.Journey{JourneyId: 1, TripId: trip, JourneyDistance: 10000, JourneyStartDate: start})
+	simulation.Add(&domain.Journey{JourneyId: 2, TripId: trip, JourneyDistance: 10000, JourneyStartDatetime: start})
+	simulation.Add(&domain.Journey{JourneyId: 3, TripId: trip, JourneyDistance: 10000, JourneyStartDatetime: start.AddDate(0, 0, 1)})
+	simulation.Add(&domain.Journey{JourneyId: 4, TripId: trip, JourneyDistance: 10000, JourneyStartDatetime: start.AddDate(0, 0, 2)})
+	result := simulation.Result()
+
+	assert.Equal(t, int64(1), result.NbCapped)
+	assert.Equal(t, []domain.IncentiveBreakdown{
+		{Key: "2023-01", NbJourneys: 2, Cost: 6},
+		{Key: "2023-02", NbJourneys: 1, Cost: 3},
+	}, result.ByMonth)
+}
@@ -2,11 +2,13 @@
 package domain
 
 import (
+	"context"
 	"io"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/paulmach/orb"
 )
 
 type Journey struct {
@@ -15,35 +17,122 @@ type Journey struct {
 	JourneyStartDatetime   time.Time
 	JourneyStartDate       time.Time
 	JourneyStartTime       time.Time
-	JourneyStartLon        uint64
-	JourneyStartLat        uint64
+	JourneyStartLon        float64
+	JourneyStartLat        float64
 	JourneyStartInsee      int64
 	JourneyStartPostalcode string
 	JourneyStartDepartment string
 	JourneyStartTown       string
 	JourneyStartTowngroup  string
 	JourneyStartCountry    string
+	JourneyStartRegion     string
+	JourneyStartEpci       string
+	JourneyStartPopulation int64
 	JourneyEndDatetime     time.Time
 	JourneyEndDate         time.Time
 	JourneyEndTime         time.Time
-	JourneyEndLon          uint64
-	JourneyEndLat          uint64
+	JourneyEndLon          float64
+	JourneyEndLat          float64
 	JourneyEndInsee        int64
 	JourneyEndPostalcode   string
 	JourneyEndDepartment   string
 	JourneyEndTown         string
 	JourneyEndTowngroup    string
 	JourneyEndCountry      string
+	JourneyEndRegion       string
+	JourneyEndEpci         string
+	JourneyEndPopulation   int64
 	PassengerSeats         int16
 	OperatorClass          string
 	JourneyDistance        int64
 	JourneyDuration        int64
 	HasIncentive           bool
+	Flags                  []string
+	VehicleKmAvoided       float64
+	Co2Saved               float64
+	EmissionFactorsVersion string
+	Anomalies              []string
+	AnomalyScore           float64
+	JourneyStartWeekday    int64
+	JourneyStartIsoWeek    string
+	IsPublicHoliday        bool
+	SchoolZone             string
+	IsSchoolHoliday        bool
+	StartZones             []string
+	EndZones               []string
+}
+
+// Ends of a journey
+const (
+	LocationStart = "start"
+	LocationEnd   = "end"
+)
+
+// Start and end of a stored journey, nil when unknown
+type JourneyLocation struct {
+	Start *orb.Point
+	End   *orb.Point
+}
+
+// Point returns the start or the end of the journey, nil when unknown
+//
+// @param side - LocationStart or LocationEnd
+func (l *JourneyLocation) Point(side string) *orb.Point {
+	if side == LocationEnd {
+		return l.End
+	}
+	return l.Start
+}
+
+// Formats of the journey files, as registered in the parser registry
+const (
+	JourneyFormatCSV     = "csv"
+	JourneyFormatParquet = "parquet"
+	JourneyFormatJSON    = "json"
+)
+
+// Commune as described by the INSEE official geographic code (COG)
+type Commune struct {
+	Insee      string
+	Name       string
+	Department string
+	Region     string
+	Epci       string
+	Population int64
 }
 
 // Repository to manage journey entities
 type JourneyRepositoryInterface interface {
-	Add(c *gin.Context, journeys []Journey) (int, error)
+	// Add stores journeys and returns the ones stored as new documents: the journeys whose id is already stored are skipped
+	Add(c *gin.Context, journeys []Journey) ([]Journey, error)
+	// Find sends the journeys matching the filter to the channel, and closes it when done
+	Find(ctx context.Context, filter *JourneyFilter, journeyChan chan<- *Journey) error
+	// FindByStart sends the journeys matching the filter to the channel by start, then by id, and closes it when done
+	FindByStart(ctx context.Context, filter *JourneyFilter, journeyChan chan<- *Journey) error
+	// Count returns the number of journeys matching the filter
+	Count(ctx context.Context, filter *JourneyFilter) (int64, error)
+	// ODMatrix counts the journeys matching the filter by origin and destination, at a geographic level
+	ODMatrix(ctx context.Context, level string, filter *JourneyFilter) ([]ODPair, error)
+	// Stats counts the journeys matching the filter by bucket of a statistic
+	Stats(ctx context.Context, stat string, filter *JourneyFilter) ([]StatBucket, error)
+	// FindTrips sends the trips of the journeys matching the filter to the channel, sorted by TripId, and closes it when done.
+	// A limit of 0 sends all the trips
+	FindTrips(ctx context.Context, filter *JourneyFilter, offset int64, limit int64, tripChan chan<- *Trip) error
+	// Co2 sums the passenger-km of the journeys matching the filter by value of a dimension, or in total for an empty dimension
+	Co2(ctx context.Context, dimension string, filter *JourneyFilter) ([]Co2Bucket, error)
+	// ReplaceAnomalies clears the anomalies of the journeys matching the filter, then stores the given ones
+	ReplaceAnomalies(ctx context.Context, filter *JourneyFilter, anomalies []JourneyAnomalies) error
+	// FindAnomalies returns the journeys matching the filter with an anomaly, or with the given one, by decreasing score
+	FindAnomalies(ctx context.Context, filter *JourneyFilter, anomaly string, offset int64, limit int64) ([]Journey, error)
+	// TagZone sets a zone on the stored journeys starting or ending in it, as TagZones does at their import,
+	// and removes it from the other ones
+	TagZone(ctx context.Context, zone *Zone) error
+	// UntagZone removes a zone from the stored journeys
+	UntagZone(ctx context.Context, name string) error
+	// BackfillLocations sets the locations of the journeys stored before them, returning the number of locations set
+	BackfillLocations(ctx context.Context) (int64, error)
+	// FindLocations sends the start and end of the journeys matching the filter to the channel, and closes it when done
+	FindLocations(ctx context.Context, filter *JourneyFilter, locationChan chan<- *JourneyLocation) error
 }
 
 // Parser to deserialize a journey
@@ -51,7 +140,44 @@ type JourneyParser interface {
 	Parse(reader io.Reader, journeyChan chan<- *Journey, errorChan chan<- string)
 }
 
+// Journey file to import
+type JourneyFile struct {
+	Name        string
+	ContentType string
+	Reader      io.Reader
+}
+
+// Registry of the journey parsers, choosing the parser of a file
+type JourneyParserRegistry interface {
+	// Select returns the format and the parser of a file. The reader of the file is replaced when its first bytes had to be buffered
+	Select(file *JourneyFile) (string, JourneyParser, error)
+}
+
+// Reference of the communes, indexed by INSEE code
+type CommuneReferential interface {
+	Get(insee int64) (*Commune, bool)
+}
+
 // Usecases for a journey
 type JourneyUsecase interface {
-	ImportFromCSVFile(c *gin.Context, reader io.Reader) (int64, []string)
+	ImportFromFile(c *gin.Context, file *JourneyFile, filter *JourneyFilter) (*ImportSummary, []string)
+	Exporter(format string) (JourneyExporter, error)
+	Export(c *gin.Context, exporter JourneyExporter, filter *JourneyFilter, writer io.Writer) (int64, error)
+	Split(c *gin.Context, file *JourneyFile, dimension string, exporter JourneyExporter, writer io.Writer) (*SplitSummary, error)
+	ODMatrix(c *gin.Context, level string, filter *JourneyFilter) ([]ODPair, error)
+	Stats(c *gin.Context, stat string, filter *JourneyFilter) ([]StatBucket, error)
+	Trips(c *gin.Context, filter *JourneyFilter, offset int64, limit int64) ([]Trip, error)
+	TripExporter(format string) (TripExporter, error)
+	ExportTrips(c *gin.Context, exporter TripExporter, filter *JourneyFilter, writer io.Writer) (int64, error)
+	Co2(c *gin.Context, dimension string, version string, filter *JourneyFilter) (*Co2Report, error)
+	SimulateIncentive(c *gin.Context, policy *IncentivePolicy, filter *JourneyFilter, offset int64, limit int64, paid func(*IncentiveJourney) error) (*IncentiveSimulationResult, error)
+	DetectAnomalies(c *gin.Context, filter *JourneyFilter) (*AnomalySummary, error)
+	Anomalies(c *gin.Context, filter *JourneyFilter, anomaly string, offset int64, limit int64) ([]Journey, error)
+	CreateZone(c *gin.Context, zone *Zone) error
+	Zones(c *gin.Context) ([]Zone, error)
+	Zone(c *gin.Context, name string) (*Zone, error)
+	UpdateZone(c *gin.Context, zone *Zone) error
+	DeleteZone(c *gin.Context, name string) error
+	Heatmap(c *gin.Context, request *HeatmapRequest, filter *JourneyFilter) ([]HeatmapCell, error)
+	Tile(c *gin.Context, request *TileRequest, filter *JourneyFilter) ([]byte, error)
 }
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"fmt"
+)
+
+// Geographic levels of the origins and destinations of an OD matrix
+const (
+	ODLevelInsee      = "insee"
+	ODLevelDepartment = "department"
+	ODLevelTowngroup  = "towngroup"
+	ODLevelCountry    = "country"
+)
+
+// ODLevels lists the geographic levels of an OD matrix
+var ODLevels = []string{ODLevelInsee, ODLevelDepartment, ODLevelTowngroup, ODLevelCountry}
+
+// Flow of journeys between an origin and a destination
+type ODPair struct {
+	Origin          string
+	Destination     string
+	NbJourneys      int64
+	PassengerSeats  int64
+	AverageDistance float64
+	AverageDuration float64
+}
+
+// ValidateODLevel checks that a geographic level is known
+//
+// @param level - Level of an OD matrix
+func ValidateODLevel(level string) error {
+	for _, l := range ODLevels {
+		if l == level {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown level '%s' (available: %s, %s, %s, %s)", level, ODLevelInsee, ODLevelDepartment, ODLevelTowngroup, ODLevelCountry)
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Step of the journey processing pipeline, run between the parser and the repository
+type JourneyProcessor interface {
+	// Process transforms, enriches or tags a journey. It returns false to drop the journey
+	Process(journey *Journey) (bool, error)
+}
+
+// Processing step identified by the name used in the configuration
+type NamedJourneyProcessor struct {
+	Name      string
+	Processor JourneyProcessor
+}
+
+// Counters of a processing step during an import
+type ProcessorStats struct {
+	Name        string
+	NbProcessed int64
+	NbDropped   int64
+	NbErrors    int64
+}
+
+// Summary of a file import
+type ImportSummary struct {
+	Format               string
+	NbJourneyImported    int64
+	NbJourneyFilteredOut int64
+	Processors           []ProcessorStats
+}
+
+// Ordered chain of processing steps
+type JourneyProcessorChain struct {
+	steps []NamedJourneyProcessor
+}
+
+// Run of a processing chain during one import, counting the journeys processed by each step
+type JourneyProcessorRun struct {
+	chain       *JourneyProcessorChain
+	nbProcessed []atomic.Int64
+	nbDropped   []atomic.Int64
+	nbErrors    []atomic.Int64
+}
+
+// NewJourneyProcessorChain creates a processing chain.
+//
+// @param steps - Steps run in order on each journey
+func NewJourneyProcessorChain(steps ...NamedJourneyProcessor) *JourneyProcessorChain {
+	return &JourneyProcessorChain{
+		steps: steps,
+	}
+}
+
+// Names returns the names of the steps of the chain
+func (chain *JourneyProcessorChain) Names() []string {
+	names := make([]string, len(chain.steps))
+	for i, step := range chain.steps {
+		names[i] = step.Name
+	}
+	return names
+}
+
+// NewRun starts a run of the chain. A run is safe for concurrent use.
+func (chain *JourneyProcessorChain) NewRun() *JourneyProcessorRun {
+	return &JourneyProcessorRun{
+		chain:       chain,
+		nbProcessed: make([]atomic.Int64, len(chain.steps)),
+		nbDropped:   make([]atomic.Int64, len(chain.steps)),
+		nbErrors:    make([]atomic.Int64, len(chain.steps)),
+	}
+}
+
+// Process runs all the steps on a journey, stopping at the first step dropping it or failing.
+//
+// @param journey - Journey to process
+//
+// @return false if the journey has been dropped, an error if a step failed
+func (run *JourneyProcessorRun) Process(journey *Journey) (bool, error) {
+	for i, step := range run.chain.steps {
+		run.nbProcessed[i].Add(1)
+		keep, err := step.Processor.Process(journey)
+		if err != nil {
+			run.nbErrors[i].Add(1)
+			return false, fmt.Errorf("problem while processing journey %d in step %s: %w", journey.JourneyId, step.Name, err)
+		}
+		if !keep {
+			run.nbDropped[i].Add(1)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Stats returns the counters of each step
+func (run *JourneyProcessorRun) Stats() []ProcessorStats {
+	stats := make([]ProcessorStats, len(run.chain.steps))
+	for i, step := range run.chain.steps {
+		stats[i] = ProcessorStats{
+			Name:        step.Name,
+			NbProcessed: run.nbProcessed[i].Load(),
+			NbDropped:   run.nbDropped[i].Load(),
+			NbErrors:    run.nbErrors[i].Load(),
+		}
+	}
+	return stats
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Dimensions along which a journey file can be split
+const (
+	JourneySplitStartDepartment = "start-department"
+	JourneySplitEndDepartment   = "end-department"
+	JourneySplitMonth           = "month"
+	JourneySplitOperatorClass   = "operator-class"
+)
+
+// Key of the journeys whose value of the dimension is unknown
+const JourneySplitUnknownKey = "unknown"
+
+var journeySplitKeys = map[string]func(journey *Journey) string{
+	JourneySplitStartDepartment: func(journey *Journey) string { return journey.JourneyStartDepartment },
+	JourneySplitEndDepartment:   func(journey *Journey) string { return journey.JourneyEndDepartment },
+	JourneySplitMonth: func(journey *Journey) string {
+		start := journey.JourneyStartDatetime
+		if start.IsZero() {
+			start = journey.JourneyStartDate
+		}
+		if start.IsZero() {
+			return ""
+		}
+		return start.Format("2006-01")
+	},
+	JourneySplitOperatorClass: func(journey *Journey) string { return journey.OperatorClass },
+}
+
+// JourneySplitKey returns the part of a split holding a journey
+//
+// @param dimension - Dimension of the split, one of the JourneySplit constants
+// @param journey - Journey to dispatch
+func JourneySplitKey(dimension string, journey *Journey) (string, error) {
+	key, ok := journeySplitKeys[dimension]
+	if !ok {
+		return "", fmt.Errorf("unknown split dimension '%s' (available: %s, %s, %s, %s)", dimension,
+			JourneySplitStartDepartment, JourneySplitEndDepartment, JourneySplitMonth, JourneySplitOperatorClass)
+	}
+
+	value := strings.TrimSpace(key(journey))
+	if value == "" {
+		return JourneySplitUnknownKey, nil
+	}
+	return value, nil
+}
+
+// ValidateJourneySplitDimension checks that a dimension is known
+//
+// @param dimension - Dimension of a split
+func ValidateJourneySplitDimension(dimension string) error {
+	_, err := JourneySplitKey(dimension, &Journey{})
+	return err
+}
+
+// Part of a split file
+type SplitPart struct {
+	Key        string
+	Filename   string
+	NbJourneys int64
+}
+
+// Summary of the split of a file
+type SplitSummary struct {
+	Format     string
+	NbJourneys int64
+	Parts      []SplitPart
+	Errors     []string
+}
+
+// Splitter of journey files into one file per value of a dimension
+type JourneySplitter interface {
+	// Split reads the file once and writes a zip archive holding a file per value of the dimension, written by the exporter.
+	// The archive is written once the whole file is read: nothing is written when the file can't be read.
+	Split(file *JourneyFile, dimension string, exporter JourneyExporter, writer io.Writer) (*SplitSummary, error)
+}
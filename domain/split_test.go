@@ -0,0 +1,43 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJourneySplitKey(t *testing.T) {
+	journey := &domain.Journey{
+		JourneyStartDepartment: "35",
+		JourneyStartDatetime:   time.Date(2023, 1, 15, 8, 0, 0, 0, time.UTC),
+		OperatorClass:          "C",
+	}
+
+	var tests = []struct {
+		dimension   string
+		journey     *domain.Journey
+		expectedKey string
+	}{
+		{domain.JourneySplitStartDepartment, journey, "35"},
+		{domain.JourneySplitEndDepartment, journey, domain.JourneySplitUnknownKey},
+		{domain.JourneySplitMonth, journey, "2023-01"},
+		{domain.JourneySplitMonth, &domain.Journey{JourneyStartDate: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)}, "2023-02"},
+		{domain.JourneySplitMonth, &domain.Journey{}, domain.JourneySplitUnknownKey},
+		{domain.JourneySplitOperatorClass, journey, "C"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.dimension+"_"+test.expectedKey, func(t *testing.T) {
+			key, err := domain.JourneySplitKey(test.dimension, test.journey)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedKey, key)
+		})
+	}
+
+	_, err := domain.JourneySplitKey("town", journey)
+	assert.Error(t, err)
+	assert.Error(t, domain.ValidateJourneySplitDimension("town"))
+}
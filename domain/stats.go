@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Statistics computed over the stored journeys
+const (
+	StatPerDay           = "per-day"
+	StatPerWeek          = "per-week"
+	StatPerMonth         = "per-month"
+	StatPerHour          = "per-hour"
+	StatPerWeekday       = "per-weekday"
+	StatPerOperatorClass = "per-operator-class"
+	StatIncentive        = "incentive"
+	StatDistance         = "distance"
+	StatDuration         = "duration"
+	StatPassengerSeats   = "passenger-seats"
+	StatPerStartZone     = "per-start-zone"
+	StatPerEndZone       = "per-end-zone"
+	StatZoneFlow         = "zone-flow"
+)
+
+// Stats lists the statistics computed over the stored journeys
+var Stats = []string{
+	StatPerDay,
+	StatPerWeek,
+	StatPerMonth,
+	StatPerHour,
+	StatPerWeekday,
+	StatPerOperatorClass,
+	StatIncentive,
+	StatDistance,
+	StatDuration,
+	StatPassengerSeats,
+	StatPerStartZone,
+	StatPerEndZone,
+	StatZoneFlow,
+}
+
+// Boundaries, in meters, of the buckets of the distance histogram. A bucket holds the values from its boundary to the next one
+var StatDistanceBoundaries = []int64{0, 2000, 5000, 10000, 20000, 30000, 50000, 80000}
+
+// Boundaries, in minutes, of the buckets of the duration histogram. A bucket holds the values from its boundary to the next one
+var StatDurationBoundaries = []int64{0, 10, 20, 30, 45, 60, 90, 120}
+
+// Separator of the start zone and the end zone in the keys of the zone flows
+const StatZoneFlowSeparator = " > "
+
+// Key of the bucket of the values from the last boundary of a histogram
+const StatOverflowKey = "more"
+
+// Number of journeys of a bucket of a statistic
+type StatBucket struct {
+	Key        string
+	NbJourneys int64
+	// Share of the journeys of the statistic in the bucket, between 0 and 1
+	Share float64
+}
+
+// ValidateStat checks that a statistic is known
+//
+// @param stat - Name of a statistic
+func ValidateStat(stat string) error {
+	for _, s := range Stats {
+		if s == stat {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown statistic '%s' (available: %s)", stat, strings.Join(Stats, ", "))
+}
+
+// ComputeStatShares fills the share of each bucket in the total number of journeys
+//
+// @param buckets - Buckets of a statistic
+func ComputeStatShares(buckets []StatBucket) {
+	var total int64
+	for _, bucket := range buckets {
+		total += bucket.NbJourneys
+	}
+	if total == 0 {
+		return
+	}
+	for i := range buckets {
+		buckets[i].Share = float64(buckets[i].NbJourneys) / float64(total)
+	}
+}
@@ -0,0 +1,32 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeStatShares(t *testing.T) {
+	buckets := []domain.StatBucket{
+		{Key: "A", NbJourneys: 1},
+		{Key: "C", NbJourneys: 3},
+	}
+
+	domain.ComputeStatShares(buckets)
+
+	assert.Equal(t, 0.25, buckets[0].Share)
+	assert.Equal(t, 0.75, buckets[1].Share)
+
+	empty := []domain.StatBucket{{Key: "A"}}
+	domain.ComputeStatShares(empty)
+	assert.Equal(t, float64(0), empty[0].Share)
+}
+
+func TestValidateStat(t *testing.T) {
+	for _, stat := range domain.Stats {
+		assert.NoError(t, domain.ValidateStat(stat))
+	}
+	assert.Error(t, domain.ValidateStat("per-year"))
+}
@@ -0,0 +1,204 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
+)
+
+// Layers of the vector tiles
+const (
+	// Clusters of the starts of the journeys
+	TileLayerOrigins = "origins"
+	// Clusters of the ends of the journeys
+	TileLayerDestinations = "destinations"
+	// Lines between the clusters of the starts and of the ends of the journeys
+	TileLayerOD = "od"
+)
+
+// TileLayers lists the layers of the vector tiles
+var TileLayers = []string{TileLayerOrigins, TileLayerDestinations, TileLayerOD}
+
+// Deepest zoom level of the vector tiles, and of their clusters
+const (
+	MaxTileZoom    = 22
+	maxClusterZoom = 30
+)
+
+// ValidateClusterDepth checks the number of zoom levels between the tiles and their clusters
+//
+// @param depth - Number of zoom levels, see NewTileAggregator
+func ValidateClusterDepth(depth int) error {
+	if depth < 1 || depth > maxClusterZoom {
+		return fmt.Errorf("the cluster depth of the tiles must be from 1 to %d, got %d", maxClusterZoom, depth)
+	}
+	return nil
+}
+
+// ValidateTileMaxJourneys checks the maximum number of journeys clustered in a tile
+//
+// @param max - Number of journeys, a tile holding more of them is refused
+func ValidateTileMaxJourneys(max int64) error {
+	if max < 1 {
+		return fmt.Errorf("the maximum number of journeys of a tile must be positive, got %d", max)
+	}
+	return nil
+}
+
+// ValidateTileCacheSize checks the number of generated tiles kept in memory
+//
+// @param size - Number of tiles, 0 disables the cache
+func ValidateTileCacheSize(size int) error {
+	if size < 0 {
+		return fmt.Errorf("the number of cached tiles can't be negative, got %d", size)
+	}
+	return nil
+}
+
+// Vector tile of a layer
+type TileRequest struct {
+	// One of the TileLayer constants
+	Layer string
+	Tile  maptile.Tile
+}
+
+// Validate checks the layer and the coordinates of the tile
+func (r *TileRequest) Validate() error {
+	switch r.Layer {
+	case TileLayerOrigins, TileLayerDestinations, TileLayerOD:
+	default:
+		return fmt.Errorf("unknown layer '%s' (available: %s, %s, %s)", r.Layer, TileLayerOrigins, TileLayerDestinations, TileLayerOD)
+	}
+	if r.Tile.Z > MaxTileZoom {
+		return fmt.Errorf("the zoom level of a tile must be from 0 to %d", MaxTileZoom)
+	}
+	if !r.Tile.Valid() {
+		return fmt.Errorf("no tile %d/%d at zoom level %d", r.Tile.X, r.Tile.Y, r.Tile.Z)
+	}
+	return nil
+}
+
+// Error of a tile holding more journeys than allowed
+type TileTooLargeError struct {
+	MaxJourneys int64
+}
+
+func (e *TileTooLargeError) Error() string {
+	return fmt.Sprintf("too many journeys in the tile (max: %d), zoom in or narrow the criteria", e.MaxJourneys)
+}
+
+// Journeys grouped in a cluster: the sum and the number of their points
+type tileCluster struct {
+	start orb.Point
+	end   orb.Point
+	count int64
+}
+
+func (c *tileCluster) add(start orb.Point, end orb.Point) {
+	c.start = orb.Point{c.start[0] + start[0], c.start[1] + start[1]}
+	c.end = orb.Point{c.end[0] + end[0], c.end[1] + end[1]}
+	c.count++
+}
+
+func (c *tileCluster) mean(sum orb.Point) orb.Point {
+	return orb.Point{sum[0] / float64(c.count), sum[1] / float64(c.count)}
+}
+
+// TileAggregator clusters the journeys of a vector tile. The tile is split in the tiles of a deeper zoom level,
+// the journeys of each of them make a cluster placed at their mean position. Lines join the clusters
+// of the starts and of the ends of the journeys, they are drawn in the tiles of both clusters.
+type TileAggregator struct {
+	request  *TileRequest
+	bound    orb.Bound
+	zoom     maptile.Zoom
+	clusters map[[2]maptile.Tile]*tileCluster
+	keys     [][2]maptile.Tile
+}
+
+// NewTileAggregator creates an aggregator of the journeys of a tile
+//
+// @param request - Layer and coordinates of the tile
+// @param depth - Number of zoom levels between the tile and its clusters: a tile holds up to 4^depth clusters.
+// Must be checked by ValidateClusterDepth
+func NewTileAggregator(request *TileRequest, depth int) *TileAggregator {
+	zoom := request.Tile.Z + maptile.Zoom(depth)
+	if zoom > maxClusterZoom {
+		zoom = maxClusterZoom
+	}
+	return &TileAggregator{
+		request:  request,
+		bound:    request.Tile.Bound(),
+		zoom:     zoom,
+		clusters: map[[2]maptile.Tile]*tileCluster{},
+	}
+}
+
+// Contains checks if a point is in the tile
+//
+// @param point - Point to check
+func (a *TileAggregator) Contains(point *orb.Point) bool {
+	return point != nil && a.bound.Contains(*point)
+}
+
+// Add clusters a journey, ignored unless it belongs to the layer of the tile. It returns whether the journey is clustered.
+//
+// @param location - Start and end of the journey
+func (a *TileAggregator) Add(location *JourneyLocation) bool {
+	var key [2]maptile.Tile
+	start, end := orb.Point{}, orb.Point{}
+	switch a.request.Layer {
+	case TileLayerOrigins:
+		if !a.Contains(location.Start) {
+			return false
+		}
+		start = *location.Start
+		key[0] = maptile.At(start, a.zoom)
+	case TileLayerDestinations:
+		if !a.Contains(location.End) {
+			return false
+		}
+		end = *location.End
+		key[1] = maptile.At(end, a.zoom)
+	default:
+		if location.Start == nil || location.End == nil || (!a.Contains(location.Start) && !a.Contains(location.End)) {
+			return false
+		}
+		start, end = *location.Start, *location.End
+		key = [2]maptile.Tile{maptile.At(start, a.zoom), maptile.At(end, a.zoom)}
+	}
+
+	cluster, ok := a.clusters[key]
+	if !ok {
+		cluster = &tileCluster{}
+		a.clusters[key] = cluster
+		a.keys = append(a.keys, key)
+	}
+	cluster.add(start, end)
+	return true
+}
+
+// Result returns the clusters of the tile as points, or as lines for the OD layer, with their number of journeys
+// in the "count" property. Features are in the order their first journey was added.
+func (a *TileAggregator) Result() *geojson.FeatureCollection {
+	collection := geojson.NewFeatureCollection()
+	for _, key := range a.keys {
+		cluster := a.clusters[key]
+
+		var geometry orb.Geometry
+		switch a.request.Layer {
+		case TileLayerOrigins:
+			geometry = cluster.mean(cluster.start)
+		case TileLayerDestinations:
+			geometry = cluster.mean(cluster.end)
+		default:
+			geometry = orb.LineString{cluster.mean(cluster.start), cluster.mean(cluster.end)}
+		}
+
+		feature := geojson.NewFeature(geometry)
+		feature.Properties["count"] = cluster.count
+		collection.Append(feature)
+	}
+	return collection
+}
@@ -0,0 +1,80 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/maptile"
+	"github.com/stretchr/testify/assert"
+)
+
+// Tile of Rennes, at zoom level 10
+var rennesTile = maptile.At(orb.Point{-1.68, 48.11}, 10)
+
+func TestTileRequest_validate(t *testing.T) {
+	assert.NoError(t, (&domain.TileRequest{Layer: domain.TileLayerOrigins, Tile: rennesTile}).Validate())
+	assert.NoError(t, (&domain.TileRequest{Layer: domain.TileLayerOD, Tile: maptile.New(0, 0, 0)}).Validate())
+
+	assert.Error(t, (&domain.TileRequest{Layer: "trips", Tile: rennesTile}).Validate())
+	assert.Error(t, (&domain.TileRequest{Layer: domain.TileLayerOD, Tile: maptile.New(2, 0, 1)}).Validate())
+	assert.Error(t, (&domain.TileRequest{Layer: domain.TileLayerOD, Tile: maptile.New(0, 0, 23)}).Validate())
+}
+
+func TestValidateClusterDepth(t *testing.T) {
+	assert.NoError(t, domain.ValidateClusterDepth(1))
+	assert.NoError(t, domain.ValidateClusterDepth(30))
+
+	assert.Error(t, domain.ValidateClusterDepth(-1))
+	assert.Error(t, domain.ValidateClusterDepth(0))
+	assert.Error(t, domain.ValidateClusterDepth(31))
+}
+
+func TestValidateTileMaxJourneys(t *testing.T) {
+	assert.NoError(t, domain.ValidateTileMaxJourneys(1))
+
+	assert.Error(t, domain.ValidateTileMaxJourneys(0))
+	assert.Error(t, domain.ValidateTileMaxJourneys(-1))
+}
+
+func TestValidateTileCacheSize(t *testing.T) {
+	assert.NoError(t, domain.ValidateTileCacheSize(0))
+	assert.NoError(t, domain.ValidateTileCacheSize(10))
+
+	assert.Error(t, domain.ValidateTileCacheSize(-1))
+}
+
+func TestTileAggregator_points(t *testing.T) {
+	aggregator := domain.NewTileAggregator(&domain.TileRequest{Layer: domain.TileLayerDestinations, Tile: rennesTile}, 4)
+	assert.True(t, aggregator.Add(&domain.JourneyLocation{End: &orb.Point{-1.680, 48.110}}))
+	assert.True(t, aggregator.Add(&domain.JourneyLocation{End: &orb.Point{-1.682, 48.112}}))
+	aggregator.Add(&domain.JourneyLocation{Start: &orb.Point{-1.68, 48.11}, End: &orb.Point{-1.55, 47.21}})
+	assert.False(t, aggregator.Add(&domain.JourneyLocation{Start: &orb.Point{-1.68, 48.11}}))
+	aggregator.Add(&domain.JourneyLocation{End: &orb.Point{-1.45, 48.11}})
+
+	result := aggregator.Result()
+	assert.Len(t, result.Features, 2)
+	assert.InDelta(t, -1.681, result.Features[0].Geometry.(orb.Point).Lon(), 1e-9)
+	assert.InDelta(t, 48.111, result.Features[0].Geometry.(orb.Point).Lat(), 1e-9)
+	assert.Equal(t, int64(2), result.Features[0].Properties["count"])
+	assert.Equal(t, orb.Point{-1.45, 48.11}, result.Features[1].Geometry)
+	assert.Equal(t, int64(1), result.Features[1].Properties["count"])
+}
+
+func TestTileAggregator_od(t *testing.T) {
+	aggregator := domain.NewTileAggregator(&domain.TileRequest{Layer: domain.TileLayerOD, Tile: rennesTile}, 4)
+	aggregator.Add(&domain.JourneyLocation{Start: &orb.Point{-1.68, 48.11}, End: &orb.Point{-1.55, 47.21}})
+	aggregator.Add(&domain.JourneyLocation{Start: &orb.Point{-1.55, 47.21}, End: &orb.Point{-1.68, 48.11}})
+	aggregator.Add(&domain.JourneyLocation{Start: &orb.Point{-1.68, 48.11}, End: &orb.Point{-1.55, 47.21}})
+	assert.False(t, aggregator.Add(&domain.JourneyLocation{Start: &orb.Point{-1.68, 48.11}}))
+	assert.False(t, aggregator.Add(&domain.JourneyLocation{Start: &orb.Point{2.35, 48.85}, End: &orb.Point{-1.55, 47.21}}))
+
+	result := aggregator.Result()
+	assert.Len(t, result.Features, 2)
+	assert.Equal(t, orb.LineString{{-1.68, 48.11}, {-1.55, 47.21}}, result.Features[0].Geometry)
+	assert.Equal(t, int64(2), result.Features[0].Properties["count"])
+	assert.Equal(t, orb.LineString{{-1.55, 47.21}, {-1.68, 48.11}}, result.Features[1].Geometry)
+	assert.True(t, aggregator.Contains(&orb.Point{-1.68, 48.11}))
+	assert.False(t, aggregator.Contains(nil))
+}
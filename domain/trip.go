@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Largest gap between the starts of the journeys of a trip not reported as inconsistent
+const TripStartTolerance = 15 * time.Minute
+
+// Trip of a driver, gathering the journeys of its passengers sharing a TripId
+type Trip struct {
+	TripId         uuid.UUID
+	NbPassengers   int64
+	PassengerSeats int64
+	StartDatetime  time.Time
+	EndDatetime    time.Time
+	JourneyIds     []int64
+	Warnings       []string
+}
+
+// NewTrip gathers the journeys of a trip: a journey per passenger.
+// Warnings are given when the journeys disagree on their origin or their start time.
+//
+// @param journeys - Journeys sharing the same TripId. Must not be empty
+func NewTrip(journeys []Journey) *Trip {
+	trip := &Trip{
+		TripId:     journeys[0].TripId,
+		JourneyIds: make([]int64, 0, len(journeys)),
+		Warnings:   []string{},
+	}
+
+	origins := map[int64]bool{}
+	var firstStart, lastStart time.Time
+	for i := range journeys {
+		journey := &journeys[i]
+		trip.NbPassengers++
+		trip.PassengerSeats += int64(journey.PassengerSeats)
+		trip.JourneyIds = append(trip.JourneyIds, journey.JourneyId)
+		if journey.JourneyStartInsee != 0 {
+			origins[journey.JourneyStartInsee] = true
+		}
+
+		if start := journey.JourneyStartDatetime; !start.IsZero() {
+			if firstStart.IsZero() || start.Before(firstStart) {
+				firstStart = start
+			}
+			if lastStart.IsZero() || start.After(lastStart) {
+				lastStart = start
+			}
+		}
+		if end := journey.JourneyEndDatetime; !end.IsZero() && (trip.EndDatetime.IsZero() || end.After(trip.EndDatetime)) {
+			trip.EndDatetime = end
+		}
+	}
+	trip.StartDatetime = firstStart
+	sort.Slice(trip.JourneyIds, func(i, j int) bool { return trip.JourneyIds[i] < trip.JourneyIds[j] })
+
+	if len(origins) > 1 {
+		insees := make([]string, 0, len(origins))
+		for insee := range origins {
+			insees = append(insees, fmt.Sprint(insee))
+		}
+		sort.Strings(insees)
+		trip.Warnings = append(trip.Warnings, fmt.Sprintf("journeys start from different communes: %s", strings.Join(insees, ", ")))
+	}
+	if gap := lastStart.Sub(firstStart); gap > TripStartTolerance {
+		trip.Warnings = append(trip.Warnings, fmt.Sprintf("journeys start %s apart", gap))
+	}
+	return trip
+}
+
+// Writer of trips into an export file
+type TripExporter interface {
+	// ContentType returns the MIME type of the exported files
+	ContentType() string
+	// Extension returns the extension of the exported files, with its leading dot
+	Extension() string
+	// Export writes the trips received from the channel until it is closed and returns the number of exported trips
+	Export(writer io.Writer, tripChan <-chan *Trip) (int64, error)
+}
+
+// Registry of the trip exporters, by format
+type TripExporterRegistry interface {
+	Get(format string) (TripExporter, error)
+}
@@ -0,0 +1,53 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTrip(t *testing.T) {
+	tripId := uuid.New()
+	start := time.Date(2023, 1, 2, 8, 0, 0, 0, time.UTC)
+	journeys := []domain.Journey{
+		{JourneyId: 2, TripId: tripId, JourneyStartInsee: 35238, PassengerSeats: 2,
+			JourneyStartDatetime: start.Add(5 * time.Minute), JourneyEndDatetime: start.Add(50 * time.Minute)},
+		{JourneyId: 1, TripId: tripId, JourneyStartInsee: 35238, PassengerSeats: 1,
+			JourneyStartDatetime: start, JourneyEndDatetime: start.Add(40 * time.Minute)},
+	}
+
+	trip := domain.NewTrip(journeys)
+
+	assert.Equal(t, &domain.Trip{
+		TripId:         tripId,
+		NbPassengers:   2,
+		PassengerSeats: 3,
+		StartDatetime:  start,
+		EndDatetime:    start.Add(50 * time.Minute),
+		JourneyIds:     []int64{1, 2},
+		Warnings:       []string{},
+	}, trip)
+}
+
+func TestNewTrip_inconsistent(t *testing.T) {
+	start := time.Date(2023, 1, 2, 8, 0, 0, 0, time.UTC)
+	journeys := []domain.Journey{
+		{JourneyId: 1, JourneyStartInsee: 35238, JourneyStartDatetime: start},
+		{JourneyId: 2, JourneyStartInsee: 35047, JourneyStartDatetime: start.Add(time.Hour)},
+		{JourneyId: 3},
+	}
+
+	trip := domain.NewTrip(journeys)
+
+	assert.Equal(t, int64(3), trip.NbPassengers)
+	assert.Equal(t, start, trip.StartDatetime)
+	assert.True(t, trip.EndDatetime.IsZero())
+	assert.Equal(t, []string{
+		"journeys start from different communes: 35047, 35238",
+		"journeys start 1h0m0s apart",
+	}, trip.Warnings)
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/paulmach/orb"
+)
+
+// Errors of the zone repository
+var (
+	ErrZoneNotFound = errors.New("zone not found")
+	ErrZoneExists   = errors.New("zone already exists")
+)
+
+// Names of the zones, used in the URLs and in the statistics
+var zoneNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// Area defined by a client, such as a business park or a catchment area
+type Zone struct {
+	Name        string
+	Description string
+	// Polygon or MultiPolygon of the zone
+	Geometry orb.Geometry
+}
+
+// Repository of the zones
+type ZoneRepositoryInterface interface {
+	// Create stores a new zone, ErrZoneExists is returned when its name is taken
+	Create(ctx context.Context, zone *Zone) error
+	// Get returns a zone, ErrZoneNotFound is returned when it doesn't exist
+	Get(ctx context.Context, name string) (*Zone, error)
+	// List returns all the zones, sorted by name
+	List(ctx context.Context) ([]Zone, error)
+	// Update replaces a zone, ErrZoneNotFound is returned when it doesn't exist
+	Update(ctx context.Context, zone *Zone) error
+	// Delete removes a zone, ErrZoneNotFound is returned when it doesn't exist
+	Delete(ctx context.Context, name string) error
+}
+
+// Validate checks the name and the geometry of the zone
+func (z *Zone) Validate() error {
+	if !zoneNamePattern.MatchString(z.Name) {
+		return fmt.Errorf("invalid zone name '%s': up to 64 letters, digits, '-' or '_', starting with a letter or a digit", z.Name)
+	}
+	switch z.Geometry.(type) {
+	case orb.Polygon, orb.MultiPolygon:
+	default:
+		return fmt.Errorf("the geometry of the zone '%s' must be a Polygon or a MultiPolygon", z.Name)
+	}
+	if bound := z.Geometry.Bound(); bound.IsEmpty() {
+		return fmt.Errorf("the geometry of the zone '%s' is empty", z.Name)
+	} else if validatePoint(bound.Min) != nil || validatePoint(bound.Max) != nil {
+		return fmt.Errorf("the geometry of the zone '%s' has coordinates out of range", z.Name)
+	}
+	return nil
+}
+
+// Contains checks if a point is in the zone
+//
+// @param point - Point to check
+func (z *Zone) Contains(point orb.Point) bool {
+	return (&GeoPolygon{Geometry: z.Geometry}).Contains(point)
+}
+
+// TagZones sets the zones where a journey starts and ends. Journeys without coordinates are in no zone
+//
+// @param journey - Journey to tag
+// @param zones - Zones to check
+func TagZones(journey *Journey, zones []Zone) {
+	journey.StartZones = []string{}
+	journey.EndZones = []string{}
+
+	start, startOk := journeyStart(journey)
+	end, endOk := journeyEnd(journey)
+	for i := range zones {
+		if startOk && zones[i].Contains(start) {
+			journey.StartZones = append(journey.StartZones, zones[i].Name)
+		}
+		if endOk && zones[i].Contains(end) {
+			journey.EndZones = append(journey.EndZones, zones[i].Name)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+var vannes = orb.Polygon{{{-2.8, 47.6}, {-2.7, 47.6}, {-2.7, 47.7}, {-2.8, 47.7}, {-2.8, 47.6}}}
+
+func TestZone_validate(t *testing.T) {
+	assert.NoError(t, (&domain.Zone{Name: "vannes-centre", Geometry: vannes}).Validate())
+	assert.NoError(t, (&domain.Zone{Name: "ZA_1", Geometry: orb.MultiPolygon{vannes}}).Validate())
+
+	assert.Error(t, (&domain.Zone{Name: "", Geometry: vannes}).Validate())
+	assert.Error(t, (&domain.Zone{Name: "-vannes", Geometry: vannes}).Validate())
+	assert.Error(t, (&domain.Zone{Name: "vannes centre", Geometry: vannes}).Validate())
+	assert.Error(t, (&domain.Zone{Name: "vannes"}).Validate())
+	assert.Error(t, (&domain.Zone{Name: "vannes", Geometry: orb.Point{-2.75, 47.65}}).Validate())
+	assert.Error(t, (&domain.Zone{Name: "vannes", Geometry: orb.Polygon{}}).Validate())
+	assert.Error(t, (&domain.Zone{Name: "vannes", Geometry: orb.Polygon{{{-200, 47.6}, {-2.7, 47.6}, {-2.7, 47.7}, {-200, 47.6}}}}).Validate())
+}
+
+func TestTagZones(t *testing.T) {
+	zones := []domain.Zone{
+		{Name: "vannes", Geometry: vannes},
+		{Name: "golfe", Geometry: orb.MultiPolygon{{{{-3.0, 47.5}, {-2.6, 47.5}, {-2.6, 47.7}, {-3.0, 47.7}, {-3.0, 47.5}}}}},
+	}
+
+	journey := &domain.Journey{
+		JourneyStartLon: -2.75, JourneyStartLat: 47.65,
+		JourneyEndLon: -2.9, JourneyEndLat: 47.55,
+	}
+	domain.TagZones(journey, zones)
+	assert.Equal(t, []string{"vannes", "golfe"}, journey.StartZones)
+	assert.Equal(t, []string{"golfe"}, journey.EndZones)
+
+	journey = &domain.Journey{JourneyEndLon: -1.68, JourneyEndLat: 48.11}
+	domain.TagZones(journey, zones)
+	assert.Empty(t, journey.StartZones)
+	assert.Empty(t, journey.EndZones)
+}
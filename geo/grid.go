@@ -0,0 +1,211 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/paulmach/orb"
+)
+
+// GridCell is the position of a cell in a grid
+type GridCell struct {
+	X int
+	Y int
+}
+
+// Grid bins points into the cells of a regular tiling
+type Grid interface {
+	// Cell returns the cell containing a point
+	Cell(point orb.Point) GridCell
+	// Polygon returns the outline of a cell
+	Polygon(cell GridCell) orb.Polygon
+	// Name returns the identifier of a cell
+	Name(cell GridCell) string
+	// Count estimates the number of cells covering a bounding box
+	Count(bound orb.Bound) int64
+}
+
+// Alphabet of the geohashes
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// squareGrid is a grid of cells of the same size in meters at a reference latitude
+type squareGrid struct {
+	width  float64
+	height float64
+}
+
+// NewSquareGrid creates a grid of square cells. Cells are measured at a reference latitude
+// and get narrower away from it.
+//
+// @param size - Side of a cell, in meters. Must be positive
+// @param latitude - Reference latitude, usually the center of the mapped area
+func NewSquareGrid(size float64, latitude float64) Grid {
+	return &squareGrid{
+		width:  size / (MetersPerDegree * math.Cos(latitude*math.Pi/180)),
+		height: size / MetersPerDegree,
+	}
+}
+
+func (g *squareGrid) Cell(point orb.Point) GridCell {
+	return GridCell{
+		X: int(math.Floor(point.Lon() / g.width)),
+		Y: int(math.Floor(point.Lat() / g.height)),
+	}
+}
+
+func (g *squareGrid) Polygon(cell GridCell) orb.Polygon {
+	return orb.Bound{
+		Min: orb.Point{float64(cell.X) * g.width, float64(cell.Y) * g.height},
+		Max: orb.Point{float64(cell.X+1) * g.width, float64(cell.Y+1) * g.height},
+	}.ToPolygon()
+}
+
+func (g *squareGrid) Name(cell GridCell) string {
+	return fmt.Sprintf("%d_%d", cell.X, cell.Y)
+}
+
+func (g *squareGrid) Count(bound orb.Bound) int64 {
+	min := g.Cell(bound.Min)
+	max := g.Cell(bound.Max)
+	return int64(max.X-min.X+1) * int64(max.Y-min.Y+1)
+}
+
+// hexGrid is a grid of pointy-top hexagons, in axial coordinates, laid on a plane where
+// longitudes are scaled at a reference latitude
+type hexGrid struct {
+	radius float64
+	scale  float64
+}
+
+// NewHexGrid creates a grid of pointy-top hexagonal cells. Cells are measured at a reference latitude.
+//
+// @param size - Distance between two opposite sides of a cell, in meters. Must be positive
+// @param latitude - Reference latitude, usually the center of the mapped area
+func NewHexGrid(size float64, latitude float64) Grid {
+	return &hexGrid{
+		radius: size / math.Sqrt(3),
+		scale:  math.Cos(latitude * math.Pi / 180),
+	}
+}
+
+func (g *hexGrid) Cell(point orb.Point) GridCell {
+	x := point.Lon() * g.scale * MetersPerDegree
+	y := point.Lat() * MetersPerDegree
+	q := (math.Sqrt(3)/3*x - y/3) / g.radius
+	r := (2.0 / 3 * y) / g.radius
+
+	// Rounding in cube coordinates, the largest rounding error is given by the two others
+	s := -q - r
+	rq, rr, rs := math.Round(q), math.Round(r), math.Round(s)
+	dq, dr, ds := math.Abs(rq-q), math.Abs(rr-r), math.Abs(rs-s)
+	if dq > dr && dq > ds {
+		rq = -rr - rs
+	} else if dr > ds {
+		rr = -rq - rs
+	}
+	return GridCell{X: int(rq), Y: int(rr)}
+}
+
+func (g *hexGrid) Polygon(cell GridCell) orb.Polygon {
+	x := g.radius * math.Sqrt(3) * (float64(cell.X) + float64(cell.Y)/2)
+	y := g.radius * 1.5 * float64(cell.Y)
+
+	ring := make(orb.Ring, 0, 7)
+	for i := 0; i < 6; i++ {
+		angle := (60*float64(i) - 30) * math.Pi / 180
+		ring = append(ring, orb.Point{
+			(x + g.radius*math.Cos(angle)) / (g.scale * MetersPerDegree),
+			(y + g.radius*math.Sin(angle)) / MetersPerDegree,
+		})
+	}
+	return orb.Polygon{append(ring, ring[0])}
+}
+
+func (g *hexGrid) Name(cell GridCell) string {
+	return fmt.Sprintf("%d_%d", cell.X, cell.Y)
+}
+
+func (g *hexGrid) Count(bound orb.Bound) int64 {
+	width := (bound.Max.Lon() - bound.Min.Lon()) * g.scale * MetersPerDegree
+	height := (bound.Max.Lat() - bound.Min.Lat()) * MetersPerDegree
+	columns := math.Ceil(width/(g.radius*math.Sqrt(3))) + 1
+	rows := math.Ceil(height/(g.radius*1.5)) + 1
+	return int64(columns * rows)
+}
+
+// geohashGrid is the grid of the geohashes of a precision, cells are numbered by their
+// longitude and latitude intervals
+type geohashGrid struct {
+	precision int
+	lonBits   int
+	latBits   int
+}
+
+// NewGeohashGrid creates a grid of geohash cells
+//
+// @param precision - Number of characters of the geohashes, from 1 to 12
+func NewGeohashGrid(precision int) Grid {
+	bits := 5 * precision
+	return &geohashGrid{
+		precision: precision,
+		lonBits:   (bits + 1) / 2,
+		latBits:   bits / 2,
+	}
+}
+
+func (g *geohashGrid) Cell(point orb.Point) GridCell {
+	return GridCell{
+		X: interval(point.Lon(), -180, 180, g.lonBits),
+		Y: interval(point.Lat(), -90, 90, g.latBits),
+	}
+}
+
+// interval gives the position of a value among 2^bits intervals of a range
+func interval(value float64, min float64, max float64, bits int) int {
+	count := 1 << bits
+	position := int(math.Floor((value - min) / (max - min) * float64(count)))
+	if position >= count {
+		return count - 1
+	}
+	if position < 0 {
+		return 0
+	}
+	return position
+}
+
+func (g *geohashGrid) Polygon(cell GridCell) orb.Polygon {
+	width := 360 / float64(int(1)<<g.lonBits)
+	height := 180 / float64(int(1)<<g.latBits)
+	return orb.Bound{
+		Min: orb.Point{-180 + float64(cell.X)*width, -90 + float64(cell.Y)*height},
+		Max: orb.Point{-180 + float64(cell.X+1)*width, -90 + float64(cell.Y+1)*height},
+	}.ToPolygon()
+}
+
+// Name interleaves the bits of the longitude and latitude intervals, starting with the longitude
+func (g *geohashGrid) Name(cell GridCell) string {
+	var name strings.Builder
+	lonBit, latBit := g.lonBits, g.latBits
+	for c := 0; c < g.precision; c++ {
+		index := 0
+		for b := 0; b < 5; b++ {
+			index <<= 1
+			if (c*5+b)%2 == 0 {
+				lonBit--
+				index |= (cell.X >> lonBit) & 1
+			} else {
+				latBit--
+				index |= (cell.Y >> latBit) & 1
+			}
+		}
+		name.WriteByte(geohashBase32[index])
+	}
+	return name.String()
+}
+
+func (g *geohashGrid) Count(bound orb.Bound) int64 {
+	min := g.Cell(bound.Min)
+	max := g.Cell(bound.Max)
+	return int64(max.X-min.X+1) * int64(max.Y-min.Y+1)
+}
@@ -0,0 +1,147 @@
+// Package geo provides geometric tools to locate journeys
+package geo
+
+import (
+	"math"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+// Approximate length of a degree of latitude, in meters
+const MetersPerDegree = 111320.0
+
+type cell struct {
+	x int
+	y int
+}
+
+// GridIndex is a spatial index of polygonal areas, bucketed in a regular grid of square cells
+type GridIndex struct {
+	cellSize   float64
+	geometries []orb.Geometry
+	bounds     []orb.Bound
+	cells      map[cell][]int
+}
+
+// NewGridIndex creates an empty spatial index.
+//
+// @param cellSize - Size of a cell of the grid, in degrees. Must be positive
+func NewGridIndex(cellSize float64) *GridIndex {
+	return &GridIndex{
+		cellSize: cellSize,
+		cells:    map[cell][]int{},
+	}
+}
+
+// Add indexes an area.
+//
+// @param geometry - Polygon, MultiPolygon or Bound of the area
+//
+// @return the position of the area, returned by Locate when a point is inside it
+func (g *GridIndex) Add(geometry orb.Geometry) int {
+	position := len(g.geometries)
+	bound := geometry.Bound()
+	g.geometries = append(g.geometries, geometry)
+	g.bounds = append(g.bounds, bound)
+
+	minCell := g.cellOf(bound.Min)
+	maxCell := g.cellOf(bound.Max)
+	for x := minCell.x; x <= maxCell.x; x++ {
+		for y := minCell.y; y <= maxCell.y; y++ {
+			g.cells[cell{x, y}] = append(g.cells[cell{x, y}], position)
+		}
+	}
+
+	return position
+}
+
+// Len returns the number of indexed areas
+func (g *GridIndex) Len() int {
+	return len(g.geometries)
+}
+
+// Geometry returns an indexed area
+//
+// @param position - Position returned when the area was added
+func (g *GridIndex) Geometry(position int) orb.Geometry {
+	return g.geometries[position]
+}
+
+// Locate returns the positions of the areas containing a point, in the order they were added
+//
+// @param point - Point to locate
+func (g *GridIndex) Locate(point orb.Point) []int {
+	var positions []int
+	for _, position := range g.cells[g.cellOf(point)] {
+		if g.bounds[position].Contains(point) && Contains(g.geometries[position], point) {
+			positions = append(positions, position)
+		}
+	}
+	return positions
+}
+
+func (g *GridIndex) cellOf(point orb.Point) cell {
+	return cell{
+		x: int(math.Floor(point.Lon() / g.cellSize)),
+		y: int(math.Floor(point.Lat() / g.cellSize)),
+	}
+}
+
+// Contains checks if a polygonal geometry contains a point. Other geometries never contain a point.
+//
+// @param geometry - Polygon, MultiPolygon or Bound
+// @param point - Point to check
+func Contains(geometry orb.Geometry, point orb.Point) bool {
+	switch g := geometry.(type) {
+	case orb.Polygon:
+		return planar.PolygonContains(g, point)
+	case orb.MultiPolygon:
+		return planar.MultiPolygonContains(g, point)
+	case orb.Bound:
+		return g.Contains(point)
+	default:
+		return false
+	}
+}
+
+// NearlyContains checks if a point is inside a polygonal geometry or close to its border.
+//
+// @param geometry - Polygon, MultiPolygon or Bound
+// @param point - Point to check
+// @param tolerance - Maximal distance to the border, in meters. The distance is approximated on a plane
+func NearlyContains(geometry orb.Geometry, point orb.Point, tolerance float64) bool {
+	if Contains(geometry, point) {
+		return true
+	}
+	if tolerance <= 0 {
+		return false
+	}
+
+	if bound, ok := geometry.(orb.Bound); ok {
+		geometry = bound.ToPolygon()
+	}
+
+	// Longitudes are scaled to get a distance in the same unit on both axes
+	scale := math.Cos(point.Lat() * math.Pi / 180)
+	scaled := orb.Clone(geometry)
+	scaleLongitudes(scaled, scale)
+	distance := planar.DistanceFrom(scaled, orb.Point{point.Lon() * scale, point.Lat()})
+
+	return distance*MetersPerDegree <= tolerance
+}
+
+func scaleLongitudes(geometry orb.Geometry, scale float64) {
+	switch g := geometry.(type) {
+	case orb.Polygon:
+		for _, ring := range g {
+			for i := range ring {
+				ring[i][0] *= scale
+			}
+		}
+	case orb.MultiPolygon:
+		for _, polygon := range g {
+			scaleLongitudes(polygon, scale)
+		}
+	}
+}
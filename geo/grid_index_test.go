@@ -0,0 +1,36 @@
+// Package geo_test tests the geometric tools
+package geo_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/geo"
+
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGridIndex(t *testing.T) {
+	index := geo.NewGridIndex(0.1)
+	square := index.Add(orb.Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}})
+	holed := index.Add(orb.MultiPolygon{{
+		{{0.5, 0.5}, {2, 0.5}, {2, 2}, {0.5, 2}, {0.5, 0.5}},
+		{{1.2, 1.2}, {1.8, 1.2}, {1.8, 1.8}, {1.2, 1.8}, {1.2, 1.2}},
+	}})
+
+	assert.Equal(t, 2, index.Len())
+	assert.Equal(t, []int{square}, index.Locate(orb.Point{0.2, 0.2}))
+	assert.Equal(t, []int{square, holed}, index.Locate(orb.Point{0.7, 0.7}))
+	assert.Empty(t, index.Locate(orb.Point{1.5, 1.5}))
+	assert.Empty(t, index.Locate(orb.Point{-3, 4}))
+}
+
+func TestNearlyContains(t *testing.T) {
+	square := orb.Polygon{{{0, 45}, {1, 45}, {1, 46}, {0, 46}, {0, 45}}}
+
+	assert.True(t, geo.NearlyContains(square, orb.Point{0.5, 45.5}, 0))
+	assert.False(t, geo.NearlyContains(square, orb.Point{1.01, 45.5}, 0))
+	assert.True(t, geo.NearlyContains(square, orb.Point{1.01, 45.5}, 1000))
+	assert.False(t, geo.NearlyContains(square, orb.Point{1.1, 45.5}, 1000))
+	assert.True(t, geo.NearlyContains(orb.Bound{Min: orb.Point{0, 45}, Max: orb.Point{1, 46}}, orb.Point{0.5, 46.005}, 1000))
+}
@@ -0,0 +1,47 @@
+package geo_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/geo"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSquareGrid(t *testing.T) {
+	grid := geo.NewSquareGrid(1000, 48)
+	rennes := orb.Point{-1.68, 48.11}
+
+	cell := grid.Cell(rennes)
+	assert.True(t, planar.PolygonContains(grid.Polygon(cell), rennes))
+	assert.Equal(t, cell, grid.Cell(orb.Point{-1.6801, 48.1101}))
+	assert.NotEqual(t, cell, grid.Cell(orb.Point{-1.66, 48.11}))
+	assert.Equal(t, "-126_5355", grid.Name(cell))
+	assert.InDelta(t, 100, grid.Count(orb.Bound{Min: orb.Point{-1.7, 48}, Max: orb.Point{-1.57, 48.08}}), 20)
+}
+
+func TestHexGrid(t *testing.T) {
+	grid := geo.NewHexGrid(1000, 48)
+
+	for _, point := range []orb.Point{{-1.68, 48.11}, {-1.681, 48.115}, {2.35, 48.85}, {0, 0}} {
+		polygon := grid.Polygon(grid.Cell(point))
+		assert.Len(t, polygon[0], 7)
+		assert.True(t, planar.PolygonContains(polygon, point), point)
+	}
+	assert.NotEqual(t, grid.Cell(orb.Point{-1.68, 48.11}), grid.Cell(orb.Point{-1.66, 48.11}))
+	assert.InDelta(t, 120, grid.Count(orb.Bound{Min: orb.Point{-1.7, 48}, Max: orb.Point{-1.57, 48.08}}), 20)
+}
+
+func TestGeohashGrid(t *testing.T) {
+	grid := geo.NewGeohashGrid(5)
+	point := orb.Point{-5.6, 42.6}
+
+	cell := grid.Cell(point)
+	assert.Equal(t, "ezs42", grid.Name(cell))
+	assert.True(t, planar.PolygonContains(grid.Polygon(cell), point))
+	assert.Equal(t, "u", geo.NewGeohashGrid(1).Name(geo.NewGeohashGrid(1).Cell(orb.Point{2.35, 48.85})))
+	assert.Equal(t, "zzzz", geo.NewGeohashGrid(4).Name(geo.NewGeohashGrid(4).Cell(orb.Point{180, 90})))
+	assert.Equal(t, int64(4), geo.NewGeohashGrid(1).Count(orb.Bound{Min: orb.Point{-10, 40}, Max: orb.Point{10, 50}}))
+}
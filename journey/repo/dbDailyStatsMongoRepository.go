@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type dbDailyStatsRepository struct {
+	logger               *zap.SugaredLogger
+	cfg                  *configuration.Config
+	location             *time.Location
+	journeyCollection    *mongo.Collection
+	dailyStatsCollection *mongo.Collection
+}
+
+// NewDbDailyStatsMongoRepository makes a repository of the daily counters of the journeys, stored in the journey_daily_stats collection.
+// The days are taken in the timezone of the statistics.
+//
+// @param logger - Logger to use. Must not be nil
+// @param cfg - Configuration of the application. Must not be nil
+// @param mongoDb - Database holding the journeys. Must not be nil
+func NewDbDailyStatsMongoRepository(logger *zap.SugaredLogger, cfg *configuration.Config, mongoDb *mongo.Database) (domain.DailyStatsRepositoryInterface, error) {
+	location, err := time.LoadLocation(cfg.Journey.Stats.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dbDailyStatsRepository{
+		logger:               logger,
+		cfg:                  cfg,
+		location:             location,
+		journeyCollection:    mongoDb.Collection(journeyCollectionName),
+		dailyStatsCollection: mongoDb.Collection(dailyStatsCollectionName),
+	}, nil
+}
+
+// Increment adds stored journeys to the counters of their days, creating the missing counters
+//
+// @param ctx - Context of the update
+// @param journeys - Journeys which have just been stored
+func (r *dbDailyStatsRepository) Increment(ctx context.Context, journeys []domain.Journey) error {
+	if len(journeys) == 0 {
+		return nil
+	}
+
+	_, err := r.dailyStatsCollection.BulkWrite(ctx, NewDailyStatsUpdates(journeys, r.location), options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// Rebuild recomputes all the counters from the stored journeys. The collection is replaced once the counters are computed.
+//
+// @param ctx - Context of the aggregation
+func (r *dbDailyStatsRepository) Rebuild(ctx context.Context) (int64, error) {
+	cursor, err := r.journeyCollection.Aggregate(ctx, NewDailyStatsRebuildPipeline(r.cfg.Journey.Stats.Timezone))
+	if err != nil {
+		return 0, err
+	}
+	if err := cursor.Close(ctx); err != nil {
+		return 0, err
+	}
+
+	nbCounters, err := r.dailyStatsCollection.CountDocuments(ctx, bson.D{})
+	if err != nil {
+		return 0, err
+	}
+	r.logger.Infow("Daily statistics rebuilt",
+		"nbCounters", nbCounters,
+	)
+	return nbCounters, nil
+}
+
+// Stats sums the counters by bucket of a statistic, without reading the journeys
+//
+// @param ctx - Context of the aggregation
+// @param stat - Statistic to compute, one of the domain.DailyStatsStats
+func (r *dbDailyStatsRepository) Stats(ctx context.Context, stat string) ([]domain.StatBucket, error) {
+	pipeline, err := NewDailyStatsStatsPipeline(stat)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.dailyStatsCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	buckets := []domain.StatBucket{}
+	for cursor.Next(ctx) {
+		var document statBucketDocument
+		if err := cursor.Decode(&document); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, document.toStatBucket())
+	}
+	return buckets, cursor.Err()
+}
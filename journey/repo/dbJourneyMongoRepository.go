@@ -2,10 +2,16 @@
 package repo
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
 	"github.com/coutcout/covoiturage-csvreader/configuration"
 	"github.com/coutcout/covoiturage-csvreader/domain"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"go.uber.org/zap"
 )
@@ -19,8 +25,11 @@ type dbJourneyRepository struct {
 
 const journeyCollectionName = "journey"
 
-// NewDbJourneyRepository make an instance of a dbJourneyRepository
-func NewDbJourneyMongoRepository(logger *zap.SugaredLogger, cfg *configuration.Config, mongoDb *mongo.Database) domain.JourneyRepositoryInterface {
+// Number of journeys checked against a zone by update
+const zoneTagBatchSize = 1000
+
+// NewDbJourneyRepository make an instance of a dbJourneyRepository, creating the missing indexes of the journey collection
+func NewDbJourneyMongoRepository(logger *zap.SugaredLogger, cfg *configuration.Config, mongoDb *mongo.Database) (domain.JourneyRepositoryInterface, error) {
 	dbJourneyRepository := &dbJourneyRepository{
 		logger:      logger,
 		cfg:         cfg,
@@ -28,23 +37,384 @@ func NewDbJourneyMongoRepository(logger *zap.SugaredLogger, cfg *configuration.C
 	}
 	dbJourneyRepository.journeyCollection = mongoDb.Collection(journeyCollectionName)
 
-	return dbJourneyRepository
+	indexes, err := dbJourneyRepository.journeyCollection.Indexes().CreateMany(context.TODO(), NewJourneyIndexes())
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("journeys stored several times, the duplicates must be removed before starting: %w", err)
+		}
+		return nil, err
+	}
+	logger.Debugw("Journey indexes created",
+		"indexes", indexes,
+	)
+
+	return dbJourneyRepository, nil
 }
 
 
-// Add adds journey to the repository. Not Implemented yet.
-// 
-// @param r - jouey the journey to be
-// @param journey
-func (r *dbJourneyRepository) Add(c *gin.Context, journeys []domain.Journey) (int, error) {
+// Add stores journeys with an unordered insertion, and returns the journeys stored as new documents. The journeys whose
+// id is already stored are skipped without error, so that importing a file again doesn't store its journeys twice.
+//
+// @param c - Context of the insertion
+// @param journeys - Journeys to store
+func (r *dbJourneyRepository) Add(c *gin.Context, journeys []domain.Journey) ([]domain.Journey, error) {
 	if journeys == nil && len(journeys) ==0{
-		return 0, nil
+		return nil, nil
 	}
 	
 	var interfaces []interface{}
 	for _, j := range journeys{
-		interfaces = append(interfaces, j)
+		interfaces = append(interfaces, NewJourneyDocument(j))
+	}
+	_, err := r.journeyCollection.InsertMany(c, interfaces, options.InsertMany().SetOrdered(false))
+	return InsertedJourneys(journeys, err)
+}
+
+// InsertedJourneys returns the journeys stored by an unordered insertion, and the error of the insertion once the
+// duplicate key errors of the journeys already stored are removed. On a write error, only the failing journeys weren't
+// stored. Other errors, such as network ones, give no stored journey.
+//
+// @param journeys - Journeys to insert
+// @param err - Error of the insertion. Can be nil
+func InsertedJourneys(journeys []domain.Journey, err error) ([]domain.Journey, error) {
+	if err == nil {
+		return journeys, nil
+	}
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) {
+		return nil, err
+	}
+
+	failed := make(map[int]bool, len(bulkErr.WriteErrors))
+	writeErrors := []mongo.BulkWriteError{}
+	for _, writeErr := range bulkErr.WriteErrors {
+		failed[writeErr.Index] = true
+		if !mongo.IsDuplicateKeyError(writeErr.WriteError) {
+			writeErrors = append(writeErrors, writeErr)
+		}
+	}
+	inserted := make([]domain.Journey, 0, len(journeys))
+	for i, journey := range journeys {
+		if !failed[i] {
+			inserted = append(inserted, journey)
+		}
+	}
+
+	if len(writeErrors) == 0 && bulkErr.WriteConcernError == nil {
+		return inserted, nil
+	}
+	bulkErr.WriteErrors = writeErrors
+	return inserted, bulkErr
+}
+
+// Find sends the journeys matching the filter to the channel, reading them with a cursor. The channel is closed when done.
+//
+// @param ctx - Context of the search. Cancelling it stops the search
+// @param filter - Criteria of the journeys. Can be nil
+// @param journeyChan - Channel which will be used to send the journeys
+func (r *dbJourneyRepository) Find(ctx context.Context, filter *domain.JourneyFilter, journeyChan chan<- *domain.Journey) error {
+	return r.find(ctx, filter, options.Find(), journeyChan)
+}
+
+// FindByStart sends the journeys matching the filter to the channel by start, then by id, reading them with a cursor.
+// The channel is closed when done.
+//
+// @param ctx - Context of the search. Cancelling it stops the search
+// @param filter - Criteria of the journeys. Can be nil
+// @param journeyChan - Channel which will be used to send the journeys
+func (r *dbJourneyRepository) FindByStart(ctx context.Context, filter *domain.JourneyFilter, journeyChan chan<- *domain.Journey) error {
+	return r.find(ctx, filter, options.Find().SetSort(NewStartSort()).SetAllowDiskUse(true), journeyChan)
+}
+
+func (r *dbJourneyRepository) find(ctx context.Context, filter *domain.JourneyFilter, findOptions *options.FindOptions, journeyChan chan<- *domain.Journey) error {
+	defer close(journeyChan)
+
+	cursor, err := r.journeyCollection.Find(ctx, NewJourneyFilterQuery(filter), findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		journey := &domain.Journey{}
+		if err := cursor.Decode(journey); err != nil {
+			return err
+		}
+
+		select {
+		case journeyChan <- journey:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return cursor.Err()
+}
+
+// Count returns the number of journeys matching the filter
+//
+// @param ctx - Context of the search
+// @param filter - Criteria of the journeys. Can be nil
+func (r *dbJourneyRepository) Count(ctx context.Context, filter *domain.JourneyFilter) (int64, error) {
+	return r.journeyCollection.CountDocuments(ctx, NewJourneyFilterQuery(filter))
+}
+
+// ODMatrix counts the journeys matching the filter by origin and destination
+//
+// @param ctx - Context of the aggregation
+// @param level - Geographic level of the origins and destinations, one of the domain.ODLevel constants
+// @param filter - Criteria of the journeys. Can be nil
+func (r *dbJourneyRepository) ODMatrix(ctx context.Context, level string, filter *domain.JourneyFilter) ([]domain.ODPair, error) {
+	pipeline, err := NewODMatrixPipeline(level, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.journeyCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	pairs := []domain.ODPair{}
+	for cursor.Next(ctx) {
+		var document odPairDocument
+		if err := cursor.Decode(&document); err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, document.toODPair())
+	}
+	return pairs, cursor.Err()
+}
+
+// Stats counts the journeys matching the filter by bucket of a statistic
+//
+// @param ctx - Context of the aggregation
+// @param stat - Statistic to compute, one of the domain.Stat constants
+// @param filter - Criteria of the journeys. Can be nil
+func (r *dbJourneyRepository) Stats(ctx context.Context, stat string, filter *domain.JourneyFilter) ([]domain.StatBucket, error) {
+	pipeline, err := NewStatsPipeline(stat, filter, r.cfg.Journey.Stats.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.journeyCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	buckets := []domain.StatBucket{}
+	for cursor.Next(ctx) {
+		var document statBucketDocument
+		if err := cursor.Decode(&document); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, document.toStatBucket())
+	}
+	return buckets, cursor.Err()
+}
+
+// FindTrips sends the trips of the journeys matching the filter to the channel, reading them with a cursor. The channel is closed when done.
+//
+// @param ctx - Context of the search. Cancelling it stops the search
+// @param filter - Criteria of the journeys. Can be nil
+// @param offset - Number of trips to skip
+// @param limit - Maximum number of trips, 0 meaning no limit
+// @param tripChan - Channel which will be used to send the trips
+func (r *dbJourneyRepository) FindTrips(ctx context.Context, filter *domain.JourneyFilter, offset int64, limit int64, tripChan chan<- *domain.Trip) error {
+	defer close(tripChan)
+
+	cursor, err := r.journeyCollection.Aggregate(ctx, NewTripsPipeline(filter, offset, limit), options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var document tripDocument
+		if err := cursor.Decode(&document); err != nil {
+			return err
+		}
+		if len(document.Journeys) == 0 {
+			continue
+		}
+
+		select {
+		case tripChan <- domain.NewTrip(document.Journeys):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return cursor.Err()
+}
+
+// Co2 sums the passenger-km of the journeys matching the filter by value of a dimension
+//
+// @param ctx - Context of the aggregation
+// @param dimension - Dimension of the buckets, one of the domain.Co2Dimensions. A single bucket is computed when empty
+// @param filter - Criteria of the journeys. Can be nil
+func (r *dbJourneyRepository) Co2(ctx context.Context, dimension string, filter *domain.JourneyFilter) ([]domain.Co2Bucket, error) {
+	pipeline, err := NewCo2Pipeline(dimension, filter, r.cfg.Journey.Stats.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.journeyCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	buckets := []domain.Co2Bucket{}
+	for cursor.Next(ctx) {
+		var document co2BucketDocument
+		if err := cursor.Decode(&document); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, document.toCo2Bucket())
+	}
+	return buckets, cursor.Err()
+}
+
+// ReplaceAnomalies clears the anomalies of the journeys matching the filter, then stores the given ones
+//
+// @param ctx - Context of the updates
+// @param filter - Criteria of the journeys whose anomalies were detected. Can be nil
+// @param anomalies - Anomalies by journey
+func (r *dbJourneyRepository) ReplaceAnomalies(ctx context.Context, filter *domain.JourneyFilter, anomalies []domain.JourneyAnomalies) error {
+	_, err := r.journeyCollection.UpdateMany(ctx, NewJourneyFilterQuery(filter), bson.D{{Key: "$set", Value: bson.D{
+		{Key: "anomalies", Value: bson.A{}},
+		{Key: "anomalyscore", Value: 0},
+	}}})
+	if err != nil {
+		return err
+	}
+
+	updates := NewAnomaliesUpdates(anomalies)
+	if len(updates) == 0 {
+		return nil
+	}
+	_, err = r.journeyCollection.BulkWrite(ctx, updates, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// FindAnomalies returns the journeys matching the filter with an anomaly, by decreasing score then by journey id
+//
+// @param ctx - Context of the search
+// @param filter - Criteria of the journeys. Can be nil
+// @param anomaly - Anomaly of the journeys, one of the domain.Anomalies. Any anomaly when empty
+// @param offset - Number of journeys to skip
+// @param limit - Maximum number of journeys, 0 meaning no limit
+func (r *dbJourneyRepository) FindAnomalies(ctx context.Context, filter *domain.JourneyFilter, anomaly string, offset int64, limit int64) ([]domain.Journey, error) {
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "anomalyscore", Value: -1}, {Key: "journeyid", Value: 1}}).
+		SetSkip(offset)
+	if limit > 0 {
+		findOptions.SetLimit(limit)
+	}
+
+	cursor, err := r.journeyCollection.Find(ctx, NewAnomaliesQuery(filter, anomaly), findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	journeys := []domain.Journey{}
+	if err := cursor.All(ctx, &journeys); err != nil {
+		return nil, err
+	}
+	return journeys, nil
+}
+
+// TagZone sets a zone on the stored journeys starting or ending in it, and removes it from the other ones.
+// The journeys in the bounding box of the zone are read by batches and checked against its geometry.
+//
+// @param ctx - Context of the updates
+// @param zone - Zone to tag
+func (r *dbJourneyRepository) TagZone(ctx context.Context, zone *domain.Zone) error {
+	if _, err := r.journeyCollection.BulkWrite(ctx, NewZoneUntagUpdates(zone.Name)); err != nil {
+		return err
+	}
+
+	cursor, err := r.journeyCollection.Find(ctx, NewZoneCandidatesQuery(zone), options.Find().
+		SetProjection(NewZoneCandidatesProjection()).
+		SetBatchSize(zoneTagBatchSize))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	journeys := make([]domain.Journey, 0, zoneTagBatchSize)
+	for {
+		more := cursor.Next(ctx)
+		if more {
+			var journey domain.Journey
+			if err := cursor.Decode(&journey); err != nil {
+				return err
+			}
+			journeys = append(journeys, journey)
+		}
+		if len(journeys) == zoneTagBatchSize || (!more && len(journeys) > 0) {
+			if updates := NewZoneTagUpdates(zone, journeys); len(updates) > 0 {
+				if _, err := r.journeyCollection.BulkWrite(ctx, updates, options.BulkWrite().SetOrdered(false)); err != nil {
+					return err
+				}
+			}
+			journeys = journeys[:0]
+		}
+		if !more {
+			return cursor.Err()
+		}
+	}
+}
+
+// BackfillLocations sets the GeoJSON locations of the journeys stored without them, so that the geographic
+// criteria find them. It returns the number of locations set, starts and ends.
+//
+// @param ctx - Context of the updates
+func (r *dbJourneyRepository) BackfillLocations(ctx context.Context) (int64, error) {
+	result, err := r.journeyCollection.BulkWrite(ctx, NewLocationBackfillUpdates())
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// UntagZone removes a zone from the stored journeys
+//
+// @param ctx - Context of the updates
+// @param name - Name of the zone
+func (r *dbJourneyRepository) UntagZone(ctx context.Context, name string) error {
+	_, err := r.journeyCollection.BulkWrite(ctx, NewZoneUntagUpdates(name))
+	return err
+}
+
+// FindLocations sends the start and end of the journeys matching the filter to the channel, and closes it when done.
+// Only the locations are read.
+//
+// @param ctx - Context of the search
+// @param filter - Criteria of the journeys. Can be nil
+// @param locationChan - Channel receiving the locations
+func (r *dbJourneyRepository) FindLocations(ctx context.Context, filter *domain.JourneyFilter, locationChan chan<- *domain.JourneyLocation) error {
+	defer close(locationChan)
+
+	cursor, err := r.journeyCollection.Find(ctx, NewJourneyFilterQuery(filter), options.Find().SetProjection(NewLocationProjection()))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var document locationDocument
+		if err := cursor.Decode(&document); err != nil {
+			return err
+		}
+
+		select {
+		case locationChan <- document.toLocation():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
-	result, err := r.journeyCollection.InsertMany(c, interfaces)
-	return len(result.InsertedIDs), err
+	return cursor.Err()
 }
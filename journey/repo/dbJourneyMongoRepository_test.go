@@ -0,0 +1,46 @@
+package repo_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/repo"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestInsertedJourneys(t *testing.T) {
+	journeys := []domain.Journey{{JourneyId: 1}, {JourneyId: 2}, {JourneyId: 3}, {JourneyId: 4}}
+	duplicate := func(index int) mongo.BulkWriteError {
+		return mongo.BulkWriteError{WriteError: mongo.WriteError{Index: index, Code: 11000, Message: "E11000 duplicate key error"}}
+	}
+
+	inserted, err := repo.InsertedJourneys(journeys, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, journeys, inserted)
+
+	inserted, err = repo.InsertedJourneys(journeys, errors.New("connection refused"))
+	assert.Error(t, err)
+	assert.Empty(t, inserted)
+
+	// Journeys already stored are skipped without error
+	inserted, err = repo.InsertedJourneys(journeys, mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{duplicate(0), duplicate(2)}})
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Journey{{JourneyId: 2}, {JourneyId: 4}}, inserted)
+
+	inserted, err = repo.InsertedJourneys(journeys, mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+		duplicate(0),
+		{WriteError: mongo.WriteError{Index: 3, Code: 121, Message: "document failed validation"}},
+	}})
+	var bulkErr mongo.BulkWriteException
+	assert.ErrorAs(t, err, &bulkErr)
+	assert.Len(t, bulkErr.WriteErrors, 1)
+	assert.Equal(t, 3, bulkErr.WriteErrors[0].Index)
+	assert.Equal(t, []domain.Journey{{JourneyId: 2}, {JourneyId: 3}}, inserted)
+
+	inserted, err = repo.InsertedJourneys(journeys, mongo.BulkWriteException{WriteConcernError: &mongo.WriteConcernError{}})
+	assert.Error(t, err)
+	assert.Equal(t, journeys, inserted)
+}
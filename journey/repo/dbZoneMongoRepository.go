@@ -0,0 +1,167 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/paulmach/orb/geojson"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const zoneCollectionName = "zone"
+
+// zoneDocument is the stored form of a zone, named by its id, with its geometry in GeoJSON
+type zoneDocument struct {
+	Name        string   `bson:"_id"`
+	Description string   `bson:"description"`
+	Geometry    bson.Raw `bson:"geometry"`
+}
+
+type dbZoneRepository struct {
+	logger         *zap.SugaredLogger
+	cfg            *configuration.Config
+	zoneCollection *mongo.Collection
+}
+
+// NewDbZoneMongoRepository makes a repository of the zones, stored in the zone collection
+//
+// @param logger - Logger to use. Must not be nil
+// @param cfg - Configuration of the application. Must not be nil
+// @param mongoDb - Database holding the zones. Must not be nil
+func NewDbZoneMongoRepository(logger *zap.SugaredLogger, cfg *configuration.Config, mongoDb *mongo.Database) domain.ZoneRepositoryInterface {
+	return &dbZoneRepository{
+		logger:         logger,
+		cfg:            cfg,
+		zoneCollection: mongoDb.Collection(zoneCollectionName),
+	}
+}
+
+// NewZoneDocument converts a zone into its stored form
+//
+// @param zone - Zone to store
+func NewZoneDocument(zone *domain.Zone) (interface{}, error) {
+	geometry, err := bson.Marshal(newGeoJSONGeometry(zone.Geometry))
+	if err != nil {
+		return nil, err
+	}
+	return zoneDocument{
+		Name:        zone.Name,
+		Description: zone.Description,
+		Geometry:    geometry,
+	}, nil
+}
+
+// toZone converts a stored zone, reading its geometry as GeoJSON
+func (d *zoneDocument) toZone() (*domain.Zone, error) {
+	content, err := bson.MarshalExtJSON(d.Geometry, false, false)
+	if err != nil {
+		return nil, err
+	}
+	geometry, err := geojson.UnmarshalGeometry(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid geometry of the zone '%s': %w", d.Name, err)
+	}
+	return &domain.Zone{
+		Name:        d.Name,
+		Description: d.Description,
+		Geometry:    geometry.Geometry(),
+	}, nil
+}
+
+// Create stores a new zone, ErrZoneExists is returned when its name is taken
+//
+// @param ctx - Context of the insertion
+// @param zone - Zone to store
+func (r *dbZoneRepository) Create(ctx context.Context, zone *domain.Zone) error {
+	document, err := NewZoneDocument(zone)
+	if err != nil {
+		return err
+	}
+	_, err = r.zoneCollection.InsertOne(ctx, document)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrZoneExists
+	}
+	return err
+}
+
+// Get returns a zone, ErrZoneNotFound is returned when it doesn't exist
+//
+// @param ctx - Context of the search
+// @param name - Name of the zone
+func (r *dbZoneRepository) Get(ctx context.Context, name string) (*domain.Zone, error) {
+	var document zoneDocument
+	err := r.zoneCollection.FindOne(ctx, bson.D{{Key: "_id", Value: name}}).Decode(&document)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrZoneNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return document.toZone()
+}
+
+// List returns all the zones, sorted by name
+//
+// @param ctx - Context of the search
+func (r *dbZoneRepository) List(ctx context.Context) ([]domain.Zone, error) {
+	cursor, err := r.zoneCollection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	zones := []domain.Zone{}
+	for cursor.Next(ctx) {
+		var document zoneDocument
+		if err := cursor.Decode(&document); err != nil {
+			return nil, err
+		}
+		zone, err := document.toZone()
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, *zone)
+	}
+	return zones, cursor.Err()
+}
+
+// Update replaces a zone, ErrZoneNotFound is returned when it doesn't exist
+//
+// @param ctx - Context of the update
+// @param zone - New version of the zone
+func (r *dbZoneRepository) Update(ctx context.Context, zone *domain.Zone) error {
+	document, err := NewZoneDocument(zone)
+	if err != nil {
+		return err
+	}
+	result, err := r.zoneCollection.ReplaceOne(ctx, bson.D{{Key: "_id", Value: zone.Name}}, document)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrZoneNotFound
+	}
+	return nil
+}
+
+// Delete removes a zone, ErrZoneNotFound is returned when it doesn't exist
+//
+// @param ctx - Context of the deletion
+// @param name - Name of the zone
+func (r *dbZoneRepository) Delete(ctx context.Context, name string) error {
+	result, err := r.zoneCollection.DeleteOne(ctx, bson.D{{Key: "_id", Value: name}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrZoneNotFound
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package repo
+
+import (
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// NewAnomaliesQuery builds the query of the journeys matching the filter with an anomaly, or with the given one
+//
+// @param filter - Criteria of the journeys. Can be nil
+// @param anomaly - Anomaly of the journeys, one of the domain.Anomalies. Any anomaly when empty
+func NewAnomaliesQuery(filter *domain.JourneyFilter, anomaly string) bson.D {
+	query := NewJourneyFilterQuery(filter)
+	if anomaly != "" {
+		return append(query, bson.E{Key: "anomalies", Value: anomaly})
+	}
+	return append(query, bson.E{Key: "anomalies.0", Value: bson.D{{Key: "$exists", Value: true}}})
+}
+
+// NewAnomaliesUpdates builds the updates storing the anomalies of the journeys having some
+//
+// @param anomalies - Anomalies by journey
+func NewAnomaliesUpdates(anomalies []domain.JourneyAnomalies) []mongo.WriteModel {
+	updates := []mongo.WriteModel{}
+	for _, journeyAnomalies := range anomalies {
+		if len(journeyAnomalies.Anomalies) == 0 {
+			continue
+		}
+		updates = append(updates, mongo.NewUpdateManyModel().
+			SetFilter(bson.D{{Key: "journeyid", Value: journeyAnomalies.JourneyId}}).
+			SetUpdate(bson.D{{Key: "$set", Value: bson.D{
+				{Key: "anomalies", Value: journeyAnomalies.Anomalies},
+				{Key: "anomalyscore", Value: journeyAnomalies.Score},
+			}}}))
+	}
+	return updates
+}
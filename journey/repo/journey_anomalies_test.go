@@ -0,0 +1,40 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/repo"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestNewAnomaliesQuery(t *testing.T) {
+	query := repo.NewAnomaliesQuery(&domain.JourneyFilter{OperatorClasses: []string{"C"}}, domain.AnomalyBurst)
+
+	assert.Len(t, query, 2)
+	assert.Equal(t, bson.E{Key: "anomalies", Value: domain.AnomalyBurst}, query[1])
+}
+
+func TestNewAnomaliesQuery_any(t *testing.T) {
+	query := repo.NewAnomaliesQuery(nil, "")
+
+	assert.Equal(t, bson.D{{Key: "anomalies.0", Value: bson.D{{Key: "$exists", Value: true}}}}, query)
+}
+
+func TestNewAnomaliesUpdates(t *testing.T) {
+	updates := repo.NewAnomaliesUpdates([]domain.JourneyAnomalies{
+		{JourneyId: 1, Anomalies: []string{}},
+		{JourneyId: 2, Anomalies: []string{domain.AnomalySpeed}, Score: 1},
+	})
+
+	assert.Len(t, updates, 1)
+	update := updates[0].(*mongo.UpdateManyModel)
+	assert.Equal(t, bson.D{{Key: "journeyid", Value: int64(2)}}, update.Filter)
+	assert.Equal(t, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "anomalies", Value: []string{domain.AnomalySpeed}},
+		{Key: "anomalyscore", Value: 1.0},
+	}}}, update.Update)
+}
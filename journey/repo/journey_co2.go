@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"fmt"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// co2BucketDocument is a bucket as returned by the aggregation pipeline of the CO2 estimates
+type co2BucketDocument struct {
+	Id          interface{} `bson:"_id"`
+	NbJourneys  int64       `bson:"nbjourneys"`
+	PassengerKm float64     `bson:"passengerkm"`
+}
+
+// NewCo2Pipeline builds the aggregation pipeline summing the passenger-km of the journeys matching the filter
+// by value of a dimension. Buckets are sorted by key.
+//
+// @param dimension - Dimension of the buckets, one of the domain.Co2Dimensions. A single bucket is computed when empty
+// @param filter - Criteria of the journeys. Can be nil
+// @param timezone - Timezone of the months, as an Olson name. UTC when empty
+func NewCo2Pipeline(dimension string, filter *domain.JourneyFilter, timezone string) (mongo.Pipeline, error) {
+	if err := domain.ValidateCo2Dimension(dimension); err != nil {
+		return nil, err
+	}
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	pipeline := mongo.Pipeline{}
+	if query := NewJourneyFilterQuery(filter); len(query) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: query}})
+	}
+
+	var key interface{}
+	switch dimension {
+	case domain.JourneySplitStartDepartment:
+		key = "$journeystartdepartment"
+	case domain.JourneySplitEndDepartment:
+		key = "$journeyenddepartment"
+	case domain.JourneySplitMonth:
+		key = statGroupKey(domain.StatPerMonth, timezone)
+	case domain.JourneySplitOperatorClass:
+		key = "$operatorclass"
+	}
+
+	return append(pipeline,
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: key},
+			{Key: "nbjourneys", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "passengerkm", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$multiply", Value: bson.A{
+				bson.D{{Key: "$divide", Value: bson.A{"$journeydistance", 1000}}},
+				"$passengerseats",
+			}}}}}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	), nil
+}
+
+// toCo2Bucket converts an aggregated document, whose key is a string or null for the total
+func (d *co2BucketDocument) toCo2Bucket() domain.Co2Bucket {
+	key := ""
+	if d.Id != nil {
+		key = fmt.Sprint(d.Id)
+	}
+	return domain.Co2Bucket{
+		Key:         key,
+		NbJourneys:  d.NbJourneys,
+		PassengerKm: d.PassengerKm,
+	}
+}
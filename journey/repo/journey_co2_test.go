@@ -0,0 +1,46 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/repo"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNewCo2Pipeline(t *testing.T) {
+	var tests = []struct {
+		dimension   string
+		expectedKey interface{}
+	}{
+		{"", nil},
+		{domain.JourneySplitStartDepartment, "$journeystartdepartment"},
+		{domain.JourneySplitEndDepartment, "$journeyenddepartment"},
+		{domain.JourneySplitOperatorClass, "$operatorclass"},
+		{domain.JourneySplitMonth, bson.D{{Key: "$dateToString", Value: bson.D{
+			{Key: "format", Value: "%Y-%m"},
+			{Key: "date", Value: "$journeystartdatetime"},
+			{Key: "timezone", Value: "UTC"},
+		}}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.dimension, func(t *testing.T) {
+			pipeline, err := repo.NewCo2Pipeline(test.dimension, nil, "")
+
+			assert.NoError(t, err)
+			assert.Len(t, pipeline, 2)
+			assert.Equal(t, "$group", pipeline[0][0].Key)
+			assert.Equal(t, bson.E{Key: "_id", Value: test.expectedKey}, pipeline[0][0].Value.(bson.D)[0])
+			assert.Equal(t, "passengerkm", pipeline[0][0].Value.(bson.D)[2].Key)
+		})
+	}
+}
+
+func TestNewCo2Pipeline_wrongDimension(t *testing.T) {
+	_, err := repo.NewCo2Pipeline("week", &domain.JourneyFilter{OperatorClasses: []string{"C"}}, "")
+
+	assert.Error(t, err)
+}
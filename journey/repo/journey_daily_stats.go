@@ -0,0 +1,131 @@
+package repo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const dailyStatsCollectionName = "journey_daily_stats"
+
+// dailyStatsKeyDocument is the identifier of the counters of a day
+type dailyStatsKeyDocument struct {
+	Day             string `bson:"day"`
+	StartDepartment string `bson:"startdepartment"`
+	EndDepartment   string `bson:"enddepartment"`
+	OperatorClass   string `bson:"operatorclass"`
+}
+
+// NewDailyStatsUpdates builds the upserts adding journeys to the counters of their days
+//
+// @param journeys - Stored journeys
+// @param location - Location of the days
+func NewDailyStatsUpdates(journeys []domain.Journey, location *time.Location) []mongo.WriteModel {
+	dailyStats := domain.AggregateDailyStats(journeys, location)
+	updates := make([]mongo.WriteModel, 0, len(dailyStats))
+	for _, stats := range dailyStats {
+		updates = append(updates, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "_id", Value: dailyStatsKeyDocument(stats.DailyStatsKey)}}).
+			SetUpdate(bson.D{{Key: "$inc", Value: bson.D{
+				{Key: "nbjourneys", Value: stats.NbJourneys},
+				{Key: "passengerseats", Value: stats.PassengerSeats},
+				{Key: "totaldistance", Value: stats.TotalDistance},
+				{Key: "totalduration", Value: stats.TotalDuration},
+				{Key: "nbwithincentive", Value: stats.NbWithIncentive},
+			}}}).
+			SetUpsert(true))
+	}
+	return updates
+}
+
+// NewDailyStatsRebuildPipeline builds the aggregation pipeline recomputing the counters of all the days from the journeys,
+// replacing the collection of the counters once done.
+// The days are computed like domain.JourneyDay.
+//
+// @param timezone - Timezone of the days, as an Olson name. UTC when empty
+func NewDailyStatsRebuildPipeline(timezone string) mongo.Pipeline {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	known := func(field string) bson.D {
+		return bson.D{{Key: "$gt", Value: bson.A{field, time.Time{}}}}
+	}
+	day := func(field string, timezone string) bson.D {
+		return bson.D{{Key: "$dateToString", Value: bson.D{
+			{Key: "format", Value: "%Y-%m-%d"},
+			{Key: "date", Value: field},
+			{Key: "timezone", Value: timezone},
+		}}}
+	}
+	ifThenElse := func(condition interface{}, then interface{}, otherwise interface{}) bson.D {
+		return bson.D{{Key: "$cond", Value: bson.D{
+			{Key: "if", Value: condition},
+			{Key: "then", Value: then},
+			{Key: "else", Value: otherwise},
+		}}}
+	}
+	sum := func(expression interface{}) bson.D {
+		return bson.D{{Key: "$sum", Value: expression}}
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "day", Value: ifThenElse(known("$journeystartdatetime"), day("$journeystartdatetime", timezone),
+					ifThenElse(known("$journeystartdate"), day("$journeystartdate", "UTC"), ""))},
+				{Key: "startdepartment", Value: "$journeystartdepartment"},
+				{Key: "enddepartment", Value: "$journeyenddepartment"},
+				{Key: "operatorclass", Value: "$operatorclass"},
+			}},
+			{Key: "nbjourneys", Value: sum(1)},
+			{Key: "passengerseats", Value: sum("$passengerseats")},
+			{Key: "totaldistance", Value: sum("$journeydistance")},
+			{Key: "totalduration", Value: sum("$journeyduration")},
+			{Key: "nbwithincentive", Value: sum(ifThenElse("$hasincentive", 1, 0))},
+		}}},
+		{{Key: "$out", Value: dailyStatsCollectionName}},
+	}
+}
+
+// NewDailyStatsStatsPipeline builds the aggregation pipeline summing the daily counters by bucket of a statistic.
+// Buckets are sorted by key. The counters without day are summed in a bucket with an empty key.
+//
+// @param stat - Statistic to compute, one of the domain.DailyStatsStats
+func NewDailyStatsStatsPipeline(stat string) (mongo.Pipeline, error) {
+	var key interface{}
+	switch stat {
+	case domain.StatPerDay:
+		key = "$_id.day"
+	case domain.StatPerWeek:
+		key = bson.D{{Key: "$cond", Value: bson.D{
+			{Key: "if", Value: bson.D{{Key: "$eq", Value: bson.A{"$_id.day", ""}}}},
+			{Key: "then", Value: ""},
+			{Key: "else", Value: bson.D{{Key: "$dateToString", Value: bson.D{
+				{Key: "format", Value: "%G-W%V"},
+				{Key: "date", Value: bson.D{{Key: "$dateFromString", Value: bson.D{
+					{Key: "dateString", Value: "$_id.day"},
+					{Key: "format", Value: "%Y-%m-%d"},
+				}}}},
+			}}}},
+		}}}
+	case domain.StatPerMonth:
+		key = bson.D{{Key: "$substrBytes", Value: bson.A{"$_id.day", 0, 7}}}
+	case domain.StatPerOperatorClass:
+		key = "$_id.operatorclass"
+	default:
+		return nil, fmt.Errorf("statistic '%s' can't be computed from the daily statistics", stat)
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: key},
+			{Key: "nbjourneys", Value: bson.D{{Key: "$sum", Value: "$nbjourneys"}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}, nil
+}
@@ -0,0 +1,68 @@
+package repo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/repo"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestNewDailyStatsUpdates(t *testing.T) {
+	day := time.Date(2023, 1, 2, 8, 0, 0, 0, time.UTC)
+	journeys := []domain.Journey{
+		{JourneyStartDatetime: day, JourneyStartDepartment: "69", JourneyEndDepartment: "01", OperatorClass: "C", PassengerSeats: 2},
+		{JourneyStartDatetime: day, JourneyStartDepartment: "69", JourneyEndDepartment: "01", OperatorClass: "C", PassengerSeats: 1},
+		{JourneyStartDatetime: day, JourneyStartDepartment: "69", JourneyEndDepartment: "69", OperatorClass: "B", PassengerSeats: 1},
+	}
+
+	updates := repo.NewDailyStatsUpdates(journeys, time.UTC)
+
+	assert.Len(t, updates, 2)
+	update := updates[0].(*mongo.UpdateOneModel)
+	assert.True(t, *update.Upsert)
+	filter, _ := bson.Marshal(update.Filter)
+	assert.Equal(t, "2023-01-02", bson.Raw(filter).Lookup("_id", "day").StringValue())
+	assert.Equal(t, "01", bson.Raw(filter).Lookup("_id", "enddepartment").StringValue())
+	inc := update.Update.(bson.D)[0]
+	assert.Equal(t, "$inc", inc.Key)
+	assert.Contains(t, inc.Value, bson.E{Key: "nbjourneys", Value: int64(2)})
+	assert.Contains(t, inc.Value, bson.E{Key: "passengerseats", Value: int64(3)})
+
+	assert.Empty(t, repo.NewDailyStatsUpdates(nil, time.UTC))
+}
+
+func TestNewDailyStatsRebuildPipeline(t *testing.T) {
+	pipeline := repo.NewDailyStatsRebuildPipeline("")
+
+	assert.Len(t, pipeline, 2)
+	assert.Equal(t, "$group", pipeline[0][0].Key)
+	assert.Equal(t, bson.E{Key: "$out", Value: "journey_daily_stats"}, pipeline[1][0])
+
+	group, _ := bson.Marshal(pipeline[0][0].Value)
+	timezone := bson.Raw(group).Lookup("_id", "day", "$cond", "then", "$dateToString", "timezone")
+	assert.Equal(t, "UTC", timezone.StringValue())
+}
+
+func TestNewDailyStatsStatsPipeline(t *testing.T) {
+	pipeline, err := repo.NewDailyStatsStatsPipeline(domain.StatPerDay)
+	assert.NoError(t, err)
+	assert.Len(t, pipeline, 2)
+	assert.Equal(t, bson.D{
+		{Key: "_id", Value: "$_id.day"},
+		{Key: "nbjourneys", Value: bson.D{{Key: "$sum", Value: "$nbjourneys"}}},
+	}, pipeline[0][0].Value)
+	assert.Equal(t, "$sort", pipeline[1][0].Key)
+
+	pipeline, err = repo.NewDailyStatsStatsPipeline(domain.StatPerWeek)
+	assert.NoError(t, err)
+	group, _ := bson.Marshal(pipeline[0][0].Value)
+	assert.Equal(t, "%G-W%V", bson.Raw(group).Lookup("_id", "$cond", "else", "$dateToString", "format").StringValue())
+
+	_, err = repo.NewDailyStatsStatsPipeline(domain.StatDistance)
+	assert.Error(t, err)
+}
@@ -0,0 +1,197 @@
+package repo
+
+import (
+	"math"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/paulmach/orb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Radius of the Earth, in meters, converting distances into the radians of $centerSphere
+const earthRadius = 6378100.0
+
+// Stored coordinates of the locations of the journeys, by location
+var locationCoordinates = map[string][2]string{
+	"journeystartlocation": {"journeystartlon", "journeystartlat"},
+	"journeyendlocation":   {"journeyendlon", "journeyendlat"},
+}
+
+// Widest bounding box, in degrees of longitude, searched with the index of the locations
+const maxIndexedBoundWidth = 90.0
+
+// Margin added to the padding of the bounding boxes, in degrees, against rounding errors
+const boundMargin = 1e-6
+
+// GeoJSON point of the start or the end of a stored journey
+type geoPoint struct {
+	Type        string    `bson:"type"`
+	Coordinates orb.Point `bson:"coordinates"`
+}
+
+// journeyDocument is the stored form of a journey: its fields, and its start and end as GeoJSON points
+// so that they can be indexed. Unknown or invalid coordinates give no point.
+type journeyDocument struct {
+	domain.Journey       `bson:",inline"`
+	JourneyStartLocation *geoPoint `bson:"journeystartlocation,omitempty"`
+	JourneyEndLocation   *geoPoint `bson:"journeyendlocation,omitempty"`
+}
+
+// NewJourneyDocument converts a journey into its stored form
+//
+// @param journey - Journey to store
+func NewJourneyDocument(journey domain.Journey) interface{} {
+	return journeyDocument{
+		Journey:              journey,
+		JourneyStartLocation: newGeoPoint(journey.JourneyStartLon, journey.JourneyStartLat),
+		JourneyEndLocation:   newGeoPoint(journey.JourneyEndLon, journey.JourneyEndLat),
+	}
+}
+
+func newGeoPoint(lon float64, lat float64) *geoPoint {
+	if (lon == 0 && lat == 0) || lon < -180 || lon > 180 || lat < -90 || lat > 90 {
+		return nil
+	}
+	return &geoPoint{Type: "Point", Coordinates: orb.Point{lon, lat}}
+}
+
+// NewLocationBackfillUpdates builds the updates setting the GeoJSON locations of the journeys stored without them,
+// before the locations were stored. Journeys without valid coordinates are left without locations.
+func NewLocationBackfillUpdates() []mongo.WriteModel {
+	updates := []mongo.WriteModel{}
+	for _, field := range []string{"journeystartlocation", "journeyendlocation"} {
+		coordinates := locationCoordinates[field]
+		updates = append(updates, mongo.NewUpdateManyModel().
+			SetFilter(bson.D{
+				{Key: field, Value: bson.D{{Key: "$exists", Value: false}}},
+				{Key: coordinates[0], Value: bson.D{{Key: "$gte", Value: -180}, {Key: "$lte", Value: 180}}},
+				{Key: coordinates[1], Value: bson.D{{Key: "$gte", Value: -90}, {Key: "$lte", Value: 90}}},
+				{Key: "$or", Value: bson.A{
+					bson.D{{Key: coordinates[0], Value: bson.D{{Key: "$ne", Value: 0}}}},
+					bson.D{{Key: coordinates[1], Value: bson.D{{Key: "$ne", Value: 0}}}},
+				}},
+			}).
+			SetUpdate(mongo.Pipeline{bson.D{{Key: "$set", Value: bson.D{{Key: field, Value: bson.D{
+				{Key: "type", Value: "Point"},
+				{Key: "coordinates", Value: bson.A{"$" + coordinates[0], "$" + coordinates[1]}},
+			}}}}}}))
+	}
+	return updates
+}
+
+// NewJourneyIndexes gives the indexes of the journey collection: 2dsphere indexes on the start and end locations,
+// the index of the journeys read by start, and the unique index on the id of the journeys skipping those already stored
+func NewJourneyIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{Keys: bson.D{{Key: "journeystartlocation", Value: "2dsphere"}}},
+		{Keys: bson.D{{Key: "journeyendlocation", Value: "2dsphere"}}},
+		{Keys: NewStartSort()},
+		{Keys: bson.D{{Key: "journeyid", Value: 1}}, Options: options.Index().SetUnique(true)},
+	}
+}
+
+// NewStartSort gives the order of the journeys by start, then by id
+func NewStartSort() bson.D {
+	return bson.D{{Key: "journeystartdatetime", Value: 1}, {Key: "journeyid", Value: 1}}
+}
+
+// newGeoCriteria converts the geographic criteria on a location into $geoWithin conditions
+//
+// @param field - Stored location, journeystartlocation or journeyendlocation
+// @param near - Disc around a point. Can be nil
+// @param bound - Bounding box. Can be nil
+// @param within - Polygon or multipolygon. Can be nil
+func newGeoCriteria(field string, near *domain.GeoCircle, bound *domain.GeoBound, within *domain.GeoPolygon) []bson.D {
+	criteria := []bson.D{}
+	geoWithin := func(shape bson.D) bson.D {
+		return bson.D{{Key: field, Value: bson.D{{Key: "$geoWithin", Value: shape}}}}
+	}
+
+	if near != nil {
+		criteria = append(criteria, geoWithin(bson.D{{Key: "$centerSphere", Value: bson.A{
+			near.Center, near.Radius / earthRadius,
+		}}}))
+	}
+	if bound != nil {
+		criteria = append(criteria, newBoundCriteria(field, bound.Bound)...)
+	}
+	if within != nil {
+		criteria = append(criteria, geoWithin(bson.D{{Key: "$geometry", Value: newGeoJSONGeometry(within.Geometry)}}))
+	}
+	return criteria
+}
+
+// newBoundCriteria converts a bounding box into conditions on a location. Bounding boxes are planar, as checked
+// by domain.GeoBound.Contains, while the edges of a $geometry polygon are geodesics bulging towards the poles:
+// the polygon is padded to hold the whole box and only narrows the search with the index, the stored coordinates
+// are then checked against the box. Boxes too wide for a polygon are only checked on the coordinates.
+//
+// @param field - Stored location, journeystartlocation or journeyendlocation
+// @param bound - Bounding box
+func newBoundCriteria(field string, bound orb.Bound) []bson.D {
+	criteria := []bson.D{}
+	if bound.Max.Lon()-bound.Min.Lon() <= maxIndexedBoundWidth {
+		criteria = append(criteria, bson.D{{Key: field, Value: bson.D{{Key: "$geoWithin", Value: bson.D{
+			{Key: "$geometry", Value: newGeoJSONGeometry(paddedBound(bound).ToPolygon())},
+		}}}}})
+	} else {
+		criteria = append(criteria, bson.D{{Key: field, Value: bson.D{{Key: "$exists", Value: true}}}})
+	}
+
+	coordinates := locationCoordinates[field]
+	return append(criteria,
+		bson.D{{Key: coordinates[0], Value: bson.D{{Key: "$gte", Value: bound.Min.Lon()}, {Key: "$lte", Value: bound.Max.Lon()}}}},
+		bson.D{{Key: coordinates[1], Value: bson.D{{Key: "$gte", Value: bound.Min.Lat()}, {Key: "$lte", Value: bound.Max.Lat()}}}},
+	)
+}
+
+// paddedBound moves the edges of a bounding box away from the equator, so that the geodesics joining its corners
+// hold the whole box. The geodesic joining two points of latitude l, w degrees of longitude apart, reaches
+// the latitude atan(tan(l) / cos(w/2)) halfway.
+func paddedBound(bound orb.Bound) orb.Bound {
+	cos := math.Cos((bound.Max.Lon() - bound.Min.Lon()) / 2 * math.Pi / 180)
+	pad := func(lat float64) float64 {
+		return math.Atan(math.Tan(lat*math.Pi/180)*cos) * 180 / math.Pi
+	}
+	return orb.Bound{
+		Min: orb.Point{bound.Min.Lon(), math.Max(-90, math.Min(bound.Min.Lat(), pad(bound.Min.Lat()))-boundMargin)},
+		Max: orb.Point{bound.Max.Lon(), math.Min(90, math.Max(bound.Max.Lat(), pad(bound.Max.Lat()))+boundMargin)},
+	}
+}
+
+// newGeoJSONGeometry converts a polygon or a multipolygon into a GeoJSON geometry
+func newGeoJSONGeometry(geometry orb.Geometry) bson.D {
+	return bson.D{
+		{Key: "type", Value: geometry.GeoJSONType()},
+		{Key: "coordinates", Value: geometry},
+	}
+}
+
+// locationDocument is the projection of a stored journey on its locations
+type locationDocument struct {
+	JourneyStartLocation *geoPoint `bson:"journeystartlocation"`
+	JourneyEndLocation   *geoPoint `bson:"journeyendlocation"`
+}
+
+// NewLocationProjection gives the projection of the stored journeys on their start and end locations
+func NewLocationProjection() bson.D {
+	return bson.D{
+		{Key: "_id", Value: 0},
+		{Key: "journeystartlocation", Value: 1},
+		{Key: "journeyendlocation", Value: 1},
+	}
+}
+
+func (d *locationDocument) toLocation() *domain.JourneyLocation {
+	location := &domain.JourneyLocation{}
+	if d.JourneyStartLocation != nil {
+		location.Start = &d.JourneyStartLocation.Coordinates
+	}
+	if d.JourneyEndLocation != nil {
+		location.End = &d.JourneyEndLocation.Coordinates
+	}
+	return location
+}
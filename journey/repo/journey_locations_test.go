@@ -0,0 +1,57 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/repo"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestNewJourneyDocument(t *testing.T) {
+	data, err := bson.Marshal(repo.NewJourneyDocument(domain.Journey{
+		JourneyId:       1,
+		JourneyStartLon: -1.68,
+		JourneyStartLat: 48.11,
+		JourneyEndLon:   200,
+		JourneyEndLat:   47.66,
+	}))
+	assert.NoError(t, err)
+
+	var document bson.M
+	assert.NoError(t, bson.Unmarshal(data, &document))
+	assert.Equal(t, int64(1), document["journeyid"])
+	assert.Equal(t, bson.M{"type": "Point", "coordinates": bson.A{-1.68, 48.11}}, document["journeystartlocation"])
+	assert.NotContains(t, document, "journeyendlocation")
+
+	var journey domain.Journey
+	assert.NoError(t, bson.Unmarshal(data, &journey))
+	assert.Equal(t, -1.68, journey.JourneyStartLon)
+}
+
+func TestNewJourneyIndexes(t *testing.T) {
+	indexes := repo.NewJourneyIndexes()
+
+	assert.Len(t, indexes, 4)
+	assert.Equal(t, bson.D{{Key: "journeystartlocation", Value: "2dsphere"}}, indexes[0].Keys)
+	assert.Equal(t, bson.D{{Key: "journeyendlocation", Value: "2dsphere"}}, indexes[1].Keys)
+	assert.Equal(t, bson.D{{Key: "journeystartdatetime", Value: 1}, {Key: "journeyid", Value: 1}}, indexes[2].Keys)
+	assert.Equal(t, bson.D{{Key: "journeyid", Value: 1}}, indexes[3].Keys)
+	assert.True(t, *indexes[3].Options.Unique)
+}
+
+func TestNewLocationBackfillUpdates(t *testing.T) {
+	updates := repo.NewLocationBackfillUpdates()
+
+	assert.Len(t, updates, 2)
+	start := updates[0].(*mongo.UpdateManyModel)
+	assert.Equal(t, bson.E{Key: "journeystartlocation", Value: bson.D{{Key: "$exists", Value: false}}}, start.Filter.(bson.D)[0])
+	assert.Equal(t, mongo.Pipeline{bson.D{{Key: "$set", Value: bson.D{{Key: "journeystartlocation", Value: bson.D{
+		{Key: "type", Value: "Point"},
+		{Key: "coordinates", Value: bson.A{"$journeystartlon", "$journeystartlat"}},
+	}}}}}}, start.Update)
+	assert.Equal(t, bson.E{Key: "journeyendlocation", Value: bson.D{{Key: "$exists", Value: false}}}, updates[1].(*mongo.UpdateManyModel).Filter.(bson.D)[0])
+}
@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"fmt"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Stored fields of the origin and the destination of the journeys, by level
+var odLevelFields = map[string][2]string{
+	domain.ODLevelInsee:      {"journeystartinsee", "journeyendinsee"},
+	domain.ODLevelDepartment: {"journeystartdepartment", "journeyenddepartment"},
+	domain.ODLevelTowngroup:  {"journeystarttowngroup", "journeyendtowngroup"},
+	domain.ODLevelCountry:    {"journeystartcountry", "journeyendcountry"},
+}
+
+// odPairDocument is an OD pair as returned by the aggregation pipeline
+type odPairDocument struct {
+	Id struct {
+		Origin      interface{} `bson:"origin"`
+		Destination interface{} `bson:"destination"`
+	} `bson:"_id"`
+	NbJourneys      int64   `bson:"nbjourneys"`
+	PassengerSeats  int64   `bson:"passengerseats"`
+	AverageDistance float64 `bson:"averagedistance"`
+	AverageDuration float64 `bson:"averageduration"`
+}
+
+// NewODMatrixPipeline builds the aggregation pipeline grouping the journeys matching the filter by origin and destination.
+// Pairs are sorted by decreasing number of journeys.
+//
+// @param level - Geographic level of the origins and destinations, one of the domain.ODLevel constants
+// @param filter - Criteria of the journeys. Can be nil
+func NewODMatrixPipeline(level string, filter *domain.JourneyFilter) (mongo.Pipeline, error) {
+	if err := domain.ValidateODLevel(level); err != nil {
+		return nil, err
+	}
+	fields := odLevelFields[level]
+
+	pipeline := mongo.Pipeline{}
+	if query := NewJourneyFilterQuery(filter); len(query) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: query}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "origin", Value: "$" + fields[0]},
+				{Key: "destination", Value: "$" + fields[1]},
+			}},
+			{Key: "nbjourneys", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "passengerseats", Value: bson.D{{Key: "$sum", Value: "$passengerseats"}}},
+			{Key: "averagedistance", Value: bson.D{{Key: "$avg", Value: "$journeydistance"}}},
+			{Key: "averageduration", Value: bson.D{{Key: "$avg", Value: "$journeyduration"}}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{
+			{Key: "nbjourneys", Value: -1},
+			{Key: "_id.origin", Value: 1},
+			{Key: "_id.destination", Value: 1},
+		}}},
+	)
+	return pipeline, nil
+}
+
+// toODPair converts an aggregated document, whose origin and destination are INSEE codes or names
+func (d *odPairDocument) toODPair() domain.ODPair {
+	return domain.ODPair{
+		Origin:          odValue(d.Id.Origin),
+		Destination:     odValue(d.Id.Destination),
+		NbJourneys:      d.NbJourneys,
+		PassengerSeats:  d.PassengerSeats,
+		AverageDistance: d.AverageDistance,
+		AverageDuration: d.AverageDuration,
+	}
+}
+
+func odValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,41 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/repo"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNewODMatrixPipeline(t *testing.T) {
+	pipeline, err := repo.NewODMatrixPipeline(domain.ODLevelDepartment, &domain.JourneyFilter{OperatorClasses: []string{"C"}})
+
+	assert.NoError(t, err)
+	assert.Len(t, pipeline, 3)
+	assert.Equal(t, bson.D{{Key: "$match", Value: bson.D{
+		{Key: "operatorclass", Value: bson.D{{Key: "$in", Value: []string{"C"}}}},
+	}}}, pipeline[0])
+	assert.Equal(t, bson.D{
+		{Key: "origin", Value: "$journeystartdepartment"},
+		{Key: "destination", Value: "$journeyenddepartment"},
+	}, pipeline[1][0].Value.(bson.D)[0].Value)
+}
+
+func TestNewODMatrixPipeline_levels(t *testing.T) {
+	for _, level := range domain.ODLevels {
+		t.Run(level, func(t *testing.T) {
+			pipeline, err := repo.NewODMatrixPipeline(level, nil)
+
+			assert.NoError(t, err)
+			// Without filter, the journeys aren't matched
+			assert.Len(t, pipeline, 2)
+			assert.Equal(t, "$group", pipeline[0][0].Key)
+		})
+	}
+
+	_, err := repo.NewODMatrixPipeline("region", nil)
+	assert.Error(t, err)
+}
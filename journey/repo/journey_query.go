@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// NewJourneyFilterQuery converts a journey filter into a query on the journey collection.
+// Fields are named as stored by the default codec, in lower case.
+//
+// @param filter - Criteria of the journeys. Can be nil
+func NewJourneyFilterQuery(filter *domain.JourneyFilter) bson.D {
+	query := bson.D{}
+	if filter == nil {
+		return query
+	}
+
+	if len(filter.Departments) > 0 {
+		query = append(query, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "journeystartdepartment", Value: bson.D{{Key: "$in", Value: filter.Departments}}}},
+			bson.D{{Key: "journeyenddepartment", Value: bson.D{{Key: "$in", Value: filter.Departments}}}},
+		}})
+	}
+	if len(filter.StartDepartments) > 0 {
+		query = append(query, bson.E{Key: "journeystartdepartment", Value: bson.D{{Key: "$in", Value: filter.StartDepartments}}})
+	}
+	if len(filter.EndDepartments) > 0 {
+		query = append(query, bson.E{Key: "journeyenddepartment", Value: bson.D{{Key: "$in", Value: filter.EndDepartments}}})
+	}
+	if len(filter.OperatorClasses) > 0 {
+		query = append(query, bson.E{Key: "operatorclass", Value: bson.D{{Key: "$in", Value: filter.OperatorClasses}}})
+	}
+
+	period := bson.D{}
+	if !filter.From.IsZero() {
+		period = append(period, bson.E{Key: "$gte", Value: filter.From})
+	}
+	if !filter.To.IsZero() {
+		period = append(period, bson.E{Key: "$lte", Value: filter.To})
+	}
+	if len(period) > 0 {
+		query = append(query, bson.E{Key: "journeystartdate", Value: period})
+	}
+
+	distance := bson.D{}
+	if filter.MinDistance > 0 {
+		distance = append(distance, bson.E{Key: "$gte", Value: filter.MinDistance})
+	}
+	if filter.MaxDistance > 0 {
+		distance = append(distance, bson.E{Key: "$lte", Value: filter.MaxDistance})
+	}
+	if len(distance) > 0 {
+		query = append(query, bson.E{Key: "journeydistance", Value: distance})
+	}
+
+	if filter.HasIncentive != nil {
+		query = append(query, bson.E{Key: "hasincentive", Value: *filter.HasIncentive})
+	}
+
+	// Several conditions on a same location can't share its key
+	geoCriteria := append(
+		newGeoCriteria("journeystartlocation", filter.StartNear, filter.StartBbox, filter.StartWithin),
+		newGeoCriteria("journeyendlocation", filter.EndNear, filter.EndBbox, filter.EndWithin)...,
+	)
+	switch len(geoCriteria) {
+	case 0:
+	case 1:
+		query = append(query, geoCriteria[0]...)
+	default:
+		criteria := bson.A{}
+		for _, criterion := range geoCriteria {
+			criteria = append(criteria, criterion)
+		}
+		query = append(query, bson.E{Key: "$and", Value: criteria})
+	}
+
+	return query
+}
@@ -0,0 +1,93 @@
+package repo_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/repo"
+
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNewJourneyFilterQuery(t *testing.T) {
+	hasIncentive := true
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var tests = []struct {
+		name     string
+		filter   *domain.JourneyFilter
+		expected bson.D
+	}{
+		{"nil_filter", nil, bson.D{}},
+		{"empty_filter", &domain.JourneyFilter{}, bson.D{}},
+		{"departments", &domain.JourneyFilter{Departments: []string{"35"}, EndDepartments: []string{"29"}}, bson.D{
+			{Key: "$or", Value: bson.A{
+				bson.D{{Key: "journeystartdepartment", Value: bson.D{{Key: "$in", Value: []string{"35"}}}}},
+				bson.D{{Key: "journeyenddepartment", Value: bson.D{{Key: "$in", Value: []string{"35"}}}}},
+			}},
+			{Key: "journeyenddepartment", Value: bson.D{{Key: "$in", Value: []string{"29"}}}},
+		}},
+		{"ranges", &domain.JourneyFilter{From: from, MaxDistance: 5000, HasIncentive: &hasIncentive}, bson.D{
+			{Key: "journeystartdate", Value: bson.D{{Key: "$gte", Value: from}}},
+			{Key: "journeydistance", Value: bson.D{{Key: "$lte", Value: int64(5000)}}},
+			{Key: "hasincentive", Value: true},
+		}},
+		{"start_near", &domain.JourneyFilter{StartNear: &domain.GeoCircle{Center: orb.Point{-1.68, 48.11}, Radius: 6378.1}}, bson.D{
+			{Key: "journeystartlocation", Value: bson.D{{Key: "$geoWithin", Value: bson.D{
+				{Key: "$centerSphere", Value: bson.A{orb.Point{-1.68, 48.11}, 0.001}},
+			}}}},
+		}},
+		{"areas", &domain.JourneyFilter{
+			StartNear: &domain.GeoCircle{Center: orb.Point{-1.68, 48.11}, Radius: 6378.1},
+			EndWithin: &domain.GeoPolygon{Geometry: orb.Polygon{{{-3, 47}, {-2, 47}, {-2, 48}, {-3, 47}}}},
+		}, bson.D{
+			{Key: "$and", Value: bson.A{
+				bson.D{{Key: "journeystartlocation", Value: bson.D{{Key: "$geoWithin", Value: bson.D{
+					{Key: "$centerSphere", Value: bson.A{orb.Point{-1.68, 48.11}, 0.001}},
+				}}}}},
+				bson.D{{Key: "journeyendlocation", Value: bson.D{{Key: "$geoWithin", Value: bson.D{
+					{Key: "$geometry", Value: bson.D{
+						{Key: "type", Value: "Polygon"},
+						{Key: "coordinates", Value: orb.Polygon{{{-3, 47}, {-2, 47}, {-2, 48}, {-3, 47}}}},
+					}},
+				}}}}},
+			}},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, repo.NewJourneyFilterQuery(test.filter))
+		})
+	}
+}
+
+func TestNewJourneyFilterQuery_bbox(t *testing.T) {
+	query := repo.NewJourneyFilterQuery(&domain.JourneyFilter{
+		StartBbox: &domain.GeoBound{Bound: orb.Bound{Min: orb.Point{-2, 47}, Max: orb.Point{0, 48}}},
+	})
+
+	criteria := query[0].Value.(bson.A)
+	assert.Len(t, criteria, 3)
+	// The geodesic edges of the polygon hold the whole box
+	polygon := criteria[0].(bson.D)[0].Value.(bson.D)[0].Value.(bson.D)[0].Value.(bson.D)[1].Value.(orb.Polygon)
+	bound := polygon.Bound()
+	assert.Equal(t, -2.0, bound.Min.Lon())
+	assert.Equal(t, 0.0, bound.Max.Lon())
+	assert.Less(t, math.Atan(math.Tan(bound.Min.Lat()*math.Pi/180)/math.Cos(math.Pi/180))*180/math.Pi, 47.0)
+	assert.Greater(t, bound.Max.Lat(), 48.0)
+	// The box is then checked on the stored coordinates
+	assert.Equal(t, bson.D{{Key: "journeystartlon", Value: bson.D{{Key: "$gte", Value: -2.0}, {Key: "$lte", Value: 0.0}}}}, criteria[1])
+	assert.Equal(t, bson.D{{Key: "journeystartlat", Value: bson.D{{Key: "$gte", Value: 47.0}, {Key: "$lte", Value: 48.0}}}}, criteria[2])
+
+	query = repo.NewJourneyFilterQuery(&domain.JourneyFilter{
+		EndBbox: &domain.GeoBound{Bound: orb.Bound{Min: orb.Point{-180, -90}, Max: orb.Point{180, 90}}},
+	})
+	criteria = query[0].Value.(bson.A)
+	assert.Len(t, criteria, 3)
+	assert.Equal(t, bson.D{{Key: "journeyendlocation", Value: bson.D{{Key: "$exists", Value: true}}}}, criteria[0])
+}
@@ -0,0 +1,137 @@
+package repo
+
+import (
+	"fmt"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// statBucketDocument is a bucket as returned by the aggregation pipelines of the statistics
+type statBucketDocument struct {
+	Id         interface{} `bson:"_id"`
+	NbJourneys int64       `bson:"nbjourneys"`
+}
+
+// NewStatsPipeline builds the aggregation pipeline counting the journeys matching the filter by bucket of a statistic.
+// Buckets are sorted by key.
+//
+// @param stat - Statistic to compute, one of the domain.Stat constants
+// @param filter - Criteria of the journeys. Can be nil
+// @param timezone - Timezone of the days, weeks, months and hours, as an Olson name. UTC when empty
+func NewStatsPipeline(stat string, filter *domain.JourneyFilter, timezone string) (mongo.Pipeline, error) {
+	if err := domain.ValidateStat(stat); err != nil {
+		return nil, err
+	}
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	pipeline := mongo.Pipeline{}
+	if query := NewJourneyFilterQuery(filter); len(query) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: query}})
+	}
+
+	count := bson.E{Key: "nbjourneys", Value: bson.D{{Key: "$sum", Value: 1}}}
+	switch stat {
+	case domain.StatDistance:
+		pipeline = append(pipeline, statHistogram("$journeydistance", domain.StatDistanceBoundaries, count))
+	case domain.StatDuration:
+		pipeline = append(pipeline, statHistogram("$journeyduration", domain.StatDurationBoundaries, count))
+	default:
+		// Journeys are counted in each of their zones, journeys outside any zone aren't counted
+		switch stat {
+		case domain.StatPerStartZone:
+			pipeline = append(pipeline, bson.D{{Key: "$unwind", Value: "$startzones"}})
+		case domain.StatPerEndZone:
+			pipeline = append(pipeline, bson.D{{Key: "$unwind", Value: "$endzones"}})
+		case domain.StatZoneFlow:
+			pipeline = append(pipeline,
+				bson.D{{Key: "$unwind", Value: "$startzones"}},
+				bson.D{{Key: "$unwind", Value: "$endzones"}},
+			)
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: statGroupKey(stat, timezone)},
+			count,
+		}}})
+	}
+
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}})
+	return pipeline, nil
+}
+
+// statGroupKey gives the expression of the bucket of a journey
+func statGroupKey(stat string, timezone string) interface{} {
+	date := func(format string) bson.D {
+		return bson.D{{Key: "$dateToString", Value: bson.D{
+			{Key: "format", Value: format},
+			{Key: "date", Value: "$journeystartdatetime"},
+			{Key: "timezone", Value: timezone},
+		}}}
+	}
+	datePart := func(operator string) bson.D {
+		return bson.D{{Key: operator, Value: bson.D{
+			{Key: "date", Value: "$journeystartdatetime"},
+			{Key: "timezone", Value: timezone},
+		}}}
+	}
+
+	switch stat {
+	case domain.StatPerDay:
+		return date("%Y-%m-%d")
+	case domain.StatPerWeek:
+		return date("%G-W%V")
+	case domain.StatPerMonth:
+		return date("%Y-%m")
+	case domain.StatPerHour:
+		return datePart("$hour")
+	case domain.StatPerWeekday:
+		return datePart("$isoDayOfWeek")
+	case domain.StatPerOperatorClass:
+		return "$operatorclass"
+	case domain.StatIncentive:
+		return "$hasincentive"
+	case domain.StatPerStartZone:
+		return "$startzones"
+	case domain.StatPerEndZone:
+		return "$endzones"
+	case domain.StatZoneFlow:
+		return bson.D{{Key: "$concat", Value: bson.A{"$startzones", domain.StatZoneFlowSeparator, "$endzones"}}}
+	default:
+		return "$passengerseats"
+	}
+}
+
+// statHistogram gives the stage counting the journeys by bucket of a numeric field
+func statHistogram(field string, boundaries []int64, count bson.E) bson.D {
+	bounds := bson.A{}
+	for _, boundary := range boundaries {
+		bounds = append(bounds, boundary)
+	}
+
+	return bson.D{{Key: "$bucket", Value: bson.D{
+		{Key: "groupBy", Value: field},
+		{Key: "boundaries", Value: bounds},
+		{Key: "default", Value: domain.StatOverflowKey},
+		{Key: "output", Value: bson.D{count}},
+	}}}
+}
+
+// toStatBucket converts an aggregated document, whose key is a string, a number or a boolean
+func (d *statBucketDocument) toStatBucket() domain.StatBucket {
+	key := ""
+	switch v := d.Id.(type) {
+	case nil:
+	case string:
+		key = v
+	default:
+		key = fmt.Sprint(v)
+	}
+	return domain.StatBucket{
+		Key:        key,
+		NbJourneys: d.NbJourneys,
+	}
+}
@@ -0,0 +1,84 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/repo"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNewStatsPipeline(t *testing.T) {
+	var tests = []struct {
+		stat          string
+		expectedStage string
+		expectedKey   interface{}
+	}{
+		{domain.StatPerDay, "$group", bson.D{{Key: "$dateToString", Value: bson.D{
+			{Key: "format", Value: "%Y-%m-%d"},
+			{Key: "date", Value: "$journeystartdatetime"},
+			{Key: "timezone", Value: "Europe/Paris"},
+		}}}},
+		{domain.StatPerWeek, "$group", nil},
+		{domain.StatPerMonth, "$group", nil},
+		{domain.StatPerHour, "$group", bson.D{{Key: "$hour", Value: bson.D{
+			{Key: "date", Value: "$journeystartdatetime"},
+			{Key: "timezone", Value: "Europe/Paris"},
+		}}}},
+		{domain.StatPerWeekday, "$group", nil},
+		{domain.StatPerOperatorClass, "$group", "$operatorclass"},
+		{domain.StatIncentive, "$group", "$hasincentive"},
+		{domain.StatPassengerSeats, "$group", "$passengerseats"},
+		{domain.StatDistance, "$bucket", nil},
+		{domain.StatDuration, "$bucket", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.stat, func(t *testing.T) {
+			pipeline, err := repo.NewStatsPipeline(test.stat, &domain.JourneyFilter{OperatorClasses: []string{"C"}}, "Europe/Paris")
+
+			assert.NoError(t, err)
+			assert.Len(t, pipeline, 3)
+			assert.Equal(t, "$match", pipeline[0][0].Key)
+			assert.Equal(t, test.expectedStage, pipeline[1][0].Key)
+			if test.expectedKey != nil {
+				assert.Equal(t, bson.E{Key: "_id", Value: test.expectedKey}, pipeline[1][0].Value.(bson.D)[0])
+			}
+			assert.Equal(t, "$sort", pipeline[2][0].Key)
+		})
+	}
+}
+
+func TestNewStatsPipeline_histogram(t *testing.T) {
+	pipeline, err := repo.NewStatsPipeline(domain.StatDistance, nil, "")
+
+	assert.NoError(t, err)
+	assert.Len(t, pipeline, 2)
+	bucket := pipeline[0][0].Value.(bson.D)
+	assert.Equal(t, bson.E{Key: "groupBy", Value: "$journeydistance"}, bucket[0])
+	assert.Len(t, bucket[1].Value, len(domain.StatDistanceBoundaries))
+	assert.Equal(t, bson.E{Key: "default", Value: domain.StatOverflowKey}, bucket[2])
+
+	_, err = repo.NewStatsPipeline("per-year", nil, "")
+	assert.Error(t, err)
+}
+
+func TestNewStatsPipeline_zones(t *testing.T) {
+	pipeline, err := repo.NewStatsPipeline(domain.StatPerStartZone, nil, "")
+
+	assert.NoError(t, err)
+	assert.Len(t, pipeline, 3)
+	assert.Equal(t, bson.D{{Key: "$unwind", Value: "$startzones"}}, pipeline[0])
+	assert.Equal(t, bson.E{Key: "_id", Value: "$startzones"}, pipeline[1][0].Value.(bson.D)[0])
+
+	pipeline, err = repo.NewStatsPipeline(domain.StatZoneFlow, nil, "")
+
+	assert.NoError(t, err)
+	assert.Len(t, pipeline, 4)
+	assert.Equal(t, bson.D{{Key: "$unwind", Value: "$startzones"}}, pipeline[0])
+	assert.Equal(t, bson.D{{Key: "$unwind", Value: "$endzones"}}, pipeline[1])
+	assert.Equal(t, bson.E{Key: "_id", Value: bson.D{{Key: "$concat", Value: bson.A{"$startzones", " > ", "$endzones"}}}},
+		pipeline[2][0].Value.(bson.D)[0])
+}
@@ -0,0 +1,41 @@
+package repo
+
+import (
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// tripDocument is a trip as returned by the aggregation pipeline of the trips
+type tripDocument struct {
+	Journeys []domain.Journey `bson:"journeys"`
+}
+
+// NewTripsPipeline builds the aggregation pipeline gathering the journeys matching the filter by TripId.
+// Trips are sorted by TripId, only the journeys matching the filter are part of their trip.
+//
+// @param filter - Criteria of the journeys. Can be nil
+// @param offset - Number of trips to skip
+// @param limit - Maximum number of trips, 0 meaning no limit
+func NewTripsPipeline(filter *domain.JourneyFilter, offset int64, limit int64) mongo.Pipeline {
+	pipeline := mongo.Pipeline{}
+	if query := NewJourneyFilterQuery(filter); len(query) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: query}})
+	}
+
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$tripid"},
+			{Key: "journeys", Value: bson.D{{Key: "$push", Value: "$$ROOT"}}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	)
+	if offset > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: offset}})
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit}})
+	}
+	return pipeline
+}
@@ -0,0 +1,30 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/repo"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNewTripsPipeline(t *testing.T) {
+	pipeline := repo.NewTripsPipeline(&domain.JourneyFilter{OperatorClasses: []string{"C"}}, 20, 10)
+
+	assert.Len(t, pipeline, 5)
+	assert.Equal(t, "$match", pipeline[0][0].Key)
+	assert.Equal(t, bson.E{Key: "_id", Value: "$tripid"}, pipeline[1][0].Value.(bson.D)[0])
+	assert.Equal(t, "$sort", pipeline[2][0].Key)
+	assert.Equal(t, bson.E{Key: "$skip", Value: int64(20)}, pipeline[3][0])
+	assert.Equal(t, bson.E{Key: "$limit", Value: int64(10)}, pipeline[4][0])
+}
+
+func TestNewTripsPipeline_all(t *testing.T) {
+	pipeline := repo.NewTripsPipeline(nil, 0, 0)
+
+	assert.Len(t, pipeline, 2)
+	assert.Equal(t, "$group", pipeline[0][0].Key)
+	assert.Equal(t, "$sort", pipeline[1][0].Key)
+}
@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Stored fields of the zones of the journeys, with the stored coordinates checked against the zones
+var zoneFields = []struct {
+	zones string
+	lon   string
+	lat   string
+}{
+	{"startzones", "journeystartlon", "journeystartlat"},
+	{"endzones", "journeyendlon", "journeyendlat"},
+}
+
+// NewZoneCandidatesQuery builds the query of the journeys starting or ending in the bounding box of a zone.
+// Whether they are in the zone itself is decided by NewZoneTagUpdates.
+//
+// @param zone - Zone to tag
+func NewZoneCandidatesQuery(zone *domain.Zone) bson.D {
+	bound := zone.Geometry.Bound()
+	criteria := bson.A{}
+	for _, fields := range zoneFields {
+		criteria = append(criteria, bson.D{
+			{Key: fields.lon, Value: bson.D{{Key: "$gte", Value: bound.Min.Lon()}, {Key: "$lte", Value: bound.Max.Lon()}}},
+			{Key: fields.lat, Value: bson.D{{Key: "$gte", Value: bound.Min.Lat()}, {Key: "$lte", Value: bound.Max.Lat()}}},
+		})
+	}
+	return bson.D{{Key: "$or", Value: criteria}}
+}
+
+// NewZoneCandidatesProjection gives the projection of the stored journeys on their id and coordinates
+func NewZoneCandidatesProjection() bson.D {
+	projection := bson.D{
+		{Key: "_id", Value: 0},
+		{Key: "journeyid", Value: 1},
+	}
+	for _, fields := range zoneFields {
+		projection = append(projection, bson.E{Key: fields.lon, Value: 1}, bson.E{Key: fields.lat, Value: 1})
+	}
+	return projection
+}
+
+// NewZoneTagUpdates builds the updates adding a zone to the candidate journeys starting or ending in it.
+// Journeys are tagged with domain.TagZones, as at their import.
+//
+// @param zone - Zone to tag
+// @param journeys - Candidate journeys, with their id and coordinates
+func NewZoneTagUpdates(zone *domain.Zone, journeys []domain.Journey) []mongo.WriteModel {
+	zones := []domain.Zone{*zone}
+	updates := []mongo.WriteModel{}
+	for i := range journeys {
+		domain.TagZones(&journeys[i], zones)
+
+		tags := bson.D{}
+		if len(journeys[i].StartZones) > 0 {
+			tags = append(tags, bson.E{Key: zoneFields[0].zones, Value: zone.Name})
+		}
+		if len(journeys[i].EndZones) > 0 {
+			tags = append(tags, bson.E{Key: zoneFields[1].zones, Value: zone.Name})
+		}
+		if len(tags) == 0 {
+			continue
+		}
+		updates = append(updates, mongo.NewUpdateManyModel().
+			SetFilter(bson.D{{Key: "journeyid", Value: journeys[i].JourneyId}}).
+			SetUpdate(bson.D{{Key: "$addToSet", Value: tags}}))
+	}
+	return updates
+}
+
+// NewZoneUntagUpdates builds the updates removing a zone from the journeys
+//
+// @param name - Name of the zone
+func NewZoneUntagUpdates(name string) []mongo.WriteModel {
+	updates := []mongo.WriteModel{}
+	for _, fields := range zoneFields {
+		updates = append(updates, mongo.NewUpdateManyModel().
+			SetFilter(bson.D{{Key: fields.zones, Value: name}}).
+			SetUpdate(bson.D{{Key: "$pull", Value: bson.D{{Key: fields.zones, Value: name}}}}))
+	}
+	return updates
+}
@@ -0,0 +1,74 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/repo"
+
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestNewZoneCandidatesQuery(t *testing.T) {
+	polygon := orb.Polygon{{{-2.8, 47.6}, {-2.7, 47.6}, {-2.7, 47.7}, {-2.8, 47.6}}}
+	query := repo.NewZoneCandidatesQuery(&domain.Zone{Name: "vannes", Geometry: polygon})
+
+	assert.Equal(t, bson.D{{Key: "$or", Value: bson.A{
+		bson.D{
+			{Key: "journeystartlon", Value: bson.D{{Key: "$gte", Value: -2.8}, {Key: "$lte", Value: -2.7}}},
+			{Key: "journeystartlat", Value: bson.D{{Key: "$gte", Value: 47.6}, {Key: "$lte", Value: 47.7}}},
+		},
+		bson.D{
+			{Key: "journeyendlon", Value: bson.D{{Key: "$gte", Value: -2.8}, {Key: "$lte", Value: -2.7}}},
+			{Key: "journeyendlat", Value: bson.D{{Key: "$gte", Value: 47.6}, {Key: "$lte", Value: 47.7}}},
+		},
+	}}}, query)
+}
+
+func TestNewZoneTagUpdates(t *testing.T) {
+	polygon := orb.Polygon{{{-2.8, 47.6}, {-2.7, 47.6}, {-2.7, 47.7}, {-2.8, 47.6}}}
+	updates := repo.NewZoneTagUpdates(&domain.Zone{Name: "vannes", Geometry: polygon}, []domain.Journey{
+		// In the bounding box but out of the triangle
+		{JourneyId: 1, JourneyStartLon: -2.79, JourneyStartLat: 47.69},
+		{JourneyId: 2, JourneyStartLon: -2.71, JourneyStartLat: 47.61, JourneyEndLon: -2.72, JourneyEndLat: 47.62},
+		{JourneyId: 3, JourneyStartLon: -2.79, JourneyStartLat: 47.69, JourneyEndLon: -2.72, JourneyEndLat: 47.62},
+	})
+
+	assert.Len(t, updates, 2)
+	assert.Equal(t, bson.D{{Key: "journeyid", Value: int64(2)}}, updates[0].(*mongo.UpdateManyModel).Filter)
+	assert.Equal(t, bson.D{{Key: "$addToSet", Value: bson.D{
+		{Key: "startzones", Value: "vannes"},
+		{Key: "endzones", Value: "vannes"},
+	}}}, updates[0].(*mongo.UpdateManyModel).Update)
+	assert.Equal(t, bson.D{{Key: "journeyid", Value: int64(3)}}, updates[1].(*mongo.UpdateManyModel).Filter)
+	assert.Equal(t, bson.D{{Key: "$addToSet", Value: bson.D{{Key: "endzones", Value: "vannes"}}}}, updates[1].(*mongo.UpdateManyModel).Update)
+}
+
+func TestNewZoneUntagUpdates(t *testing.T) {
+	updates := repo.NewZoneUntagUpdates("vannes")
+
+	assert.Len(t, updates, 2)
+	assert.Equal(t, bson.D{{Key: "startzones", Value: "vannes"}}, updates[0].(*mongo.UpdateManyModel).Filter)
+	assert.Equal(t, bson.D{{Key: "$pull", Value: bson.D{{Key: "startzones", Value: "vannes"}}}}, updates[0].(*mongo.UpdateManyModel).Update)
+	assert.Equal(t, bson.D{{Key: "$pull", Value: bson.D{{Key: "endzones", Value: "vannes"}}}}, updates[1].(*mongo.UpdateManyModel).Update)
+}
+
+func TestNewZoneDocument(t *testing.T) {
+	document, err := repo.NewZoneDocument(&domain.Zone{
+		Name:        "vannes",
+		Description: "Centre de Vannes",
+		Geometry:    orb.Polygon{{{-2.8, 47.6}, {-2.7, 47.6}, {-2.7, 47.7}, {-2.8, 47.6}}},
+	})
+	assert.NoError(t, err)
+
+	data, err := bson.Marshal(document)
+	assert.NoError(t, err)
+	var stored bson.M
+	assert.NoError(t, bson.Unmarshal(data, &stored))
+	assert.Equal(t, "vannes", stored["_id"])
+	assert.Equal(t, "Centre de Vannes", stored["description"])
+	assert.Equal(t, "Polygon", stored["geometry"].(bson.M)["type"])
+}
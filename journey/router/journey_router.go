@@ -2,22 +2,112 @@
 package router
 
 import (
+	"encoding/csv"
+	"errors"
 	"fmt"
 	"mime/multipart"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/coutcout/covoiturage-csvreader/configuration"
 	"github.com/coutcout/covoiturage-csvreader/domain"
 	"github.com/coutcout/covoiturage-csvreader/messaging"
 
 	"github.com/gin-gonic/gin"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
 	"go.uber.org/zap"
 )
 
 type form struct {
-	Files []*multipart.FileHeader `form:"files" binding:"required"`
+	Files  []*multipart.FileHeader `form:"files" binding:"required"`
+	Preset string                  `form:"preset"`
+	domain.JourneyFilter
 }
 
+type exportQuery struct {
+	Format string `form:"format" binding:"required"`
+	Preset string `form:"preset"`
+	domain.JourneyFilter
+}
+
+type splitForm struct {
+	File      *multipart.FileHeader `form:"file" binding:"required"`
+	Dimension string                `form:"by" binding:"required"`
+	Format    string                `form:"format"`
+}
+
+type odQuery struct {
+	Level  string `form:"level" binding:"required"`
+	Format string `form:"format"`
+	Preset string `form:"preset"`
+	domain.JourneyFilter
+}
+
+type statsQuery struct {
+	Preset string `form:"preset"`
+	domain.JourneyFilter
+}
+
+type tripsQuery struct {
+	Offset int64  `form:"offset" binding:"min=0"`
+	Limit  int64  `form:"limit" binding:"min=0"`
+	Preset string `form:"preset"`
+	domain.JourneyFilter
+}
+
+type co2Query struct {
+	Dimension string `form:"by"`
+	Version   string `form:"version"`
+	Preset    string `form:"preset"`
+	domain.JourneyFilter
+}
+
+type anomaliesQuery struct {
+	Anomaly string `form:"anomaly"`
+	Offset  int64  `form:"offset" binding:"min=0"`
+	Limit   int64  `form:"limit" binding:"min=0"`
+	Preset  string `form:"preset"`
+	domain.JourneyFilter
+}
+
+type zoneBody struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Geometry    *domain.GeoPolygon `json:"geometry" binding:"required"`
+}
+
+type heatmapQuery struct {
+	Grid       string           `form:"grid"`
+	Side       string           `form:"side"`
+	Resolution float64          `form:"resolution" binding:"required"`
+	Bbox       *domain.GeoBound `form:"bbox" binding:"required"`
+	Preset     string           `form:"preset"`
+	domain.JourneyFilter
+}
+
+type tileQuery struct {
+	Preset string `form:"preset"`
+	domain.JourneyFilter
+}
+
+type incentiveQuery struct {
+	Format string `form:"format"`
+	Offset int64  `form:"offset" binding:"min=0"`
+	Limit  int64  `form:"limit" binding:"min=0"`
+	Preset string `form:"preset"`
+	domain.JourneyFilter
+}
+
+// Number of items of a page of the paginated listings (trips, anomalies, paid journeys of an incentive policy), when not requested,
+// and maximum number of them in a page
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
 type journeyRoute struct {
 	logger         *zap.SugaredLogger
 	journeyUsecase domain.JourneyUsecase
@@ -40,6 +130,57 @@ func NewJourneyRouter(logger *zap.SugaredLogger, cfg *configuration.Config, main
 	mainRouter.POST("/import", func(c *gin.Context) {
 		router.importJourney(c)
 	})
+	mainRouter.GET("/export", func(c *gin.Context) {
+		router.exportJourney(c)
+	})
+	mainRouter.POST("/split", func(c *gin.Context) {
+		router.splitJourney(c)
+	})
+	mainRouter.GET("/od", func(c *gin.Context) {
+		router.odMatrix(c)
+	})
+	mainRouter.GET("/trips", func(c *gin.Context) {
+		router.trips(c)
+	})
+	mainRouter.GET("/trips/export", func(c *gin.Context) {
+		router.exportTrips(c)
+	})
+	mainRouter.GET("/co2", func(c *gin.Context) {
+		router.co2(c)
+	})
+	mainRouter.POST("/incentives/simulate", func(c *gin.Context) {
+		router.simulateIncentive(c)
+	})
+	mainRouter.GET("/anomalies", func(c *gin.Context) {
+		router.anomalies(c)
+	})
+	mainRouter.GET("/heatmap", func(c *gin.Context) {
+		router.heatmap(c)
+	})
+	mainRouter.GET("/tiles/:layer/:z/:x/:y", func(c *gin.Context) {
+		router.tile(c)
+	})
+	mainRouter.POST("/zones", func(c *gin.Context) {
+		router.createZone(c)
+	})
+	mainRouter.GET("/zones", func(c *gin.Context) {
+		router.zones(c)
+	})
+	mainRouter.GET("/zones/:name", func(c *gin.Context) {
+		router.zone(c)
+	})
+	mainRouter.PUT("/zones/:name", func(c *gin.Context) {
+		router.updateZone(c)
+	})
+	mainRouter.DELETE("/zones/:name", func(c *gin.Context) {
+		router.deleteZone(c)
+	})
+	mainRouter.GET("/stats", func(c *gin.Context) {
+		c.JSON(http.StatusOK, messaging.StatsListResponseMessage{Stats: domain.Stats})
+	})
+	mainRouter.GET("/stats/:stat", func(c *gin.Context) {
+		router.stats(c)
+	})
 }
 
 // importJourney imports files from a file upload
@@ -60,6 +201,18 @@ func (j *journeyRoute) importJourney(c *gin.Context) {
 		return
 	}
 
+	filter, err := j.importFilter(c, &form)
+	if err != nil {
+		j.logger.Errorw("Error importing file",
+			"error", err.Error(),
+		)
+		c.Error(err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, messaging.SingleResponseMessage{
+			Errors: []string{err.Error()},
+		})
+		return
+	}
+
 	maxUploadFileSize := j.cfg.Journey.Import.MaxUploadFile * 1024
 	response := messaging.MultipleResponseMessage{
 		Files: []messaging.FileImportResponseMessage{},
@@ -100,10 +253,26 @@ func (j *journeyRoute) importJourney(c *gin.Context) {
 			break
 		}
 
-		nbLineImported, errors := j.journeyUsecase.ImportFromCSVFile(c, openedFile)
+		summary, errors := j.journeyUsecase.ImportFromFile(c, &domain.JourneyFile{
+			Name:        formFile.Filename,
+			ContentType: formFile.Header.Get("Content-Type"),
+			Reader:      openedFile,
+		}, filter)
+		fileResponse.Format = summary.Format
+		nbLineImported := summary.NbJourneyImported
 		response.Data.NbLineImported += int(nbLineImported)
+		response.Data.NbLineFilteredOut += int(summary.NbJourneyFilteredOut)
 		fileResponse.Errors = append(fileResponse.Errors, errors...)
 		fileResponse.NbLineImported = int(nbLineImported)
+		fileResponse.NbLineFilteredOut = int(summary.NbJourneyFilteredOut)
+		for _, processorStats := range summary.Processors {
+			fileResponse.Processors = append(fileResponse.Processors, messaging.ProcessorResponseMessage{
+				Name:        processorStats.Name,
+				NbProcessed: int(processorStats.NbProcessed),
+				NbDropped:   int(processorStats.NbDropped),
+				NbErrors:    int(processorStats.NbErrors),
+			})
+		}
 		if len(errors) > 0 {
 			response.Data.NbFilesWithErrors++
 			if nbLineImported == 0 {
@@ -123,3 +292,907 @@ func (j *journeyRoute) importJourney(c *gin.Context) {
 
 	c.JSON(responseStatus, response)
 }
+
+// importFilter builds the filter of an import from the preset and the criteria of the request.
+// Criteria given in the request, as form fields or in the query string, override the ones of the preset.
+//
+// @param c - gin. Context of the request
+// @param form - the submitted form
+func (j *journeyRoute) importFilter(c *gin.Context, form *form) (*domain.JourneyFilter, error) {
+	if err := c.ShouldBindQuery(&form.JourneyFilter); err != nil {
+		return nil, err
+	}
+	if preset := c.Query("preset"); preset != "" {
+		form.Preset = preset
+	}
+
+	return j.presetFilter(form.Preset, form.JourneyFilter)
+}
+
+// presetFilter applies the criteria of a request over the ones of a preset, and validates the result.
+//
+// @param presetName - Name of the preset, in the configured filters. Can be empty
+// @param criteria - Criteria of the request
+func (j *journeyRoute) presetFilter(presetName string, criteria domain.JourneyFilter) (*domain.JourneyFilter, error) {
+	filter := criteria
+	if presetName != "" {
+		preset, ok := j.cfg.Journey.Import.Filters[presetName]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter preset '%s'", presetName)
+		}
+		filter = preset.Override(&criteria)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return &filter, nil
+}
+
+// exportJourney writes the stored journeys matching the criteria of the query string in the requested format
+//
+// @param j - route to respond to requests to export journeys
+// @param c - gin. Context of the request
+func (j *journeyRoute) exportJourney(c *gin.Context) {
+	var query exportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		j.logger.Errorw("Error exporting journeys",
+			"error", err.Error(),
+		)
+		c.Error(err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, messaging.SingleResponseMessage{
+			Errors: []string{"'format' parameter is required"},
+		})
+		return
+	}
+
+	filter, err := j.presetFilter(query.Preset, query.JourneyFilter)
+	if err == nil {
+		var exporter domain.JourneyExporter
+		if exporter, err = j.journeyUsecase.Exporter(query.Format); err == nil {
+			j.export(c, exporter, filter)
+			return
+		}
+	}
+
+	j.logger.Errorw("Error exporting journeys",
+		"error", err.Error(),
+	)
+	c.Error(err)
+	c.AbortWithStatusJSON(http.StatusBadRequest, messaging.SingleResponseMessage{
+		Errors: []string{err.Error()},
+	})
+}
+
+// export streams the exported file in the response.
+// Once the file has started to be sent, errors can't change the response status anymore and are only logged.
+func (j *journeyRoute) export(c *gin.Context, exporter domain.JourneyExporter, filter *domain.JourneyFilter) {
+	c.Header("Content-Type", exporter.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"journeys%s\"", exporter.Extension()))
+
+	nbExported, err := j.journeyUsecase.Export(c, exporter, filter, c.Writer)
+	if err != nil {
+		j.logger.Errorw("Error exporting journeys",
+			"error", err.Error(),
+		)
+		c.Error(err)
+		if !c.Writer.Written() {
+			status := http.StatusInternalServerError
+			var tooLarge *domain.ExportTooLargeError
+			if errors.As(err, &tooLarge) {
+				status = http.StatusBadRequest
+			}
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			c.AbortWithStatusJSON(status, messaging.SingleResponseMessage{
+				Errors: []string{err.Error()},
+			})
+		}
+		return
+	}
+
+	j.logger.Debugw("Journeys exported",
+		"nbJourneys", nbExported,
+	)
+	if !c.Writer.Written() {
+		c.Status(http.StatusOK)
+		c.Writer.WriteHeaderNow()
+	}
+}
+
+// splitJourney splits an uploaded file into a file per value of a dimension, sent back as a zip archive
+//
+// @param j - route to respond to requests to split files
+// @param c - gin. Context of the request
+func (j *journeyRoute) splitJourney(c *gin.Context) {
+	var form splitForm
+	if err := c.ShouldBind(&form); err != nil {
+		j.badRequest(c, "Error splitting file", err, "'file' and 'by' parameters are required")
+		return
+	}
+	if form.Format == "" {
+		form.Format = domain.JourneyFormatCSV
+	}
+
+	maxUploadFileSize := j.cfg.Journey.Import.MaxUploadFile * 1024
+	if form.File.Size > maxUploadFileSize {
+		err := fmt.Errorf("file %s is too big (current: %d - max: %d)", form.File.Filename, form.File.Size, maxUploadFileSize)
+		j.badRequest(c, "Error splitting file", err, err.Error())
+		return
+	}
+	if err := domain.ValidateJourneySplitDimension(form.Dimension); err != nil {
+		j.badRequest(c, "Error splitting file", err, err.Error())
+		return
+	}
+	exporter, err := j.journeyUsecase.Exporter(form.Format)
+	if err != nil {
+		j.badRequest(c, "Error splitting file", err, err.Error())
+		return
+	}
+
+	openedFile, err := form.File.Open()
+	if err != nil {
+		j.badRequest(c, "Error splitting file", err, err.Error())
+		return
+	}
+	defer openedFile.Close()
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"journeys-%s.zip\"", form.Dimension))
+	summary, err := j.journeyUsecase.Split(c, &domain.JourneyFile{
+		Name:        form.File.Filename,
+		ContentType: form.File.Header.Get("Content-Type"),
+		Reader:      openedFile,
+	}, form.Dimension, exporter, c.Writer)
+	if err != nil {
+		c.Error(err)
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			c.AbortWithStatusJSON(http.StatusBadRequest, messaging.SingleResponseMessage{
+				Errors: []string{err.Error()},
+			})
+		}
+		return
+	}
+
+	j.logger.Debugw("File split",
+		"filename", form.File.Filename,
+		"nbParts", len(summary.Parts),
+	)
+	c.Status(http.StatusOK)
+}
+
+// odMatrix sends the flows of the stored journeys between origins and destinations, as JSON or CSV
+//
+// @param j - route to respond to requests for origin-destination matrices
+// @param c - gin. Context of the request
+func (j *journeyRoute) odMatrix(c *gin.Context) {
+	var query odQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		j.badRequest(c, "Error computing the OD matrix", err, "'level' parameter is required")
+		return
+	}
+	if query.Format == "" {
+		query.Format = domain.JourneyFormatJSON
+	}
+	if query.Format != domain.JourneyFormatJSON && query.Format != domain.JourneyFormatCSV {
+		err := fmt.Errorf("unsupported format '%s' (available: %s, %s)", query.Format, domain.JourneyFormatJSON, domain.JourneyFormatCSV)
+		j.badRequest(c, "Error computing the OD matrix", err, err.Error())
+		return
+	}
+	if err := domain.ValidateODLevel(query.Level); err != nil {
+		j.badRequest(c, "Error computing the OD matrix", err, err.Error())
+		return
+	}
+	filter, err := j.presetFilter(query.Preset, query.JourneyFilter)
+	if err != nil {
+		j.badRequest(c, "Error computing the OD matrix", err, err.Error())
+		return
+	}
+
+	pairs, err := j.journeyUsecase.ODMatrix(c, query.Level, filter)
+	if err != nil {
+		c.Error(err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, messaging.SingleResponseMessage{
+			Errors: []string{err.Error()},
+		})
+		return
+	}
+
+	if query.Format == domain.JourneyFormatCSV {
+		j.writeODMatrixCsv(c, query.Level, pairs)
+		return
+	}
+
+	response := messaging.ODMatrixResponseMessage{
+		Level: query.Level,
+		Pairs: make([]messaging.ODPairResponseMessage, 0, len(pairs)),
+	}
+	for _, pair := range pairs {
+		response.Pairs = append(response.Pairs, messaging.ODPairResponseMessage(pair))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// writeODMatrixCsv writes the OD pairs as a CSV file, with the separator of the official journey files
+func (j *journeyRoute) writeODMatrixCsv(c *gin.Context, level string, pairs []domain.ODPair) {
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"od-%s.csv\"", level))
+	c.Status(http.StatusOK)
+
+	csvWriter := csv.NewWriter(c.Writer)
+	csvWriter.Comma = ';'
+	csvWriter.Write([]string{"origin", "destination", "nb_journeys", "passenger_seats", "average_distance", "average_duration"})
+	for _, pair := range pairs {
+		csvWriter.Write([]string{
+			pair.Origin,
+			pair.Destination,
+			strconv.FormatInt(pair.NbJourneys, 10),
+			strconv.FormatInt(pair.PassengerSeats, 10),
+			strconv.FormatFloat(pair.AverageDistance, 'f', 1, 64),
+			strconv.FormatFloat(pair.AverageDuration, 'f', 1, 64),
+		})
+	}
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		j.logger.Errorw("Error writing the OD matrix",
+			"error", err.Error(),
+		)
+		c.Error(err)
+	}
+}
+
+// stats sends the number of stored journeys by bucket of a statistic
+//
+// @param j - route to respond to requests for statistics
+// @param c - gin. Context of the request
+func (j *journeyRoute) stats(c *gin.Context) {
+	stat := c.Param("stat")
+	if err := domain.ValidateStat(stat); err != nil {
+		c.Error(err)
+		c.AbortWithStatusJSON(http.StatusNotFound, messaging.SingleResponseMessage{
+			Errors: []string{err.Error()},
+		})
+		return
+	}
+
+	var query statsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		j.badRequest(c, "Error computing statistics", err, err.Error())
+		return
+	}
+	filter, err := j.presetFilter(query.Preset, query.JourneyFilter)
+	if err != nil {
+		j.badRequest(c, "Error computing statistics", err, err.Error())
+		return
+	}
+
+	buckets, err := j.journeyUsecase.Stats(c, stat, filter)
+	if err != nil {
+		c.Error(err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, messaging.SingleResponseMessage{
+			Errors: []string{err.Error()},
+		})
+		return
+	}
+
+	response := messaging.StatsResponseMessage{
+		Stat:    stat,
+		Buckets: make([]messaging.StatBucketResponseMessage, 0, len(buckets)),
+	}
+	for _, bucket := range buckets {
+		response.Buckets = append(response.Buckets, messaging.StatBucketResponseMessage(bucket))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// trips sends a page of the trips of the stored journeys, gathered by TripId
+//
+// @param j - route to respond to requests for trips
+// @param c - gin. Context of the request
+func (j *journeyRoute) trips(c *gin.Context) {
+	var query tripsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		j.badRequest(c, "Error reading trips", err, err.Error())
+		return
+	}
+	limit, err := pageLimit(query.Limit)
+	if err != nil {
+		j.badRequest(c, "Error reading trips", err, err.Error())
+		return
+	}
+	query.Limit = limit
+	filter, err := j.presetFilter(query.Preset, query.JourneyFilter)
+	if err != nil {
+		j.badRequest(c, "Error reading trips", err, err.Error())
+		return
+	}
+
+	trips, err := j.journeyUsecase.Trips(c, filter, query.Offset, query.Limit)
+	if err != nil {
+		c.Error(err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, messaging.SingleResponseMessage{
+			Errors: []string{err.Error()},
+		})
+		return
+	}
+
+	response := messaging.TripsResponseMessage{
+		Offset: query.Offset,
+		Limit:  query.Limit,
+		Trips:  make([]messaging.TripResponseMessage, 0, len(trips)),
+	}
+	for _, trip := range trips {
+		response.Trips = append(response.Trips, messaging.TripResponseMessage{
+			TripId:         trip.TripId.String(),
+			NbPassengers:   trip.NbPassengers,
+			PassengerSeats: trip.PassengerSeats,
+			StartDatetime:  optionalTime(trip.StartDatetime),
+			EndDatetime:    optionalTime(trip.EndDatetime),
+			JourneyIds:     trip.JourneyIds,
+			Warnings:       trip.Warnings,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// exportTrips writes the trips of the stored journeys matching the criteria of the query string in the requested format.
+// Once the file has started to be sent, errors can't change the response status anymore and are only logged.
+//
+// @param j - route to respond to requests to export trips
+// @param c - gin. Context of the request
+func (j *journeyRoute) exportTrips(c *gin.Context) {
+	var query exportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		j.badRequest(c, "Error exporting trips", err, "'format' parameter is required")
+		return
+	}
+	filter, err := j.presetFilter(query.Preset, query.JourneyFilter)
+	if err != nil {
+		j.badRequest(c, "Error exporting trips", err, err.Error())
+		return
+	}
+	exporter, err := j.journeyUsecase.TripExporter(query.Format)
+	if err != nil {
+		j.badRequest(c, "Error exporting trips", err, err.Error())
+		return
+	}
+
+	c.Header("Content-Type", exporter.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"trips%s\"", exporter.Extension()))
+	nbExported, err := j.journeyUsecase.ExportTrips(c, exporter, filter, c.Writer)
+	if err != nil {
+		c.Error(err)
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, messaging.SingleResponseMessage{
+				Errors: []string{err.Error()},
+			})
+		}
+		return
+	}
+
+	j.logger.Debugw("Trips exported",
+		"nbTrips", nbExported,
+	)
+	if !c.Writer.Written() {
+		c.Status(http.StatusOK)
+		c.Writer.WriteHeaderNow()
+	}
+}
+
+// co2 sends the estimates of the emissions avoided by the stored journeys, in total or by value of a dimension
+//
+// @param j - route to respond to requests for CO2 estimates
+// @param c - gin. Context of the request
+func (j *journeyRoute) co2(c *gin.Context) {
+	var query co2Query
+	if err := c.ShouldBindQuery(&query); err != nil {
+		j.badRequest(c, "Error estimating the CO2 savings", err, err.Error())
+		return
+	}
+	if err := domain.ValidateCo2Dimension(query.Dimension); err != nil {
+		j.badRequest(c, "Error estimating the CO2 savings", err, err.Error())
+		return
+	}
+	if _, ok := j.cfg.Journey.Co2.Factors[query.Version]; query.Version != "" && !ok {
+		err := fmt.Errorf("unknown emission factors version '%s'", query.Version)
+		j.badRequest(c, "Error estimating the CO2 savings", err, err.Error())
+		return
+	}
+	filter, err := j.presetFilter(query.Preset, query.JourneyFilter)
+	if err != nil {
+		j.badRequest(c, "Error estimating the CO2 savings", err, err.Error())
+		return
+	}
+
+	report, err := j.journeyUsecase.Co2(c, query.Dimension, query.Version, filter)
+	if err != nil {
+		c.Error(err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, messaging.SingleResponseMessage{
+			Errors: []string{err.Error()},
+		})
+		return
+	}
+
+	response := messaging.Co2ResponseMessage{
+		Version:     report.Version,
+		CarEmission: report.Factors.CarEmission,
+		ModalShift:  report.Factors.ModalShift,
+		Buckets:     make([]messaging.Co2BucketResponseMessage, 0, len(report.Buckets)),
+	}
+	for _, bucket := range report.Buckets {
+		response.Buckets = append(response.Buckets, messaging.Co2BucketResponseMessage(bucket))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// simulateIncentive runs the incentive policy of the request body over the stored journeys matching the criteria of the query string.
+// The result is sent as JSON with a page of the paid journeys, or all the paid journeys as CSV.
+//
+// @param j - route to respond to requests to simulate incentive policies
+// @param c - gin. Context of the request
+func (j *journeyRoute) simulateIncentive(c *gin.Context) {
+	var query incentiveQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		j.badRequest(c, "Error simulating an incentive policy", err, err.Error())
+		return
+	}
+	if query.Format == "" {
+		query.Format = domain.JourneyFormatJSON
+	}
+	if query.Format != domain.JourneyFormatJSON && query.Format != domain.JourneyFormatCSV {
+		err := fmt.Errorf("unsupported format '%s' (available: %s, %s)", query.Format, domain.JourneyFormatJSON, domain.JourneyFormatCSV)
+		j.badRequest(c, "Error simulating an incentive policy", err, err.Error())
+		return
+	}
+	limit, err := pageLimit(query.Limit)
+	if err != nil {
+		j.badRequest(c, "Error simulating an incentive policy", err, err.Error())
+		return
+	}
+	query.Limit = limit
+	filter, err := j.presetFilter(query.Preset, query.JourneyFilter)
+	if err != nil {
+		j.badRequest(c, "Error simulating an incentive policy", err, err.Error())
+		return
+	}
+
+	var policy domain.IncentivePolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		j.badRequest(c, "Error simulating an incentive policy", err, err.Error())
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		j.badRequest(c, "Error simulating an incentive policy", err, err.Error())
+		return
+	}
+
+	if query.Format == domain.JourneyFormatCSV {
+		j.writeIncentiveJourneysCsv(c, &policy, filter)
+		return
+	}
+
+	result, err := j.journeyUsecase.SimulateIncentive(c, &policy, filter, query.Offset, query.Limit, nil)
+	if err != nil {
+		c.Error(err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, messaging.SingleResponseMessage{
+			Errors: []string{err.Error()},
+		})
+		return
+	}
+
+	response := messaging.IncentiveSimulationResponseMessage{
+		Policy:     policy.Name,
+		NbJourneys: result.NbJourneys,
+		NbEligible: result.NbEligible,
+		NbCapped:   result.NbCapped,
+		NbPaid:     result.NbPaid,
+		TotalCost:  result.TotalCost,
+		ByMonth:    make([]messaging.IncentiveBreakdownResponseMessage, 0, len(result.ByMonth)),
+		ByCommune:  make([]messaging.IncentiveBreakdownResponseMessage, 0, len(result.ByCommune)),
+		Offset:     query.Offset,
+		Limit:      query.Limit,
+		Journeys:   make([]messaging.IncentiveJourneyResponseMessage, 0, len(result.Journeys)),
+	}
+	for _, breakdown := range result.ByMonth {
+		response.ByMonth = append(response.ByMonth, messaging.IncentiveBreakdownResponseMessage(breakdown))
+	}
+	for _, breakdown := range result.ByCommune {
+		response.ByCommune = append(response.ByCommune, messaging.IncentiveBreakdownResponseMessage(breakdown))
+	}
+	for _, journey := range result.Journeys {
+		response.Journeys = append(response.Journeys, messaging.IncentiveJourneyResponseMessage{
+			JourneyId:     journey.JourneyId,
+			TripId:        journey.TripId.String(),
+			StartDatetime: optionalTime(journey.StartDatetime),
+			Day:           journey.Day,
+			Commune:       journey.Commune,
+			Amount:        journey.Amount,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// writeIncentiveJourneysCsv simulates a policy and writes the journeys it pays as a CSV file while they are paid,
+// with the separator of the official journey files. Once the file has started to be sent, errors can't change
+// the response status anymore and are only logged.
+func (j *journeyRoute) writeIncentiveJourneysCsv(c *gin.Context, policy *domain.IncentivePolicy, filter *domain.JourneyFilter) {
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", "attachment; filename=\"incentive-journeys.csv\"")
+
+	csvWriter := csv.NewWriter(c.Writer)
+	csvWriter.Comma = ';'
+	csvWriter.Write([]string{"journey_id", "trip_id", "journey_start_datetime", "day", "commune", "amount"})
+	_, err := j.journeyUsecase.SimulateIncentive(c, policy, filter, 0, 0, func(journey *domain.IncentiveJourney) error {
+		start := ""
+		if !journey.StartDatetime.IsZero() {
+			start = journey.StartDatetime.Format(time.RFC3339)
+		}
+		return csvWriter.Write([]string{
+			strconv.FormatInt(journey.JourneyId, 10),
+			journey.TripId.String(),
+			start,
+			journey.Day,
+			journey.Commune,
+			strconv.FormatFloat(journey.Amount, 'f', 2, 64),
+		})
+	})
+	if err == nil {
+		csvWriter.Flush()
+		err = csvWriter.Error()
+	}
+	if err != nil {
+		c.Error(err)
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, messaging.SingleResponseMessage{
+				Errors: []string{err.Error()},
+			})
+			return
+		}
+		j.logger.Errorw("Error writing the incentive journeys",
+			"error", err.Error(),
+		)
+	}
+}
+
+// anomalies sends a page of the stored journeys flagged by the anomaly detection, by decreasing score
+//
+// @param j - route to respond to requests for anomalies
+// @param c - gin. Context of the request
+func (j *journeyRoute) anomalies(c *gin.Context) {
+	var query anomaliesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		j.badRequest(c, "Error reading anomalies", err, err.Error())
+		return
+	}
+	if query.Anomaly != "" {
+		if err := domain.ValidateAnomaly(query.Anomaly); err != nil {
+			j.badRequest(c, "Error reading anomalies", err, err.Error())
+			return
+		}
+	}
+	limit, err := pageLimit(query.Limit)
+	if err != nil {
+		j.badRequest(c, "Error reading anomalies", err, err.Error())
+		return
+	}
+	query.Limit = limit
+	filter, err := j.presetFilter(query.Preset, query.JourneyFilter)
+	if err != nil {
+		j.badRequest(c, "Error reading anomalies", err, err.Error())
+		return
+	}
+
+	journeys, err := j.journeyUsecase.Anomalies(c, filter, query.Anomaly, query.Offset, query.Limit)
+	if err != nil {
+		c.Error(err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, messaging.SingleResponseMessage{
+			Errors: []string{err.Error()},
+		})
+		return
+	}
+
+	response := messaging.AnomaliesResponseMessage{
+		Offset:   query.Offset,
+		Limit:    query.Limit,
+		Journeys: make([]messaging.AnomalyResponseMessage, 0, len(journeys)),
+	}
+	for _, journey := range journeys {
+		response.Journeys = append(response.Journeys, messaging.AnomalyResponseMessage{
+			JourneyId:       journey.JourneyId,
+			TripId:          journey.TripId.String(),
+			StartDatetime:   optionalTime(journey.JourneyStartDatetime),
+			StartTown:       journey.JourneyStartTown,
+			EndTown:         journey.JourneyEndTown,
+			JourneyDistance: journey.JourneyDistance,
+			JourneyDuration: journey.JourneyDuration,
+			Anomalies:       journey.Anomalies,
+			Score:           journey.AnomalyScore,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// createZone stores the zone of the request body and tags the stored journeys starting or ending in it
+//
+// @param j - route to respond to requests to create zones
+// @param c - gin. Context of the request
+func (j *journeyRoute) createZone(c *gin.Context) {
+	var body zoneBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		j.badRequest(c, "Error creating a zone", err, err.Error())
+		return
+	}
+	zone := &domain.Zone{Name: body.Name, Description: body.Description, Geometry: body.Geometry.Geometry}
+	if err := zone.Validate(); err != nil {
+		j.badRequest(c, "Error creating a zone", err, err.Error())
+		return
+	}
+
+	if err := j.journeyUsecase.CreateZone(c, zone); err != nil {
+		j.zoneError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, newZoneResponseMessage(zone))
+}
+
+// zones lists the zones
+//
+// @param j - route to respond to requests for zones
+// @param c - gin. Context of the request
+func (j *journeyRoute) zones(c *gin.Context) {
+	zones, err := j.journeyUsecase.Zones(c)
+	if err != nil {
+		j.zoneError(c, err)
+		return
+	}
+
+	response := messaging.ZonesResponseMessage{Zones: make([]messaging.ZoneResponseMessage, 0, len(zones))}
+	for i := range zones {
+		response.Zones = append(response.Zones, newZoneResponseMessage(&zones[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// zone sends the zone named in the path
+//
+// @param j - route to respond to requests for zones
+// @param c - gin. Context of the request
+func (j *journeyRoute) zone(c *gin.Context) {
+	zone, err := j.journeyUsecase.Zone(c, c.Param("name"))
+	if err != nil {
+		j.zoneError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newZoneResponseMessage(zone))
+}
+
+// updateZone replaces the zone named in the path by the one of the request body, and tags the stored journeys again
+//
+// @param j - route to respond to requests to update zones
+// @param c - gin. Context of the request
+func (j *journeyRoute) updateZone(c *gin.Context) {
+	var body zoneBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		j.badRequest(c, "Error updating a zone", err, err.Error())
+		return
+	}
+	name := c.Param("name")
+	if body.Name != "" && body.Name != name {
+		err := fmt.Errorf("a zone can't be renamed from '%s' to '%s'", name, body.Name)
+		j.badRequest(c, "Error updating a zone", err, err.Error())
+		return
+	}
+	zone := &domain.Zone{Name: name, Description: body.Description, Geometry: body.Geometry.Geometry}
+	if err := zone.Validate(); err != nil {
+		j.badRequest(c, "Error updating a zone", err, err.Error())
+		return
+	}
+
+	if err := j.journeyUsecase.UpdateZone(c, zone); err != nil {
+		j.zoneError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newZoneResponseMessage(zone))
+}
+
+// deleteZone removes the zone named in the path, and removes it from the stored journeys
+//
+// @param j - route to respond to requests to delete zones
+// @param c - gin. Context of the request
+func (j *journeyRoute) deleteZone(c *gin.Context) {
+	name := c.Param("name")
+	if err := j.journeyUsecase.DeleteZone(c, name); err != nil {
+		j.zoneError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, messaging.SingleResponseMessage{
+		Message: fmt.Sprintf("zone '%s' deleted", name),
+	})
+}
+
+// heatmap counts the journeys matching the filter of the query by cell of a grid, and sends the cells as GeoJSON
+//
+// @param j - route to respond to requests for heatmaps
+// @param c - gin. Context of the request
+func (j *journeyRoute) heatmap(c *gin.Context) {
+	var query heatmapQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		j.badRequest(c, "Error computing a heatmap", err, "'resolution' and 'bbox' parameters are required: "+err.Error())
+		return
+	}
+	request := &domain.HeatmapRequest{
+		Grid:       query.Grid,
+		Side:       query.Side,
+		Resolution: query.Resolution,
+		Bound:      query.Bbox.Bound,
+	}
+	if request.Grid == "" {
+		request.Grid = domain.HeatmapGridSquare
+	}
+	if request.Side == "" {
+		request.Side = domain.LocationStart
+	}
+	if err := request.Validate(); err != nil {
+		j.badRequest(c, "Error computing a heatmap", err, err.Error())
+		return
+	}
+	filter, err := j.presetFilter(query.Preset, query.JourneyFilter)
+	if err != nil {
+		j.badRequest(c, "Error computing a heatmap", err, err.Error())
+		return
+	}
+
+	cells, err := j.journeyUsecase.Heatmap(c, request, filter)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var tooLarge *domain.HeatmapTooLargeError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusBadRequest
+		}
+		c.Error(err)
+		c.AbortWithStatusJSON(status, messaging.SingleResponseMessage{
+			Errors: []string{err.Error()},
+		})
+		return
+	}
+
+	collection := geojson.NewFeatureCollection()
+	for _, cell := range cells {
+		feature := geojson.NewFeature(cell.Polygon)
+		feature.Properties["cell"] = cell.Cell
+		feature.Properties["count"] = cell.NbJourneys
+		collection.Append(feature)
+	}
+	content, err := collection.MarshalJSON()
+	if err != nil {
+		c.Error(err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, messaging.SingleResponseMessage{
+			Errors: []string{err.Error()},
+		})
+		return
+	}
+	c.Data(http.StatusOK, "application/geo+json", content)
+}
+
+// tile sends the vector tile of a layer at /tiles/{layer}/{z}/{x}/{y}.mvt, for the journeys matching the filter of the query
+//
+// @param j - route to respond to requests for vector tiles
+// @param c - gin. Context of the request
+func (j *journeyRoute) tile(c *gin.Context) {
+	var query tileQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		j.badRequest(c, "Error generating a tile", err, err.Error())
+		return
+	}
+	request, err := tileRequest(c.Param("layer"), c.Param("z"), c.Param("x"), c.Param("y"))
+	if err == nil {
+		err = request.Validate()
+	}
+	if err != nil {
+		j.badRequest(c, "Error generating a tile", err, err.Error())
+		return
+	}
+	filter, err := j.presetFilter(query.Preset, query.JourneyFilter)
+	if err != nil {
+		j.badRequest(c, "Error generating a tile", err, err.Error())
+		return
+	}
+
+	content, err := j.journeyUsecase.Tile(c, request, filter)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var tooLarge *domain.TileTooLargeError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusBadRequest
+		}
+		c.Error(err)
+		c.AbortWithStatusJSON(status, messaging.SingleResponseMessage{
+			Errors: []string{err.Error()},
+		})
+		return
+	}
+	if ttl := j.cfg.Journey.Tiles.CacheTTL; ttl > 0 {
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int64(ttl.Seconds())))
+	}
+	c.Data(http.StatusOK, "application/vnd.mapbox-vector-tile", content)
+}
+
+// tileRequest reads the layer and the coordinates of a tile from the path of a request, the last one ending with .mvt
+func tileRequest(layer string, z string, x string, y string) (*domain.TileRequest, error) {
+	if !strings.HasSuffix(y, ".mvt") {
+		return nil, fmt.Errorf("a tile must be requested as /tiles/{layer}/{z}/{x}/{y}.mvt")
+	}
+	coordinates := make([]uint32, 0, 3)
+	for _, value := range []string{z, x, strings.TrimSuffix(y, ".mvt")} {
+		coordinate, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tile coordinate '%s'", value)
+		}
+		coordinates = append(coordinates, uint32(coordinate))
+	}
+	return &domain.TileRequest{
+		Layer: layer,
+		Tile:  maptile.New(coordinates[1], coordinates[2], maptile.Zoom(coordinates[0])),
+	}, nil
+}
+
+// zoneError answers with the status of an error of the zone usecases
+func (j *journeyRoute) zoneError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, domain.ErrZoneNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, domain.ErrZoneExists):
+		status = http.StatusConflict
+	}
+	c.Error(err)
+	c.AbortWithStatusJSON(status, messaging.SingleResponseMessage{
+		Errors: []string{err.Error()},
+	})
+}
+
+func newZoneResponseMessage(zone *domain.Zone) messaging.ZoneResponseMessage {
+	return messaging.ZoneResponseMessage{
+		Name:        zone.Name,
+		Description: zone.Description,
+		Geometry:    geojson.NewGeometry(zone.Geometry),
+	}
+}
+
+// pageLimit gives the number of items of a page of a listing, the default one when not requested
+//
+// @param limit - Requested number of items, 0 when not requested
+func pageLimit(limit int64) (int64, error) {
+	if limit == 0 {
+		return defaultPageLimit, nil
+	}
+	if limit > maxPageLimit {
+		return 0, fmt.Errorf("limit %d is too high (max: %d)", limit, maxPageLimit)
+	}
+	return limit, nil
+}
+
+// optionalTime gives nil for an unknown time
+func optionalTime(value time.Time) *time.Time {
+	if value.IsZero() {
+		return nil
+	}
+	return &value
+}
+
+// badRequest logs an error of the request and answers with a message
+func (j *journeyRoute) badRequest(c *gin.Context, logMessage string, err error, message string) {
+	j.logger.Errorw(logMessage,
+		"error", err.Error(),
+	)
+	c.Error(err)
+	c.AbortWithStatusJSON(http.StatusBadRequest, messaging.SingleResponseMessage{
+		Errors: []string{message},
+	})
+}
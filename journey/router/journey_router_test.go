@@ -4,21 +4,30 @@ package router_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
+	"net/url"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 
 	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
 	"github.com/coutcout/covoiturage-csvreader/journey/router"
 	"github.com/coutcout/covoiturage-csvreader/messaging"
 	"github.com/coutcout/covoiturage-csvreader/mocks"
@@ -68,8 +77,11 @@ func TestImportCSVFile(t *testing.T) {
 			if test.hasErrors {
 				returnedErrors = []string{"error"}
 			}
-			mock := mockJUsecase.On("ImportFromCSVFile", mock.Anything, mock.Anything)
-			mock.Return(int64(test.expectedImportedLine), returnedErrors)
+			mock := mockJUsecase.On("ImportFromFile", mock.Anything, mock.Anything, mock.Anything)
+			mock.Return(&domain.ImportSummary{
+				NbJourneyImported: int64(test.expectedImportedLine),
+				Processors:        []domain.ProcessorStats{{Name: "insee", NbProcessed: int64(test.expectedImportedLine)}},
+			}, returnedErrors)
 
 			body := &bytes.Buffer{}
 			writer := multipart.NewWriter(body)
@@ -103,6 +115,9 @@ func TestImportCSVFile(t *testing.T) {
 			for _, fileMessage := range response.Files {
 				if fileMessage.Filename == test.filename {
 					assert.Equal(t, test.expectedImportedLine, fileMessage.NbLineImported)
+					if test.statusCode == http.StatusAccepted {
+						assert.Equal(t, []messaging.ProcessorResponseMessage{{Name: "insee", NbProcessed: test.expectedImportedLine}}, fileMessage.Processors)
+					}
 					if test.hasErrors {
 						assert.NotEmpty(t, fileMessage.Errors)
 					}
@@ -151,3 +166,977 @@ func TestImportCSVFile_wrongParameter(t *testing.T) {
 		assert.NotEmpty(t, response.Errors)
 	})
 }
+
+func TestImportCSVFile_filter(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	type tmplTest struct {
+		name           string
+		query          string
+		statusCode     int
+		expectedFilter *domain.JourneyFilter
+	}
+
+	tests := []tmplTest{
+		{"no_filter", "", http.StatusAccepted, &domain.JourneyFilter{}},
+		{"parameters", "?department=35&operator-class=c&from=2023-01-01", http.StatusAccepted, &domain.JourneyFilter{
+			Departments:     []string{"35"},
+			OperatorClasses: []string{"C"},
+			From:            time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		{"preset", "?preset=brittany", http.StatusAccepted, &domain.JourneyFilter{
+			Departments:     []string{"22", "29", "35", "56"},
+			OperatorClasses: []string{"B", "C"},
+		}},
+		{"preset_overridden", "?preset=brittany&operator-class=A", http.StatusAccepted, &domain.JourneyFilter{
+			Departments:     []string{"22", "29", "35", "56"},
+			OperatorClasses: []string{"A"},
+		}},
+		{"unknown_preset", "?preset=unknown", http.StatusBadRequest, nil},
+		{"unknown_operator_class", "?operator-class=D", http.StatusBadRequest, nil},
+		{"wrong_period", "?from=2023-02-01&to=2023-01-01", http.StatusBadRequest, nil},
+		{"wrong_date", "?from=01/02/2023", http.StatusBadRequest, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := mockJUsecase.On("ImportFromFile", mock.Anything, mock.Anything, test.expectedFilter)
+			mock.Return(&domain.ImportSummary{NbJourneyImported: 2, NbJourneyFilteredOut: 1}, nil)
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			f, err := writer.CreateFormFile("files", "dataset_1.csv")
+			if err != nil {
+				logger.Error(err)
+			}
+
+			file, err := os.Open(filepath.Join("testdata", "dataset_1.csv"))
+			if err != nil {
+				logger.Error(err)
+			}
+
+			_, err2 := io.Copy(f, file)
+			if err != nil {
+				logger.Error(err2)
+			}
+
+			writer.Close()
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/import"+test.query, body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.statusCode, w.Code)
+			if test.statusCode == http.StatusAccepted {
+				response := messaging.MultipleResponseMessage{}
+				json.NewDecoder(w.Body).Decode(&response)
+				assert.Equal(t, 1, response.Data.NbLineFilteredOut)
+				assert.Equal(t, 1, response.Files[0].NbLineFilteredOut)
+			}
+
+			mock.Unset()
+		})
+	}
+}
+
+func TestImportCSVFile_file(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	matchFile := func(file *domain.JourneyFile) bool {
+		content, _ := io.ReadAll(file.Reader)
+		return file.Name == "journeys.parquet" && file.ContentType == "application/vnd.apache.parquet" && string(content) == "content"
+	}
+	mockJUsecase.On("ImportFromFile", mock.Anything, mock.MatchedBy(matchFile), mock.Anything).
+		Return(&domain.ImportSummary{Format: domain.JourneyFormatParquet, NbJourneyImported: 1}, nil)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="files"; filename="journeys.parquet"`)
+	header.Set("Content-Type", "application/vnd.apache.parquet")
+	f, err := writer.CreatePart(header)
+	if err != nil {
+		logger.Error(err)
+	}
+	f.Write([]byte("content"))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/import", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	response := messaging.MultipleResponseMessage{}
+	json.NewDecoder(w.Body).Decode(&response)
+	assert.Equal(t, domain.JourneyFormatParquet, response.Files[0].Format)
+	mockJUsecase.AssertExpectations(t)
+}
+
+func TestExportJourney(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	exporter := new(mocks.JourneyExporter)
+	exporter.On("ContentType").Return("application/vnd.apache.parquet")
+	exporter.On("Extension").Return(".parquet")
+	mockJUsecase.On("Exporter", domain.JourneyFormatParquet).Return(exporter, nil)
+
+	expectedFilter := &domain.JourneyFilter{
+		Departments:     []string{"22", "29", "35", "56"},
+		OperatorClasses: []string{"A"},
+	}
+	mockJUsecase.On("Export", mock.Anything, exporter, expectedFilter, mock.Anything).
+		Run(func(args mock.Arguments) {
+			args.Get(3).(io.Writer).Write([]byte("PAR1"))
+		}).
+		Return(int64(1), nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/export?format=parquet&preset=brittany&operator-class=A", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/vnd.apache.parquet", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="journeys.parquet"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "PAR1", w.Body.String())
+	mockJUsecase.AssertExpectations(t)
+}
+
+func TestExportJourney_areas(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	exporter := new(mocks.JourneyExporter)
+	exporter.On("ContentType").Return("text/csv")
+	exporter.On("Extension").Return(".csv")
+	mockJUsecase.On("Exporter", domain.JourneyFormatCSV).Return(exporter, nil)
+
+	expectedFilter := &domain.JourneyFilter{
+		StartNear: &domain.GeoCircle{Center: orb.Point{-1.68, 48.11}, Radius: 5000},
+		EndWithin: &domain.GeoPolygon{Geometry: orb.Polygon{{{-2.8, 47.6}, {-2.7, 47.6}, {-2.7, 47.7}, {-2.8, 47.6}}}},
+	}
+	mockJUsecase.On("Export", mock.Anything, exporter, expectedFilter, mock.Anything).Return(int64(0), nil)
+
+	query := url.Values{
+		"format":     {"csv"},
+		"start-near": {"[-1.68,48.11,5000]"},
+		"end-within": {`{"type":"Polygon","coordinates":[[[-2.8,47.6],[-2.7,47.6],[-2.7,47.7],[-2.8,47.6]]]}`},
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/export?"+query.Encode(), nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockJUsecase.AssertExpectations(t)
+}
+
+func TestExportJourney_wrongParameter(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	mockJUsecase.On("Exporter", "xlsx").Return(nil, errors.New("unsupported export format 'xlsx' (available: parquet)"))
+
+	type tmplTest struct {
+		name  string
+		query string
+	}
+
+	tests := []tmplTest{
+		{"no_format", ""},
+		{"unknown_format", "?format=xlsx"},
+		{"unknown_preset", "?format=parquet&preset=unknown"},
+		{"wrong_period", "?format=parquet&from=2023-02-01&to=2023-01-01"},
+		{"wrong_disc", "?format=parquet&start-near=" + url.QueryEscape("[-1.68,48.11]")},
+		{"wrong_area", "?format=parquet&end-within=" + url.QueryEscape(`{"type":"Point","coordinates":[-1.68,48.11]}`)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/export"+test.query, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			response := messaging.SingleResponseMessage{}
+			json.NewDecoder(w.Body).Decode(&response)
+			assert.NotEmpty(t, response.Errors)
+		})
+	}
+	mockJUsecase.AssertNotCalled(t, "Export", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExportJourney_failure(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	exporter := new(mocks.JourneyExporter)
+	exporter.On("ContentType").Return("application/vnd.apache.parquet")
+	exporter.On("Extension").Return(".parquet")
+	mockJUsecase.On("Exporter", domain.JourneyFormatParquet).Return(exporter, nil)
+	mockJUsecase.On("Export", mock.Anything, exporter, mock.Anything, mock.Anything).Return(int64(0), errors.New("database unavailable"))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/export?format=parquet", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+	response := messaging.SingleResponseMessage{}
+	json.NewDecoder(w.Body).Decode(&response)
+	assert.Equal(t, []string{"database unavailable"}, response.Errors)
+}
+
+func TestExportJourney_tooLarge(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	exporter := new(mocks.JourneyExporter)
+	exporter.On("ContentType").Return("application/geo+json")
+	exporter.On("Extension").Return(".geojson")
+	mockJUsecase.On("Exporter", domain.JourneyFormatGeoJSONStart).Return(exporter, nil)
+	mockJUsecase.On("Export", mock.Anything, exporter, mock.Anything, mock.Anything).
+		Return(int64(0), &domain.ExportTooLargeError{NbJourneys: 10, MaxJourneys: 3})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/export?format=geojson-start", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	response := messaging.SingleResponseMessage{}
+	json.NewDecoder(w.Body).Decode(&response)
+	assert.Contains(t, response.Errors[0], "too many journeys")
+}
+
+// splitRequest builds a split request uploading dataset_1.csv with the given form fields
+func splitRequest(fields map[string]string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	f, err := writer.CreateFormFile("file", "dataset_1.csv")
+	if err != nil {
+		logger.Error(err)
+	}
+	content, err := os.ReadFile(filepath.Join("testdata", "dataset_1.csv"))
+	if err != nil {
+		logger.Error(err)
+	}
+	f.Write(content)
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", "/split", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestSplitJourney(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	exporter := new(mocks.JourneyExporter)
+	mockJUsecase.On("Exporter", domain.JourneyFormatCSV).Return(exporter, nil)
+	matchFile := func(file *domain.JourneyFile) bool { return file.Name == "dataset_1.csv" }
+	mockJUsecase.On("Split", mock.Anything, mock.MatchedBy(matchFile), domain.JourneySplitMonth, exporter, mock.Anything).
+		Run(func(args mock.Arguments) {
+			args.Get(4).(io.Writer).Write([]byte("PK"))
+		}).
+		Return(&domain.SplitSummary{NbJourneys: 3}, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, splitRequest(map[string]string{"by": "month"}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="journeys-month.zip"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "PK", w.Body.String())
+	mockJUsecase.AssertExpectations(t)
+}
+
+func TestSplitJourney_wrongParameter(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	mockJUsecase.On("Exporter", "xlsx").Return(nil, errors.New("unsupported export format 'xlsx' (available: csv)"))
+
+	type tmplTest struct {
+		name   string
+		fields map[string]string
+	}
+
+	tests := []tmplTest{
+		{"no_dimension", map[string]string{}},
+		{"unknown_dimension", map[string]string{"by": "town"}},
+		{"unknown_format", map[string]string{"by": "month", "format": "xlsx"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, splitRequest(test.fields))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			response := messaging.SingleResponseMessage{}
+			json.NewDecoder(w.Body).Decode(&response)
+			assert.NotEmpty(t, response.Errors)
+		})
+	}
+	mockJUsecase.AssertNotCalled(t, "Split", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSplitJourney_failure(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	exporter := new(mocks.JourneyExporter)
+	mockJUsecase.On("Exporter", domain.JourneyFormatParquet).Return(exporter, nil)
+	mockJUsecase.On("Split", mock.Anything, mock.Anything, domain.JourneySplitStartDepartment, exporter, mock.Anything).
+		Return(nil, errors.New("no journey can be read from file dataset_1.csv"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, splitRequest(map[string]string{"by": "start-department", "format": "parquet"}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+}
+
+func TestODMatrix(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	pairs := []domain.ODPair{
+		{Origin: "35", Destination: "56", NbJourneys: 2, PassengerSeats: 3, AverageDistance: 12000, AverageDuration: 900.5},
+		{Origin: "35", Destination: "35", NbJourneys: 1, PassengerSeats: 1, AverageDistance: 5000, AverageDuration: 600},
+	}
+	expectedFilter := &domain.JourneyFilter{
+		OperatorClasses: []string{"C"},
+		From:            time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:              time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+	mockJUsecase.On("ODMatrix", mock.Anything, domain.ODLevelDepartment, expectedFilter).Return(pairs, nil)
+
+	t.Run("json", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/od?level=department&operator-class=c&from=2023-01-01&to=2023-01-31", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		response := messaging.ODMatrixResponseMessage{}
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, domain.ODLevelDepartment, response.Level)
+		assert.Len(t, response.Pairs, 2)
+		assert.Equal(t, messaging.ODPairResponseMessage(pairs[0]), response.Pairs[0])
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/od?level=department&format=csv&operator-class=C&from=2023-01-01&to=2023-01-31", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+		assert.Equal(t, "origin;destination;nb_journeys;passenger_seats;average_distance;average_duration\n"+
+			"35;56;2;3;12000.0;900.5\n"+
+			"35;35;1;1;5000.0;600.0\n", w.Body.String())
+	})
+}
+
+func TestODMatrix_wrongParameter(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	type tmplTest struct {
+		name  string
+		query string
+	}
+
+	tests := []tmplTest{
+		{"no_level", ""},
+		{"unknown_level", "?level=region"},
+		{"unknown_format", "?level=insee&format=xlsx"},
+		{"wrong_period", "?level=insee&from=2023-02-01&to=2023-01-01"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/od"+test.query, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+	mockJUsecase.AssertNotCalled(t, "ODMatrix", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestStats(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	expectedFilter := &domain.JourneyFilter{
+		Departments:     []string{"22", "29", "35", "56"},
+		OperatorClasses: []string{"B", "C"},
+	}
+	mockJUsecase.On("Stats", mock.Anything, domain.StatPerHour, expectedFilter).
+		Return([]domain.StatBucket{{Key: "8", NbJourneys: 2, Share: 1}}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/stats/per-hour?preset=brittany", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := messaging.StatsResponseMessage{}
+	json.NewDecoder(w.Body).Decode(&response)
+	assert.Equal(t, messaging.StatsResponseMessage{
+		Stat:    domain.StatPerHour,
+		Buckets: []messaging.StatBucketResponseMessage{{Key: "8", NbJourneys: 2, Share: 1}},
+	}, response)
+}
+
+func TestStats_list(t *testing.T) {
+	r := gin.Default()
+	router.NewJourneyRouter(&logger, config, r, new(mocks.JourneyUsecase))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/stats", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := messaging.StatsListResponseMessage{}
+	json.NewDecoder(w.Body).Decode(&response)
+	assert.Equal(t, domain.Stats, response.Stats)
+}
+
+func TestStats_wrongParameter(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	type tmplTest struct {
+		name       string
+		path       string
+		statusCode int
+	}
+
+	tests := []tmplTest{
+		{"unknown_stat", "/stats/per-year", http.StatusNotFound},
+		{"unknown_preset", "/stats/per-day?preset=unknown", http.StatusBadRequest},
+		{"wrong_date", "/stats/per-day?from=01/02/2023", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", test.path, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.statusCode, w.Code)
+		})
+	}
+	mockJUsecase.AssertNotCalled(t, "Stats", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTrips(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	start := time.Date(2023, 1, 2, 8, 0, 0, 0, time.UTC)
+	trip := domain.Trip{
+		TripId:         uuid.MustParse("5a280bc3-f42d-4d3b-9554-c6fe5322edb5"),
+		NbPassengers:   2,
+		PassengerSeats: 2,
+		StartDatetime:  start,
+		JourneyIds:     []int64{1, 2},
+		Warnings:       []string{},
+	}
+	mockJUsecase.On("Trips", mock.Anything, &domain.JourneyFilter{OperatorClasses: []string{"C"}}, int64(20), int64(100)).
+		Return([]domain.Trip{trip}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/trips?offset=20&operator-class=C", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := messaging.TripsResponseMessage{}
+	json.NewDecoder(w.Body).Decode(&response)
+	assert.Equal(t, messaging.TripsResponseMessage{
+		Offset: 20,
+		Limit:  100,
+		Trips: []messaging.TripResponseMessage{{
+			TripId:         "5a280bc3-f42d-4d3b-9554-c6fe5322edb5",
+			NbPassengers:   2,
+			PassengerSeats: 2,
+			StartDatetime:  &start,
+			JourneyIds:     []int64{1, 2},
+			Warnings:       []string{},
+		}},
+	}, response)
+}
+
+func TestTrips_wrongParameter(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	for _, query := range []string{"limit=5000", "offset=-1", "preset=unknown"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/trips?"+query, nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	mockJUsecase.AssertNotCalled(t, "Trips")
+}
+
+func TestExportTrips(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	exporter := new(mocks.TripExporter)
+	exporter.On("ContentType").Return("text/csv")
+	exporter.On("Extension").Return(".csv")
+	mockJUsecase.On("TripExporter", domain.JourneyFormatCSV).Return(exporter, nil)
+	mockJUsecase.On("TripExporter", mock.Anything).Return(nil, errors.New("unsupported export format"))
+	mockJUsecase.On("ExportTrips", mock.Anything, exporter, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			args.Get(3).(io.Writer).Write([]byte("trip_id\n"))
+		}).
+		Return(int64(0), nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/trips/export?format=csv", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "attachment; filename=\"trips.csv\"", w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "trip_id\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/trips/export?format=parquet", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCo2(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	mockJUsecase.On("Co2", mock.Anything, domain.JourneySplitOperatorClass, "2022", &domain.JourneyFilter{}).
+		Return(&domain.Co2Report{
+			Version: "2022",
+			Factors: domain.EmissionFactors{CarEmission: 0.2, ModalShift: 1},
+			Buckets: []domain.Co2Bucket{{Key: "C", NbJourneys: 1, PassengerKm: 10, VehicleKmAvoided: 10, Co2Saved: 2}},
+		}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/co2?by=operator-class&version=2022", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := messaging.Co2ResponseMessage{}
+	json.NewDecoder(w.Body).Decode(&response)
+	assert.Equal(t, messaging.Co2ResponseMessage{
+		Version:     "2022",
+		CarEmission: 0.2,
+		ModalShift:  1,
+		Buckets:     []messaging.Co2BucketResponseMessage{{Key: "C", NbJourneys: 1, PassengerKm: 10, VehicleKmAvoided: 10, Co2Saved: 2}},
+	}, response)
+}
+
+func TestCo2_wrongParameter(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	for _, query := range []string{"by=week", "version=1990", "preset=unknown"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/co2?"+query, nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	mockJUsecase.AssertNotCalled(t, "Co2")
+}
+
+func TestSimulateIncentive(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	start := time.Date(2023, 1, 2, 8, 0, 0, 0, time.UTC)
+	tripId := uuid.MustParse("5a280bc3-f42d-4d3b-9554-c6fe5322edb5")
+	expectedPolicy := &domain.IncentivePolicy{
+		Name:          "test",
+		DistanceBands: []domain.IncentiveDistanceBand{{MinDistance: 5000, MaxDistance: 50000, Fixed: 2, PerKm: 0.1}},
+		DailyCap:      2,
+	}
+	expectedFilter := &domain.JourneyFilter{
+		From: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2023, 3, 31, 0, 0, 0, 0, time.UTC),
+	}
+	journeys := []domain.IncentiveJourney{
+		{JourneyId: 1, TripId: tripId, StartDatetime: start, Day: "2023-01-02", Commune: "35238", Amount: 3},
+		{JourneyId: 2, TripId: tripId, StartDatetime: start, Day: "2023-01-02", Commune: "35238", Amount: 3},
+	}
+	result := &domain.IncentiveSimulationResult{
+		NbJourneys: 2,
+		NbEligible: 2,
+		NbPaid:     2,
+		TotalCost:  6,
+		ByMonth:    []domain.IncentiveBreakdown{{Key: "2023-01", NbJourneys: 2, Cost: 6}},
+		ByCommune:  []domain.IncentiveBreakdown{{Key: "35238", NbJourneys: 2, Cost: 6}},
+		Journeys:   journeys[1:],
+	}
+	mockJUsecase.On("SimulateIncentive", mock.Anything, expectedPolicy, expectedFilter, int64(1), int64(1), mock.Anything).
+		Return(result, nil)
+	// The CSV file gets every paid journey while it is simulated
+	mockJUsecase.On("SimulateIncentive", mock.Anything, expectedPolicy, expectedFilter, int64(0), int64(0), mock.Anything).
+		Run(func(args mock.Arguments) {
+			paid := args.Get(5).(func(*domain.IncentiveJourney) error)
+			for i := range journeys {
+				paid(&journeys[i])
+			}
+		}).
+		Return(result, nil)
+
+	body := `{"name": "test", "distance-bands": [{"min-distance": 5000, "max-distance": 50000, "fixed": 2, "per-km": 0.1}], "daily-cap": 2}`
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/incentives/simulate?offset=1&limit=1&from=2023-01-01&to=2023-03-31", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := messaging.IncentiveSimulationResponseMessage{}
+	json.NewDecoder(w.Body).Decode(&response)
+	assert.Equal(t, "test", response.Policy)
+	assert.Equal(t, int64(2), response.NbPaid)
+	assert.Equal(t, 6.0, response.TotalCost)
+	assert.Equal(t, []messaging.IncentiveBreakdownResponseMessage{{Key: "2023-01", NbJourneys: 2, Cost: 6}}, response.ByMonth)
+	assert.Equal(t, int64(1), response.Offset)
+	assert.Equal(t, int64(1), response.Limit)
+	assert.Equal(t, []messaging.IncentiveJourneyResponseMessage{{
+		JourneyId:     2,
+		TripId:        "5a280bc3-f42d-4d3b-9554-c6fe5322edb5",
+		StartDatetime: &start,
+		Day:           "2023-01-02",
+		Commune:       "35238",
+		Amount:        3,
+	}}, response.Journeys)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/incentives/simulate?format=csv&from=2023-01-01&to=2023-03-31", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Equal(t, "journey_id;trip_id;journey_start_datetime;day;commune;amount\n"+
+		"1;5a280bc3-f42d-4d3b-9554-c6fe5322edb5;2023-01-02T08:00:00Z;2023-01-02;35238;3.00\n"+
+		"2;5a280bc3-f42d-4d3b-9554-c6fe5322edb5;2023-01-02T08:00:00Z;2023-01-02;35238;3.00\n", w.Body.String())
+}
+
+func TestSimulateIncentive_defaultPage(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	mockJUsecase.On("SimulateIncentive", mock.Anything, mock.Anything, mock.Anything, int64(0), int64(100), mock.Anything).
+		Return(&domain.IncentiveSimulationResult{}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/incentives/simulate", bytes.NewBufferString(`{"distance-bands": [{"fixed": 1}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockJUsecase.AssertExpectations(t)
+}
+
+func TestSimulateIncentive_wrongParameter(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	var tests = []struct {
+		query string
+		body  string
+	}{
+		{"", `{"distance-bands": []}`},
+		{"", `not json`},
+		{"format=parquet", `{"distance-bands": [{"fixed": 1}]}`},
+		{"preset=unknown", `{"distance-bands": [{"fixed": 1}]}`},
+		{"limit=1001", `{"distance-bands": [{"fixed": 1}]}`},
+		{"", `{"distance-bands": [{"fixed": 1}], "daily-cap": 2, "monthly-cap": 3}`},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/incentives/simulate?"+test.query, bytes.NewBufferString(test.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, test.body)
+	}
+	mockJUsecase.AssertNotCalled(t, "SimulateIncentive")
+}
+
+func TestAnomalies(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	start := time.Date(2023, 1, 2, 8, 0, 0, 0, time.UTC)
+	journey := domain.Journey{
+		JourneyId:            1,
+		TripId:               uuid.MustParse("5a280bc3-f42d-4d3b-9554-c6fe5322edb5"),
+		JourneyStartDatetime: start,
+		JourneyStartTown:     "Rennes",
+		JourneyEndTown:       "Nantes",
+		JourneyDistance:      100000,
+		JourneyDuration:      20,
+		Anomalies:            []string{domain.AnomalySpeed},
+		AnomalyScore:         1,
+	}
+	mockJUsecase.On("Anomalies", mock.Anything, &domain.JourneyFilter{Departments: []string{"35"}}, domain.AnomalySpeed, int64(0), int64(10)).
+		Return([]domain.Journey{journey}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/anomalies?anomaly=speed&limit=10&department=35", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := messaging.AnomaliesResponseMessage{}
+	json.NewDecoder(w.Body).Decode(&response)
+	assert.Equal(t, messaging.AnomaliesResponseMessage{
+		Offset: 0,
+		Limit:  10,
+		Journeys: []messaging.AnomalyResponseMessage{{
+			JourneyId:       1,
+			TripId:          "5a280bc3-f42d-4d3b-9554-c6fe5322edb5",
+			StartDatetime:   &start,
+			StartTown:       "Rennes",
+			EndTown:         "Nantes",
+			JourneyDistance: 100000,
+			JourneyDuration: 20,
+			Anomalies:       []string{domain.AnomalySpeed},
+			Score:           1,
+		}},
+	}, response)
+}
+
+func TestAnomalies_wrongParameter(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	for _, query := range []string{"anomaly=teleport", "limit=5000", "offset=-1", "preset=unknown"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/anomalies?"+query, nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	mockJUsecase.AssertNotCalled(t, "Anomalies")
+}
+
+func TestZones(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	polygon := orb.Polygon{{{-2.8, 47.6}, {-2.7, 47.6}, {-2.7, 47.7}, {-2.8, 47.6}}}
+	geometry := `{"type": "Polygon", "coordinates": [[[-2.8, 47.6], [-2.7, 47.6], [-2.7, 47.7], [-2.8, 47.6]]]}`
+	vannes := &domain.Zone{Name: "vannes", Description: "Vannes", Geometry: polygon}
+	mockJUsecase.On("CreateZone", mock.Anything, vannes).Return(nil)
+	mockJUsecase.On("CreateZone", mock.Anything, &domain.Zone{Name: "lorient", Geometry: polygon}).Return(domain.ErrZoneExists)
+	mockJUsecase.On("Zones", mock.Anything).Return([]domain.Zone{*vannes}, nil)
+	mockJUsecase.On("Zone", mock.Anything, "vannes").Return(vannes, nil)
+	mockJUsecase.On("Zone", mock.Anything, "lorient").Return(nil, domain.ErrZoneNotFound)
+	mockJUsecase.On("UpdateZone", mock.Anything, vannes).Return(nil)
+	mockJUsecase.On("UpdateZone", mock.Anything, &domain.Zone{Name: "lorient", Geometry: polygon}).Return(domain.ErrZoneNotFound)
+	mockJUsecase.On("DeleteZone", mock.Anything, "vannes").Return(nil)
+	mockJUsecase.On("DeleteZone", mock.Anything, "lorient").Return(errors.New("database error"))
+
+	type tmplTest struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		statusCode int
+	}
+
+	tests := []tmplTest{
+		{"create", "POST", "/zones", `{"name": "vannes", "description": "Vannes", "geometry": ` + geometry + `}`, http.StatusCreated},
+		{"create_existing", "POST", "/zones", `{"name": "lorient", "geometry": ` + geometry + `}`, http.StatusConflict},
+		{"create_wrong_name", "POST", "/zones", `{"name": "vannes centre", "geometry": ` + geometry + `}`, http.StatusBadRequest},
+		{"create_no_geometry", "POST", "/zones", `{"name": "vannes"}`, http.StatusBadRequest},
+		{"create_wrong_geometry", "POST", "/zones", `{"name": "vannes", "geometry": {"type": "Point", "coordinates": [-2.8, 47.6]}}`, http.StatusBadRequest},
+		{"list", "GET", "/zones", "", http.StatusOK},
+		{"get", "GET", "/zones/vannes", "", http.StatusOK},
+		{"get_unknown", "GET", "/zones/lorient", "", http.StatusNotFound},
+		{"update", "PUT", "/zones/vannes", `{"description": "Vannes", "geometry": ` + geometry + `}`, http.StatusOK},
+		{"update_unknown", "PUT", "/zones/lorient", `{"geometry": ` + geometry + `}`, http.StatusNotFound},
+		{"update_rename", "PUT", "/zones/vannes", `{"name": "lorient", "geometry": ` + geometry + `}`, http.StatusBadRequest},
+		{"delete", "DELETE", "/zones/vannes", "", http.StatusOK},
+		{"delete_failure", "DELETE", "/zones/lorient", "", http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(test.method, test.path, bytes.NewBufferString(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.statusCode, w.Code)
+		})
+	}
+}
+
+func TestZones_response(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	polygon := orb.Polygon{{{-2.8, 47.6}, {-2.7, 47.6}, {-2.7, 47.7}, {-2.8, 47.6}}}
+	mockJUsecase.On("Zones", mock.Anything).Return([]domain.Zone{{Name: "vannes", Description: "Vannes", Geometry: polygon}}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/zones", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response := messaging.ZonesResponseMessage{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Len(t, response.Zones, 1)
+	assert.Equal(t, "vannes", response.Zones[0].Name)
+	assert.Equal(t, "Vannes", response.Zones[0].Description)
+	assert.Equal(t, polygon, response.Zones[0].Geometry.Geometry())
+}
+
+func TestHeatmap(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	request := &domain.HeatmapRequest{
+		Grid:       domain.HeatmapGridHex,
+		Side:       domain.LocationStart,
+		Resolution: 2000,
+		Bound:      orb.Bound{Min: orb.Point{-1.8, 48}, Max: orb.Point{-1.5, 48.2}},
+	}
+	polygon := orb.Polygon{{{-1.7, 48.1}, {-1.6, 48.1}, {-1.6, 48.2}, {-1.7, 48.1}}}
+	mockJUsecase.On("Heatmap", mock.Anything, request, &domain.JourneyFilter{Departments: []string{"35"}}).
+		Return([]domain.HeatmapCell{{Cell: "-45_3060", Polygon: polygon, NbJourneys: 12}}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/heatmap?grid=hex&resolution=2000&department=35&bbox="+url.QueryEscape("[-1.8, 48, -1.5, 48.2]"), nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/geo+json", w.Header().Get("Content-Type"))
+	collection, err := geojson.UnmarshalFeatureCollection(w.Body.Bytes())
+	assert.NoError(t, err)
+	assert.Len(t, collection.Features, 1)
+	assert.Equal(t, polygon, collection.Features[0].Geometry)
+	assert.Equal(t, "-45_3060", collection.Features[0].Properties["cell"])
+	assert.Equal(t, 12.0, collection.Features[0].Properties["count"])
+}
+
+func TestHeatmap_wrongParameter(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+	mockJUsecase.On("Heatmap", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &domain.HeatmapTooLargeError{NbCells: 5000, MaxCells: 100})
+
+	bbox := "&bbox=" + url.QueryEscape("[-1.8, 48, -1.5, 48.2]")
+	for _, query := range []string{
+		"resolution=500",
+		"bbox=" + url.QueryEscape("[-1.8, 48]") + "&resolution=500",
+		"grid=triangle&resolution=500" + bbox,
+		"side=middle&resolution=500" + bbox,
+		"grid=geohash&resolution=20" + bbox,
+		"preset=unknown&resolution=500" + bbox,
+		"resolution=50" + bbox,
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/heatmap?"+query, nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	mockJUsecase.AssertNumberOfCalls(t, "Heatmap", 1)
+}
+
+func TestTile(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	request := &domain.TileRequest{Layer: domain.TileLayerOrigins, Tile: maptile.New(505, 355, 10)}
+	mockJUsecase.On("Tile", mock.Anything, request, &domain.JourneyFilter{Departments: []string{"35"}}).
+		Return([]byte("tile"), nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tiles/origins/10/505/355.mvt?department=35", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/vnd.mapbox-vector-tile", w.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "tile", w.Body.String())
+
+	mockJUsecase.On("Tile", mock.Anything, request, &domain.JourneyFilter{Departments: []string{"22"}}).
+		Return(nil, &domain.TileTooLargeError{MaxJourneys: 3})
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/tiles/origins/10/505/355.mvt?department=22", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTile_wrongParameter(t *testing.T) {
+	r := gin.Default()
+	mockJUsecase := new(mocks.JourneyUsecase)
+	router.NewJourneyRouter(&logger, config, r, mockJUsecase)
+
+	for _, path := range []string{
+		"/tiles/trips/10/505/355.mvt",
+		"/tiles/origins/10/505/355.png",
+		"/tiles/origins/10/505/-1.mvt",
+		"/tiles/origins/1/5/0.mvt",
+		"/tiles/od/30/0/0.mvt",
+		"/tiles/od/10/505/355.mvt?preset=unknown",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+	}
+	mockJUsecase.AssertNotCalled(t, "Tile")
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.uber.org/zap"
+)
+
+func init() {
+	RegisterJourneyProcessor("anomalies", NewAnomalyDetector)
+}
+
+type anomalyDetector struct {
+	thresholds domain.AnomalyThresholds
+}
+
+// NewAnomalyDetector creates a processing step scoring the anomalies which can be detected on each journey alone:
+// impossible speeds and distances far from the straight-line distance. The anomalies between journeys, such as trips
+// at two places at once or bursts of identical journeys, are detected after the import by the detect-anomalies command.
+//
+// @param logger - Logger to use. Must not be nil.
+// @param cfg - Configuration giving the thresholds of the detection
+func NewAnomalyDetector(logger *zap.SugaredLogger, cfg *configuration.Config) (domain.JourneyProcessor, error) {
+	logger.Infow("Anomaly detector created",
+		"thresholds", cfg.Journey.Anomalies,
+	)
+	return &anomalyDetector{
+		thresholds: cfg.Journey.Anomalies,
+	}, nil
+}
+
+// Process sets the anomalies and the score of the journey. Journeys with anomalies are kept
+//
+// @param journey - Journey to check
+func (d *anomalyDetector) Process(journey *domain.Journey) (bool, error) {
+	journey.Anomalies = domain.DetectJourneyAnomalies(journey, &d.thresholds)
+	journey.AnomalyScore = domain.AnomalyScore(journey.Anomalies)
+	return true, nil
+}
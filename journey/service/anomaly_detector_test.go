@@ -0,0 +1,23 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnomalyDetector(t *testing.T) {
+	detector, err := service.NewAnomalyDetector(&logger, config)
+	assert.NoError(t, err)
+
+	journey := &domain.Journey{JourneyDistance: 100000, JourneyDuration: 20}
+	keep, err := detector.Process(journey)
+
+	assert.NoError(t, err)
+	assert.True(t, keep)
+	assert.Equal(t, []string{domain.AnomalySpeed}, journey.Anomalies)
+	assert.Equal(t, 1.0, journey.AnomalyScore)
+}
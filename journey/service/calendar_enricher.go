@@ -0,0 +1,177 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	RegisterJourneyProcessor("calendar", NewCalendarEnricher)
+}
+
+// schoolCalendarFile is the layout of a school calendar file
+type schoolCalendarFile struct {
+	// Departments of each zone
+	Zones map[string][]string `yaml:"zones"`
+	// Periods of school holidays, with their first and last days, both included
+	Holidays []struct {
+		Name  string   `yaml:"name"`
+		Zones []string `yaml:"zones"`
+		From  string   `yaml:"from"`
+		To    string   `yaml:"to"`
+	} `yaml:"holidays"`
+}
+
+type schoolHolidays struct {
+	from time.Time
+	to   time.Time
+}
+
+type schoolCalendar struct {
+	zones    map[string]string
+	holidays map[string][]schoolHolidays
+}
+
+// LoadSchoolCalendar loads a school calendar file. See ReadSchoolCalendar for its layout.
+//
+// @param logger - Logger to use. Must not be nil.
+// @param path - Path to the YAML file
+func LoadSchoolCalendar(logger *zap.SugaredLogger, path string) (domain.SchoolCalendar, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	calendar, err := ReadSchoolCalendar(file)
+	if err != nil {
+		return nil, fmt.Errorf("problem while loading school calendar %s: %w", path, err)
+	}
+
+	logger.Infow("School calendar loaded",
+		"file", path,
+		"nbDepartments", len(calendar.(*schoolCalendar).zones),
+	)
+	return calendar, nil
+}
+
+// ReadSchoolCalendar reads a school calendar file.
+//
+// The YAML file lists the departments of each zone under 'zones', and the periods of holidays under 'holidays',
+// each with its name, its zones and its first and last days ('from' and 'to', YYYY-MM-DD, both included).
+//
+// @param reader - Reader of the YAML file
+func ReadSchoolCalendar(reader io.Reader) (domain.SchoolCalendar, error) {
+	content := schoolCalendarFile{}
+	if err := yaml.NewDecoder(reader).Decode(&content); err != nil {
+		return nil, err
+	}
+
+	calendar := &schoolCalendar{
+		zones:    map[string]string{},
+		holidays: map[string][]schoolHolidays{},
+	}
+	for zone, departments := range content.Zones {
+		for _, department := range departments {
+			if other, ok := calendar.zones[department]; ok {
+				return nil, fmt.Errorf("department %s is in zones %s and %s", department, other, zone)
+			}
+			calendar.zones[department] = zone
+		}
+	}
+
+	for _, holidays := range content.Holidays {
+		from, err := time.Parse(time.DateOnly, holidays.From)
+		if err != nil {
+			return nil, fmt.Errorf("holidays '%s': %w", holidays.Name, err)
+		}
+		to, err := time.Parse(time.DateOnly, holidays.To)
+		if err != nil {
+			return nil, fmt.Errorf("holidays '%s': %w", holidays.Name, err)
+		}
+		if to.Before(from) {
+			return nil, fmt.Errorf("holidays '%s' end before they begin", holidays.Name)
+		}
+		for _, zone := range holidays.Zones {
+			if _, ok := content.Zones[zone]; !ok {
+				return nil, fmt.Errorf("holidays '%s': unknown zone '%s'", holidays.Name, zone)
+			}
+			calendar.holidays[zone] = append(calendar.holidays[zone], schoolHolidays{from: from, to: to})
+		}
+	}
+	return calendar, nil
+}
+
+// Zone returns the school zone of a department, empty when the department has no zone
+//
+// @param department - Code of the department
+func (c *schoolCalendar) Zone(department string) string {
+	return c.zones[department]
+}
+
+// IsHoliday tells whether a day is in the school holidays of a zone
+//
+// @param zone - School zone
+// @param day - Day to check, only its date is read
+func (c *schoolCalendar) IsHoliday(zone string, day time.Time) bool {
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	for _, holidays := range c.holidays[zone] {
+		if !date.Before(holidays.from) && !date.After(holidays.to) {
+			return true
+		}
+	}
+	return false
+}
+
+type calendarEnricher struct {
+	location *time.Location
+	calendar domain.SchoolCalendar
+}
+
+// NewCalendarEnricher creates a processing step completing each journey with the calendar of its start day:
+// the day of the week, the ISO week, whether it is a French public holiday, the school zone of the start department
+// and whether the day is in its school holidays. The school zones are skipped when no school calendar file is configured.
+//
+// @param logger - Logger to use. Must not be nil.
+// @param cfg - Configuration giving the timezone of the days and the school calendar file
+func NewCalendarEnricher(logger *zap.SugaredLogger, cfg *configuration.Config) (domain.JourneyProcessor, error) {
+	location, err := time.LoadLocation(cfg.Journey.Stats.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	enricher := &calendarEnricher{location: location}
+	if path := cfg.Journey.Referential.SchoolCalendar.File; path != "" {
+		if enricher.calendar, err = LoadSchoolCalendar(logger, path); err != nil {
+			return nil, err
+		}
+	}
+	return enricher, nil
+}
+
+// Process sets the calendar of the start day of the journey. Journeys without start day are kept as they are
+//
+// @param journey - Journey to enrich
+func (e *calendarEnricher) Process(journey *domain.Journey) (bool, error) {
+	day, err := time.Parse(time.DateOnly, domain.JourneyDay(journey, e.location))
+	if err != nil {
+		return true, nil
+	}
+
+	journey.JourneyStartWeekday = domain.IsoWeekday(day)
+	journey.JourneyStartIsoWeek = domain.IsoWeek(day)
+	_, journey.IsPublicHoliday = domain.FrenchPublicHoliday(day, journey.JourneyStartDepartment)
+	if e.calendar != nil {
+		journey.SchoolZone = e.calendar.Zone(journey.JourneyStartDepartment)
+		journey.IsSchoolHoliday = journey.SchoolZone != "" && e.calendar.IsHoliday(journey.SchoolZone, day)
+	}
+	return true, nil
+}
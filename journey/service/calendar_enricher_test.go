@@ -0,0 +1,112 @@
+package service_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSchoolCalendar(t *testing.T) {
+	calendar, err := service.LoadSchoolCalendar(&logger, filepath.Join("testdata", "school-calendar.yaml"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "B", calendar.Zone("35"))
+	assert.Equal(t, "C", calendar.Zone("75"))
+	assert.Equal(t, "", calendar.Zone("2A"))
+	assert.True(t, calendar.IsHoliday("A", time.Date(2023, 2, 4, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, calendar.IsHoliday("A", time.Date(2023, 2, 19, 23, 0, 0, 0, time.UTC)))
+	assert.False(t, calendar.IsHoliday("A", time.Date(2023, 2, 20, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, calendar.IsHoliday("C", time.Date(2023, 2, 4, 0, 0, 0, 0, time.UTC)))
+
+	_, err = service.LoadSchoolCalendar(&logger, filepath.Join("testdata", "unknown.yaml"))
+	assert.Error(t, err)
+}
+
+func TestReadSchoolCalendar_invalid(t *testing.T) {
+	tests := []struct {
+		content string
+		err     string
+	}{
+		{"zones: {A: ['35'], B: ['35']}", "department 35 is in zones"},
+		{"zones: {A: ['35']}\nholidays: [{name: Hiver, zones: [D], from: '2023-02-04', to: '2023-02-19'}]", "holidays 'Hiver': unknown zone 'D'"},
+		{"zones: {A: ['35']}\nholidays: [{name: Hiver, zones: [A], from: '2023-02-19', to: '2023-02-04'}]", "holidays 'Hiver' end before they begin"},
+		{"zones: {A: ['35']}\nholidays: [{name: Hiver, zones: [A], from: '04/02/2023', to: '2023-02-19'}]", "holidays 'Hiver': parsing time"},
+	}
+
+	for _, test := range tests {
+		_, err := service.ReadSchoolCalendar(strings.NewReader(test.content))
+		assert.ErrorContains(t, err, test.err)
+	}
+}
+
+func TestCalendarEnricher(t *testing.T) {
+	cfg := *config
+	cfg.Journey.Stats.Timezone = "Europe/Paris"
+	cfg.Journey.Referential.SchoolCalendar.File = filepath.Join("testdata", "school-calendar.yaml")
+	enricher, err := service.NewCalendarEnricher(&logger, &cfg)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		journey  domain.Journey
+		expected domain.Journey
+	}{
+		{
+			"school_holidays",
+			// Saturday 2023-02-11 in Paris
+			domain.Journey{JourneyStartDatetime: time.Date(2023, 2, 10, 23, 30, 0, 0, time.UTC), JourneyStartDepartment: "35"},
+			domain.Journey{JourneyStartDatetime: time.Date(2023, 2, 10, 23, 30, 0, 0, time.UTC), JourneyStartDepartment: "35",
+				JourneyStartWeekday: 6, JourneyStartIsoWeek: "2023-W06", SchoolZone: "B", IsSchoolHoliday: true},
+		},
+		{
+			"public_holiday_from_the_start_date",
+			domain.Journey{JourneyStartDate: time.Date(2023, 5, 18, 0, 0, 0, 0, time.UTC), JourneyStartDepartment: "75"},
+			domain.Journey{JourneyStartDate: time.Date(2023, 5, 18, 0, 0, 0, 0, time.UTC), JourneyStartDepartment: "75",
+				JourneyStartWeekday: 4, JourneyStartIsoWeek: "2023-W20", IsPublicHoliday: true, SchoolZone: "C"},
+		},
+		{
+			"unknown_zone",
+			domain.Journey{JourneyStartDate: time.Date(2023, 2, 13, 0, 0, 0, 0, time.UTC), JourneyStartDepartment: "2A"},
+			domain.Journey{JourneyStartDate: time.Date(2023, 2, 13, 0, 0, 0, 0, time.UTC), JourneyStartDepartment: "2A",
+				JourneyStartWeekday: 1, JourneyStartIsoWeek: "2023-W07"},
+		},
+		{
+			"unknown_day",
+			domain.Journey{JourneyStartDepartment: "35"},
+			domain.Journey{JourneyStartDepartment: "35"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			journey := test.journey
+			keep, err := enricher.Process(&journey)
+
+			assert.NoError(t, err)
+			assert.True(t, keep)
+			assert.Equal(t, test.expected, journey)
+		})
+	}
+}
+
+func TestCalendarEnricher_withoutSchoolCalendar(t *testing.T) {
+	cfg := *config
+	cfg.Journey.Stats.Timezone = "UTC"
+	cfg.Journey.Referential.SchoolCalendar.File = ""
+	enricher, err := service.NewCalendarEnricher(&logger, &cfg)
+	assert.NoError(t, err)
+
+	journey := domain.Journey{JourneyStartDate: time.Date(2023, 2, 13, 0, 0, 0, 0, time.UTC), JourneyStartDepartment: "35"}
+	_, err = enricher.Process(&journey)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), journey.JourneyStartWeekday)
+	assert.Equal(t, "", journey.SchoolZone)
+	assert.False(t, journey.IsSchoolHoliday)
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.uber.org/zap"
+)
+
+func init() {
+	RegisterJourneyProcessor("co2", NewCo2Estimator)
+}
+
+type co2Estimator struct {
+	version string
+	factors domain.EmissionFactors
+}
+
+// NewCo2Estimator creates a processing step estimating the vehicle-km avoided and the kg of CO2 saved by each journey,
+// from its distance and its passenger seats. The journeys keep the version of the emission factors used.
+//
+// @param logger - Logger to use. Must not be nil.
+// @param cfg - Configuration giving the emission factors by version and the version to apply
+func NewCo2Estimator(logger *zap.SugaredLogger, cfg *configuration.Config) (domain.JourneyProcessor, error) {
+	version := cfg.Journey.Co2.Version
+	factors, ok := cfg.Journey.Co2.Factors[version]
+	if !ok {
+		return nil, fmt.Errorf("unknown emission factors version '%s'", version)
+	}
+
+	logger.Infow("CO2 estimator created",
+		"version", version,
+		"carEmission", factors.CarEmission,
+		"modalShift", factors.ModalShift,
+	)
+	return &co2Estimator{
+		version: version,
+		factors: factors,
+	}, nil
+}
+
+// Process sets the estimates of the journey. Journeys without distance avoid nothing
+//
+// @param journey - Journey to enrich
+func (e *co2Estimator) Process(journey *domain.Journey) (bool, error) {
+	passengerKm := domain.JourneyPassengerKm(journey)
+	journey.VehicleKmAvoided = e.factors.VehicleKmAvoided(passengerKm)
+	journey.Co2Saved = e.factors.Co2Saved(passengerKm)
+	journey.EmissionFactorsVersion = e.version
+	return true, nil
+}
@@ -0,0 +1,33 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCo2Estimator(t *testing.T) {
+	estimator, err := service.NewCo2Estimator(&logger, config)
+	assert.NoError(t, err)
+
+	journey := &domain.Journey{JourneyDistance: 10000, PassengerSeats: 2}
+	keep, err := estimator.Process(journey)
+
+	assert.NoError(t, err)
+	assert.True(t, keep)
+	assert.Equal(t, 10.0, journey.VehicleKmAvoided)
+	assert.Equal(t, 1.0, journey.Co2Saved)
+	assert.Equal(t, "2023", journey.EmissionFactorsVersion)
+}
+
+func TestCo2Estimator_unknownVersion(t *testing.T) {
+	cfg := *config
+	cfg.Journey.Co2.Version = "1990"
+
+	_, err := service.NewCo2Estimator(&logger, &cfg)
+
+	assert.EqualError(t, err, "unknown emission factors version '1990'")
+}
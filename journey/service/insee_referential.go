@@ -0,0 +1,263 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.uber.org/zap"
+)
+
+// Modes of the INSEE enricher when the department or the town of a journey disagrees with the referential
+const (
+	InseeModeFlag = "flag"
+	InseeModeFix  = "fix"
+)
+
+func init() {
+	RegisterJourneyProcessor("insee", func(logger *zap.SugaredLogger, cfg *configuration.Config) (domain.JourneyProcessor, error) {
+		referential, err := LoadInseeReferential(logger, cfg.Journey.Referential.Insee.File)
+		if err != nil {
+			return nil, err
+		}
+		return NewInseeEnricher(logger, cfg, referential)
+	})
+}
+
+type inseeReferential struct {
+	communes map[int64]*domain.Commune
+}
+
+// LoadInseeReferential loads the INSEE COG commune file.
+//
+// The file is read using its header: COM is required, TYPECOM, DEP, REG, LIBELLE and COMPARENT
+// come from the official file, EPCI and PMUN (or POPULATION) are optional columns which can be merged into it.
+// Municipal districts inherit the department, the region and the EPCI of their parent commune.
+//
+// @param logger - Logger to use. Must not be nil.
+// @param path - Path to the CSV file, separated by ',' or ';'
+func LoadInseeReferential(logger *zap.SugaredLogger, path string) (domain.CommuneReferential, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	referential, err := ReadInseeReferential(file)
+	if err != nil {
+		return nil, fmt.Errorf("problem while loading INSEE referential %s: %w", path, err)
+	}
+
+	logger.Infow("INSEE referential loaded",
+		"file", path,
+		"nbCommunes", len(referential.(*inseeReferential).communes),
+	)
+	return referential, nil
+}
+
+// ReadInseeReferential reads an INSEE COG commune file. See LoadInseeReferential for the expected columns.
+//
+// @param reader - Reader of the CSV file
+func ReadInseeReferential(reader io.Reader) (domain.CommuneReferential, error) {
+	bufReader := bufio.NewReader(reader)
+	head, _ := bufReader.Peek(4096)
+	firstLine, _, _ := strings.Cut(string(head), "\n")
+
+	csvReader := csv.NewReader(bufReader)
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		csvReader.Comma = ';'
+	}
+
+	headers, err := csvReader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, header := range headers {
+		columns[strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(header, "\ufeff")))] = i
+	}
+	if _, ok := columns["COM"]; !ok {
+		return nil, fmt.Errorf("column COM is missing")
+	}
+	if _, ok := columns["PMUN"]; !ok {
+		if i, ok := columns["POPULATION"]; ok {
+			columns["PMUN"] = i
+		}
+	}
+
+	column := func(line []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(line) {
+			return strings.TrimSpace(line[i])
+		}
+		return ""
+	}
+
+	referential := &inseeReferential{
+		communes: map[int64]*domain.Commune{},
+	}
+	types := map[int64]string{}
+	parents := map[int64]int64{}
+	for {
+		line, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		code, err := strconv.ParseInt(column(line, "COM"), 10, 64)
+		if err != nil {
+			// Corsican codes (2A, 2B) can't be referenced by a numerical INSEE code
+			continue
+		}
+
+		// Associated and delegated communes share their code with a real commune, which prevails
+		typeCom := column(line, "TYPECOM")
+		if previousType, ok := types[code]; ok && (previousType == "COM" || typeCom != "COM") {
+			continue
+		}
+
+		population, _ := strconv.ParseInt(column(line, "PMUN"), 10, 64)
+		referential.communes[code] = &domain.Commune{
+			Insee:      column(line, "COM"),
+			Name:       column(line, "LIBELLE"),
+			Department: column(line, "DEP"),
+			Region:     column(line, "REG"),
+			Epci:       column(line, "EPCI"),
+			Population: population,
+		}
+		types[code] = typeCom
+		if parent, err := strconv.ParseInt(column(line, "COMPARENT"), 10, 64); err == nil && typeCom == "ARM" {
+			parents[code] = parent
+		}
+	}
+
+	for code, parentCode := range parents {
+		commune := referential.communes[code]
+		if parent, ok := referential.communes[parentCode]; ok {
+			if commune.Department == "" {
+				commune.Department = parent.Department
+			}
+			if commune.Region == "" {
+				commune.Region = parent.Region
+			}
+			if commune.Epci == "" {
+				commune.Epci = parent.Epci
+			}
+		}
+	}
+
+	return referential, nil
+}
+
+// Get returns the commune with the given INSEE code
+//
+// @param insee - INSEE code of the commune
+func (r *inseeReferential) Get(insee int64) (*domain.Commune, bool) {
+	commune, ok := r.communes[insee]
+	return commune, ok
+}
+
+type inseeEnricher struct {
+	logger      *zap.SugaredLogger
+	referential domain.CommuneReferential
+	fix         bool
+}
+
+// NewInseeEnricher creates a processing step checking journeys against the INSEE referential.
+//
+// Unknown INSEE codes and mismatching departments, towns or postal codes are flagged on the journey.
+// In "fix" mode, the department and the town are replaced by the ones of the referential.
+// Known communes complete the journey with their region, EPCI and population.
+//
+// @param logger - Logger to use. Must not be nil.
+// @param cfg - Configuration, giving the mode of the enricher: InseeModeFlag or InseeModeFix
+// @param referential - Referential of the communes. Must not be nil.
+func NewInseeEnricher(logger *zap.SugaredLogger, cfg *configuration.Config, referential domain.CommuneReferential) (domain.JourneyProcessor, error) {
+	mode := cfg.Journey.Referential.Insee.Mode
+	if mode != InseeModeFlag && mode != InseeModeFix {
+		return nil, fmt.Errorf("invalid journey.referential.insee.mode '%s' (available: %s, %s)", mode, InseeModeFlag, InseeModeFix)
+	}
+
+	return &inseeEnricher{
+		logger:      logger,
+		referential: referential,
+		fix:         mode == InseeModeFix,
+	}, nil
+}
+
+// Process validates and completes the start and the end of a journey
+//
+// @param journey - Journey to enrich
+func (e *inseeEnricher) Process(journey *domain.Journey) (bool, error) {
+	for _, place := range journeyPlaces(journey) {
+		e.enrichPlace(journey, place)
+	}
+	return true, nil
+}
+
+func (e *inseeEnricher) enrichPlace(journey *domain.Journey, place journeyPlace) {
+	if *place.insee == 0 {
+		return
+	}
+
+	commune, ok := e.referential.Get(*place.insee)
+	if !ok {
+		place.flag(journey, "insee_unknown")
+		return
+	}
+
+	if *place.department != commune.Department {
+		if e.fix {
+			*place.department = commune.Department
+			place.flag(journey, "department_fixed")
+		} else {
+			place.flag(journey, "department_mismatch")
+		}
+	}
+
+	if !sameTown(*place.town, commune.Name) {
+		if e.fix {
+			*place.town = fmt.Sprintf("%s (%s)", commune.Name, commune.Department)
+			place.flag(journey, "town_fixed")
+		} else {
+			place.flag(journey, "town_mismatch")
+		}
+	}
+
+	if *place.postalcode != "" && !postalcodeInDepartment(*place.postalcode, commune.Department) {
+		place.flag(journey, "postalcode_mismatch")
+	}
+
+	*place.region = commune.Region
+	*place.epci = commune.Epci
+	*place.population = commune.Population
+}
+
+// sameTown compares a town of the open data files, suffixed by its department ("Igny (91)"), with a commune name
+func sameTown(town string, name string) bool {
+	if i := strings.LastIndex(town, " ("); i > 0 && strings.HasSuffix(town, ")") {
+		town = town[:i]
+	}
+	return strings.EqualFold(strings.TrimSpace(town), name)
+}
+
+// postalcodeInDepartment checks the postal code starts with the department code
+func postalcodeInDepartment(postalcode string, department string) bool {
+	switch {
+	case department == "2A" || department == "2B":
+		return strings.HasPrefix(postalcode, "20")
+	case department == "":
+		return true
+	default:
+		return strings.HasPrefix(postalcode, department)
+	}
+}
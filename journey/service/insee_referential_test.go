@@ -0,0 +1,131 @@
+// Package service_test tests the application services
+package service_test
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/service"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+var logger zap.SugaredLogger
+var config *configuration.Config
+
+func init() {
+	newLogger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Println("Error initializing logger!")
+	}
+
+	logger = *newLogger.Sugar()
+
+	config, err = configuration.NewConfig("../../resource/configurations/application-tu.yaml")
+	if err != nil {
+		logger.Error(err)
+	}
+}
+
+func TestLoadInseeReferential(t *testing.T) {
+	referential, err := service.LoadInseeReferential(&logger, filepath.Join("testdata", "communes.csv"))
+	assert.NoError(t, err)
+
+	mantes, ok := referential.Get(78361)
+	assert.True(t, ok)
+	assert.Equal(t, "Mantes-la-Jolie", mantes.Name)
+	assert.Equal(t, "78", mantes.Department)
+	assert.Equal(t, "11", mantes.Region)
+	assert.Equal(t, "200059889", mantes.Epci)
+	assert.Equal(t, int64(45124), mantes.Population)
+
+	paris14, ok := referential.Get(75114)
+	assert.True(t, ok)
+	assert.Equal(t, "75", paris14.Department)
+	assert.Equal(t, "11", paris14.Region)
+	assert.Equal(t, "200054781", paris14.Epci)
+
+	_, ok = referential.Get(99999)
+	assert.False(t, ok)
+
+	_, err = service.LoadInseeReferential(&logger, filepath.Join("testdata", "unknown.csv"))
+	assert.Error(t, err)
+}
+
+func TestInseeEnricher(t *testing.T) {
+	f, _ := os.Open(filepath.Join("testdata", "communes.csv"))
+	defer f.Close()
+	referential, err := service.ReadInseeReferential(f)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		mode          string
+		journey       domain.Journey
+		expectedFlags []string
+		expectedTown  string
+	}{
+		{
+			"consistent_journey",
+			service.InseeModeFlag,
+			domain.Journey{
+				JourneyStartInsee: 78361, JourneyStartPostalcode: "78200", JourneyStartDepartment: "78", JourneyStartTown: "Mantes-la-Jolie (78)",
+				JourneyEndInsee: 91312, JourneyEndPostalcode: "91430", JourneyEndDepartment: "91", JourneyEndTown: "Igny (91)",
+			},
+			nil,
+			"Mantes-la-Jolie (78)",
+		},
+		{
+			"flag_mismatch",
+			service.InseeModeFlag,
+			domain.Journey{
+				JourneyStartInsee: 78361, JourneyStartPostalcode: "92190", JourneyStartDepartment: "92", JourneyStartTown: "Meudon (92)",
+				JourneyEndInsee: 12345, JourneyEndDepartment: "91", JourneyEndTown: "Igny (91)",
+			},
+			[]string{"start_department_mismatch", "start_town_mismatch", "start_postalcode_mismatch", "end_insee_unknown"},
+			"Meudon (92)",
+		},
+		{
+			"fix_mismatch",
+			service.InseeModeFix,
+			domain.Journey{
+				JourneyStartInsee: 78361, JourneyStartDepartment: "92", JourneyStartTown: "Meudon (92)",
+			},
+			[]string{"start_department_fixed", "start_town_fixed"},
+			"Mantes-la-Jolie (78)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := *config
+			cfg.Journey.Referential.Insee.Mode = test.mode
+			enricher, err := service.NewInseeEnricher(&logger, &cfg, referential)
+			assert.NoError(t, err)
+
+			journey := test.journey
+			keep, err := enricher.Process(&journey)
+			assert.NoError(t, err)
+			assert.True(t, keep)
+			assert.Equal(t, test.expectedFlags, journey.Flags)
+			assert.Equal(t, test.expectedTown, journey.JourneyStartTown)
+			assert.Equal(t, "11", journey.JourneyStartRegion)
+		})
+	}
+}
+
+func TestNewInseeEnricher_unknownMode(t *testing.T) {
+	for _, mode := range []string{"", "Fix", "correct"} {
+		cfg := *config
+		cfg.Journey.Referential.Insee.Mode = mode
+
+		enricher, err := service.NewInseeEnricher(&logger, &cfg, nil)
+		assert.Nil(t, enricher, "mode '%s'", mode)
+		assert.ErrorContains(t, err, "journey.referential.insee.mode", "mode '%s'", mode)
+	}
+}
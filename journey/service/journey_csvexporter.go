@@ -0,0 +1,99 @@
+package service
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.uber.org/zap"
+)
+
+// Schemas of the official files written by the CSV exports
+const (
+	journeySchema27 = "journey-27"
+	journeySchema29 = "journey-29"
+)
+
+func init() {
+	RegisterJourneyExporter(domain.JourneyFormatCSV, NewJourneyCsvExporterFactory(journeySchema29))
+	RegisterJourneyExporter(domain.JourneyFormatCSV27, NewJourneyCsvExporterFactory(journeySchema27))
+	RegisterJourneyExporter(domain.JourneyFormatCSV29, NewJourneyCsvExporterFactory(journeySchema29))
+}
+
+type journeyCsvExporter struct {
+	logger     *zap.SugaredLogger
+	schemaName string
+	schema     *JourneySchema
+}
+
+// NewJourneyCsvExporterFactory returns the factory of an exporter writing CSV files in the layout of a schema:
+// its headers, its separator, its datetime layouts and its boolean tokens. The exported files can be imported again.
+//
+// @param schemaName - Name of the schema of the files
+func NewJourneyCsvExporterFactory(schemaName string) JourneyExporterFactory {
+	return func(logger *zap.SugaredLogger, cfg *configuration.Config, schemas []*JourneySchema) domain.JourneyExporter {
+		exporter := &journeyCsvExporter{
+			logger:     logger,
+			schemaName: schemaName,
+		}
+		for _, schema := range schemas {
+			if schema.Name == schemaName {
+				exporter.schema = schema
+			}
+		}
+		return exporter
+	}
+}
+
+// ContentType returns the MIME type of CSV files
+func (e *journeyCsvExporter) ContentType() string {
+	return "text/csv"
+}
+
+// Extension returns the extension of CSV files
+func (e *journeyCsvExporter) Extension() string {
+	return ".csv"
+}
+
+// Export writes the headers of the schema, then a line per journey as soon as it is received
+//
+// @param writer - Destination of the file
+// @param journeyChan - Channel of the journeys to export
+func (e *journeyCsvExporter) Export(writer io.Writer, journeyChan <-chan *domain.Journey) (int64, error) {
+	if e.schema == nil {
+		return 0, fmt.Errorf("unknown schema %s", e.schemaName)
+	}
+
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Comma = e.schema.SeparatorRune()
+
+	record := make([]string, len(e.schema.Columns))
+	for i, column := range e.schema.Columns {
+		record[i] = column.Name
+	}
+	if err := csvWriter.Write(record); err != nil {
+		return 0, err
+	}
+
+	var nbExported int64
+	for journey := range journeyChan {
+		for i, column := range e.schema.Columns {
+			record[i] = column.Format(journey)
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return nbExported, err
+		}
+		nbExported++
+	}
+
+	csvWriter.Flush()
+	e.logger.Debugw("Journeys exported",
+		"format", domain.JourneyFormatCSV,
+		"schema", e.schema.Name,
+		"nbJourneys", nbExported,
+	)
+	return nbExported, csvWriter.Error()
+}
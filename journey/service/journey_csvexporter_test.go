@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportCsv(t *testing.T) {
+	schemas, err := service.LoadJourneySchemas(&logger, config)
+	assert.NoError(t, err)
+	registry := service.NewJourneyExporterRegistry(&logger, config, schemas)
+	parser := service.NewJourneyCsvParser(&logger, config, schemas)
+
+	var tests = []struct {
+		format   string
+		filename string
+	}{
+		{domain.JourneyFormatCSV, "dataset_1.csv"},
+		{domain.JourneyFormatCSV29, "dataset_1.csv"},
+		{domain.JourneyFormatCSV27, "dataset_27fields.csv"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			content, err := os.ReadFile(filepath.Join("..", "usecase", "testdata", test.filename))
+			assert.NoError(t, err)
+			journeys, errors := parseAll(parser, bytes.NewReader(content))
+			assert.Empty(t, errors)
+
+			exporter, err := registry.Get(test.format)
+			assert.NoError(t, err)
+			reader := exportAll(t, exporter, journeys)
+
+			// Same headers as the imported file
+			exported := bufio.NewScanner(reader)
+			exported.Scan()
+			original := bufio.NewScanner(bytes.NewReader(content))
+			original.Scan()
+			assert.Equal(t, original.Text(), exported.Text())
+
+			reader.Seek(0, 0)
+			imported, errors := parseAll(parser, reader)
+			assert.Empty(t, errors)
+			assert.Equal(t, journeys, imported)
+		})
+	}
+}
+
+func TestExportCsv_booleans(t *testing.T) {
+	schemas, err := service.LoadJourneySchemas(&logger, config)
+	assert.NoError(t, err)
+	exporter, err := service.NewJourneyExporterRegistry(&logger, config, schemas).Get(domain.JourneyFormatCSV27)
+	assert.NoError(t, err)
+
+	reader := exportAll(t, exporter, []*domain.Journey{
+		{JourneyId: 1, OperatorClass: "A", HasIncentive: true},
+		{JourneyId: 2, OperatorClass: "B"},
+	})
+
+	lines := bufio.NewScanner(reader)
+	lines.Scan()
+	lines.Scan()
+	assert.Equal(t, "1;00000000-0000-0000-0000-000000000000;;;;0;0;0;;;;;;;;0;0;0;;;;;0;A;0;0;OUI", lines.Text())
+	lines.Scan()
+	assert.Equal(t, "2;00000000-0000-0000-0000-000000000000;;;;0;0;0;;;;;;;;0;0;0;;;;;0;B;0;0;NON", lines.Text())
+}
@@ -2,34 +2,45 @@
 package service
 
 import (
+	"bufio"
 	"encoding/csv"
 	"fmt"
 	"io"
-	"strconv"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/coutcout/covoiturage-csvreader/configuration"
 	"github.com/coutcout/covoiturage-csvreader/domain"
 
-	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+func init() {
+	RegisterJourneyParser(JourneyParserFormat{
+		Name:         domain.JourneyFormatCSV,
+		Extensions:   []string{".csv"},
+		ContentTypes: []string{"text/csv", "application/csv"},
+		Sniff:        sniffCsv,
+		Factory:      NewJourneyCsvParser,
+	})
+}
+
 type journeyCsvParser struct {
-	logger *zap.SugaredLogger
-	cfg    *configuration.Config
+	logger  *zap.SugaredLogger
+	cfg     *configuration.Config
+	schemas []*JourneySchema
 }
 
 // NewJourneyCsvParser returns a parser for CSV files.
 //
 // @param logger - the logger to use for logging errors. Must not be nil.
 // @param cfg - the configuration. Config to use for parsing the file
-func NewJourneyCsvParser(logger *zap.SugaredLogger, cfg *configuration.Config) domain.JourneyParser {
+// @param schemas - the known layouts of the files, see LoadJourneySchemas
+func NewJourneyCsvParser(logger *zap.SugaredLogger, cfg *configuration.Config, schemas []*JourneySchema) domain.JourneyParser {
 	return &journeyCsvParser{
 		logger,
 		cfg,
+		schemas,
 	}
 }
 
@@ -45,14 +56,18 @@ type job struct {
 // @param journeyChan - Channel which will be used to send imported journeys
 // @param errorChan - Channel which will be used to send errors
 func (p *journeyCsvParser) Parse(reader io.Reader, journeyChan chan<- *domain.Journey, errorChan chan<- string) {
-	csvReader := csv.NewReader(reader)
-	csvReader.Comma = ';'
+	go p.parse(reader, journeyChan, errorChan)
+}
+
+func (p *journeyCsvParser) parse(reader io.Reader, journeyChan chan<- *domain.Journey, errorChan chan<- string) {
+	bufReader := bufio.NewReader(reader)
 
 	p.logger.Debug("Reading headers")
-	if _, err := csvReader.Read(); err != nil {
+	headers, err := bufReader.ReadString('\n')
+	if err != nil && (err != io.EOF || headers == "") {
 
 		close(journeyChan)
-		if err.Error() == "EOF" {
+		if err == io.EOF {
 			p.logger.Info("End of the file")
 			close(errorChan)
 			return
@@ -66,6 +81,24 @@ func (p *journeyCsvParser) Parse(reader io.Reader, journeyChan chan<- *domain.Jo
 		return
 	}
 
+	schema, columns := SelectJourneySchema(p.schemas, headers)
+	if schema == nil {
+		close(journeyChan)
+		p.logger.Errorw("Unknown file layout",
+			"headers", headers,
+		)
+		errorChan <- "the headers of the file are uncompatible with all the known CSV schemas"
+		close(errorChan)
+		return
+	}
+	p.logger.Debugw("Schema selected",
+		"schema", schema.Name,
+	)
+
+	csvReader := csv.NewReader(bufReader)
+	csvReader.Comma = schema.SeparatorRune()
+	csvReader.FieldsPerRecord = -1
+
 	numWorkers := p.cfg.Journey.Parser.WorkerPoolSize
 	jobs := make(chan *job, numWorkers)
 
@@ -85,16 +118,7 @@ func (p *journeyCsvParser) Parse(reader io.Reader, journeyChan chan<- *domain.Jo
 					"csvLine", job,
 				)
 
-				var res *domain.Journey
-				var err error
-				if len(job.line) == 27 {
-					res, err = p.parseJourney27Fields(job.line, job.lineNumber)
-				} else if len(job.line) == 29 {
-					res, err = p.parseJourney29Fields(job.line, job.lineNumber)
-				} else {
-					err = fmt.Errorf("the number of fields (%d) is uncompatible with all the known CSV parsers", len(job.line))
-				}
-
+				res, err := p.parseJourney(schema, columns, job.line, job.lineNumber)
 				if err != nil {
 					errorChan <- err.Error()
 				} else {
@@ -146,132 +170,39 @@ func (p *journeyCsvParser) Parse(reader io.Reader, journeyChan chan<- *domain.Jo
 
 }
 
-func (p *journeyCsvParser) parseJourney27Fields(r []string, lineNumber int) (journey *domain.Journey, err error) {
-	defer func() {
-		if panicErr := recover(); panicErr != nil {
-			err = fmt.Errorf("problem while parsing a journey: line %d in wrong format", lineNumber)
-			p.logger.Errorw(err.Error(),
+// parseJourney converts a line of the file into a journey, using the columns of the schema of the file
+//
+// @param schema - Schema of the file
+// @param columns - Columns of the schema, in the order of the file
+// @param r - Fields of the line
+// @param lineNumber - Number of the line, used in errors
+func (p *journeyCsvParser) parseJourney(schema *JourneySchema, columns []*SchemaColumn, r []string, lineNumber int) (*domain.Journey, error) {
+	if len(r) != len(columns) {
+		return nil, fmt.Errorf("problem while parsing a journey: line %d has %d fields, schema %s expects %d", lineNumber, len(r), schema.Name, len(columns))
+	}
+
+	journey := &domain.Journey{}
+	for i, column := range columns {
+		if err := column.Set(journey, r[i]); err != nil {
+			p.logger.Errorw("Problem while parsing a journey",
 				"line", strings.Join(r, ","),
-				"error", panicErr,
+				"error", err,
 			)
+			return nil, fmt.Errorf("problem while parsing a journey: line %d, field %s: %w", lineNumber, column.Name, err)
 		}
-	}()
-
-	journeyId, _ := strconv.ParseInt(r[0], 10, 64)
-	tripId, _ := uuid.Parse(r[1])
-	startDateTime, _ := time.Parse("2006-01-02T15:04:05-07:00", r[2])
-	startDate, _ := time.Parse(time.DateOnly, r[3])
-	startTime, _ := time.Parse(time.TimeOnly, r[4])
-	startLon, _ := strconv.ParseUint(r[5], 10, 64)
-	startLat, _ := strconv.ParseUint(r[6], 10, 64)
-	startInsee, _ := strconv.ParseInt(r[7], 10, 64)
-
-	endDateTime, _ := time.Parse("2006-01-02T15:04:05-07:00", r[12])
-	endDate, _ := time.Parse(time.DateOnly, r[13])
-	endTime, _ := time.Parse(time.TimeOnly, r[14])
-	endLon, _ := strconv.ParseUint(r[15], 10, 64)
-	endLat, _ := strconv.ParseUint(r[16], 10, 64)
-	endInsee, _ := strconv.ParseInt(r[17], 10, 64)
-	passagerSeats, _ := strconv.ParseInt(r[22], 10, 16)
-	distance, _ := strconv.ParseInt(r[24], 10, 64)
-	duration, _ := strconv.ParseInt(r[25], 10, 64)
-	hasIncentive := r[26] == "OUI"
-
-	journey = &domain.Journey{
-		JourneyId:              journeyId,
-		TripId:                 tripId,
-		JourneyStartDatetime:   startDateTime,
-		JourneyStartDate:       startDate,
-		JourneyStartTime:       startTime,
-		JourneyStartLon:        startLon,
-		JourneyStartLat:        startLat,
-		JourneyStartInsee:      startInsee,
-		JourneyStartDepartment: r[8],
-		JourneyStartTown:       r[9],
-		JourneyStartTowngroup:  r[10],
-		JourneyStartCountry:    r[11],
-		JourneyEndDatetime:     endDateTime,
-		JourneyEndDate:         endDate,
-		JourneyEndTime:         endTime,
-		JourneyEndLon:          endLon,
-		JourneyEndLat:          endLat,
-		JourneyEndInsee:        endInsee,
-		JourneyEndDepartment:   r[18],
-		JourneyEndTown:         r[19],
-		JourneyEndTowngroup:    r[20],
-		JourneyEndCountry:      r[21],
-		PassengerSeats:         int16(passagerSeats),
-		OperatorClass:          r[23],
-		JourneyDistance:        distance,
-		JourneyDuration:        duration,
-		HasIncentive:           hasIncentive,
 	}
 
 	return journey, nil
 }
 
-func (p *journeyCsvParser) parseJourney29Fields(r []string, lineNumber int) (journey *domain.Journey, err error) {
-	defer func() {
-		if panicErr := recover(); panicErr != nil {
-			err = fmt.Errorf("problem while parsing a journey: line %d in wrong format", lineNumber)
-			p.logger.Errorw(err.Error(),
-				"line", strings.Join(r, ","),
-				"error", panicErr,
-			)
+// sniffCsv recognizes a CSV file whose first line holds the headers of one of the schemas
+func sniffCsv(head []byte, schemas []*JourneySchema) bool {
+	firstLine, _, _ := strings.Cut(string(trimJourneyFileHead(head)), "\n")
+	firstLine = strings.TrimRight(firstLine, "\r")
+	for _, schema := range schemas {
+		if schema.MatchHeaders(strings.Split(firstLine, schema.Separator)) {
+			return true
 		}
-	}()
-
-	journeyId, _ := strconv.ParseInt(r[0], 10, 64)
-	tripId, _ := uuid.Parse(r[1])
-	startDateTime, _ := time.Parse("2006-01-02T15:04:05-07:00", r[2])
-	startDate, _ := time.Parse(time.DateOnly, r[3])
-	startTime, _ := time.Parse(time.TimeOnly, r[4])
-	startLon, _ := strconv.ParseUint(r[5], 10, 64)
-	startLat, _ := strconv.ParseUint(r[6], 10, 64)
-	startInsee, _ := strconv.ParseInt(r[7], 10, 64)
-
-	endDateTime, _ := time.Parse("2006-01-02T15:04:05-07:00", r[13])
-	endDate, _ := time.Parse(time.DateOnly, r[14])
-	endTime, _ := time.Parse(time.TimeOnly, r[15])
-	endLon, _ := strconv.ParseUint(r[16], 10, 64)
-	endLat, _ := strconv.ParseUint(r[17], 10, 64)
-	endInsee, _ := strconv.ParseInt(r[18], 10, 64)
-	passagerSeats, _ := strconv.ParseInt(r[24], 10, 16)
-	distance, _ := strconv.ParseInt(r[26], 10, 64)
-	duration, _ := strconv.ParseInt(r[27], 10, 64)
-	hasIncentive := r[28] == "OUI"
-
-	journey = &domain.Journey{
-		JourneyId:              journeyId,
-		TripId:                 tripId,
-		JourneyStartDatetime:   startDateTime,
-		JourneyStartDate:       startDate,
-		JourneyStartTime:       startTime,
-		JourneyStartLon:        startLon,
-		JourneyStartLat:        startLat,
-		JourneyStartInsee:      startInsee,
-		JourneyStartPostalcode: r[8],
-		JourneyStartDepartment: r[9],
-		JourneyStartTown:       r[10],
-		JourneyStartTowngroup:  r[11],
-		JourneyStartCountry:    r[12],
-		JourneyEndDatetime:     endDateTime,
-		JourneyEndDate:         endDate,
-		JourneyEndTime:         endTime,
-		JourneyEndLon:          endLon,
-		JourneyEndLat:          endLat,
-		JourneyEndInsee:        endInsee,
-		JourneyEndPostalcode:   r[19],
-		JourneyEndDepartment:   r[20],
-		JourneyEndTown:         r[21],
-		JourneyEndTowngroup:    r[22],
-		JourneyEndCountry:      r[23],
-		PassengerSeats:         int16(passagerSeats),
-		OperatorClass:          r[25],
-		JourneyDistance:        distance,
-		JourneyDuration:        duration,
-		HasIncentive:           hasIncentive,
 	}
-
-	return journey, nil
+	return false
 }
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.uber.org/zap"
+)
+
+// JourneyExporterFactory creates an exporter from the configuration and the known schemas
+type JourneyExporterFactory func(logger *zap.SugaredLogger, cfg *configuration.Config, schemas []*JourneySchema) domain.JourneyExporter
+
+var journeyExporterFactories = map[string]JourneyExporterFactory{}
+
+// RegisterJourneyExporter makes an export format available.
+//
+// @param name - Name of the format, as requested by the clients. Must be unique
+// @param factory - Factory creating the exporter
+func RegisterJourneyExporter(name string, factory JourneyExporterFactory) {
+	if _, ok := journeyExporterFactories[name]; ok {
+		panic(fmt.Sprintf("journey exporter %s is already registered", name))
+	}
+	journeyExporterFactories[name] = factory
+}
+
+type journeyExporterRegistry struct {
+	exporters map[string]domain.JourneyExporter
+}
+
+// NewJourneyExporterRegistry creates an exporter for each registered format.
+//
+// @param logger - Logger to use. Must not be nil.
+// @param cfg - Configuration given to the exporters
+// @param schemas - the known layouts of the files, see LoadJourneySchemas
+func NewJourneyExporterRegistry(logger *zap.SugaredLogger, cfg *configuration.Config, schemas []*JourneySchema) domain.JourneyExporterRegistry {
+	registry := &journeyExporterRegistry{
+		exporters: map[string]domain.JourneyExporter{},
+	}
+	for name, factory := range journeyExporterFactories {
+		registry.exporters[name] = factory(logger, cfg, schemas)
+	}
+
+	logger.Infow("Journey exporters registered",
+		"formats", registry.formats(),
+	)
+	return registry
+}
+
+// Get returns the exporter of a format
+//
+// @param format - Name of the format
+func (r *journeyExporterRegistry) Get(format string) (domain.JourneyExporter, error) {
+	exporter, ok := r.exporters[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported export format '%s' (available: %s)", format, strings.Join(r.formats(), ", "))
+	}
+	return exporter, nil
+}
+
+func (r *journeyExporterRegistry) formats() []string {
+	names := make([]string, 0, len(r.exporters))
+	for name := range r.exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
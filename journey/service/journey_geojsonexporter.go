@@ -0,0 +1,181 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"go.uber.org/zap"
+)
+
+// Number of journeys of a GeoJSON export when the configuration doesn't give it
+const defaultGeoJsonMaxFeatures = 100000
+
+func init() {
+	RegisterJourneyExporter(domain.JourneyFormatGeoJSONStart, NewJourneyGeoJsonExporterFactory(journeyStartPoint))
+	RegisterJourneyExporter(domain.JourneyFormatGeoJSONEnd, NewJourneyGeoJsonExporterFactory(journeyEndPoint))
+	RegisterJourneyExporter(domain.JourneyFormatGeoJSONLine, NewJourneyGeoJsonExporterFactory(journeyLine))
+}
+
+// JourneyGeometry gives the geometry of the feature of a journey, and false when the journey has no location
+type JourneyGeometry func(journey *domain.Journey) (orb.Geometry, bool)
+
+type journeyGeoJsonExporter struct {
+	logger      *zap.SugaredLogger
+	maxFeatures int64
+	geometry    JourneyGeometry
+}
+
+// NewJourneyGeoJsonExporterFactory returns the factory of an exporter writing a GeoJSON FeatureCollection, one feature per journey.
+// The properties of the features are the fields of the journeys, the journeys without location are skipped.
+//
+// @param geometry - Geometry of the features
+func NewJourneyGeoJsonExporterFactory(geometry JourneyGeometry) JourneyExporterFactory {
+	return func(logger *zap.SugaredLogger, cfg *configuration.Config, schemas []*JourneySchema) domain.JourneyExporter {
+		maxFeatures := cfg.Journey.Export.GeoJSON.MaxFeatures
+		if maxFeatures <= 0 {
+			maxFeatures = defaultGeoJsonMaxFeatures
+		}
+
+		return &journeyGeoJsonExporter{
+			logger:      logger,
+			maxFeatures: maxFeatures,
+			geometry:    geometry,
+		}
+	}
+}
+
+// ContentType returns the MIME type of GeoJSON files
+func (e *journeyGeoJsonExporter) ContentType() string {
+	return "application/geo+json"
+}
+
+// Extension returns the extension of GeoJSON files
+func (e *journeyGeoJsonExporter) Extension() string {
+	return ".geojson"
+}
+
+// MaxJourneys returns the maximum number of features of a file
+func (e *journeyGeoJsonExporter) MaxJourneys() int64 {
+	return e.maxFeatures
+}
+
+// Export writes the journeys as the features of a FeatureCollection, each feature being written as soon as its journey is received
+//
+// @param writer - Destination of the file
+// @param journeyChan - Channel of the journeys to export
+func (e *journeyGeoJsonExporter) Export(writer io.Writer, journeyChan <-chan *domain.Journey) (int64, error) {
+	bufWriter := bufio.NewWriter(writer)
+	if _, err := bufWriter.WriteString(`{"type":"FeatureCollection","features":[`); err != nil {
+		return 0, err
+	}
+
+	var nbExported, nbSkipped int64
+	for journey := range journeyChan {
+		geometry, ok := e.geometry(journey)
+		if !ok {
+			nbSkipped++
+			continue
+		}
+
+		feature := geojson.NewFeature(geometry)
+		feature.Properties = journeyProperties(journey)
+		content, err := json.Marshal(feature)
+		if err != nil {
+			return nbExported, err
+		}
+
+		if nbExported > 0 {
+			bufWriter.WriteByte(',')
+		}
+		bufWriter.WriteByte('\n')
+		if _, err := bufWriter.Write(content); err != nil {
+			return nbExported, err
+		}
+		nbExported++
+	}
+
+	if _, err := bufWriter.WriteString("\n]}\n"); err != nil {
+		return nbExported, err
+	}
+
+	e.logger.Debugw("Journeys exported",
+		"format", "geojson",
+		"nbJourneys", nbExported,
+		"nbSkipped", nbSkipped,
+	)
+	return nbExported, bufWriter.Flush()
+}
+
+// journeyStartPoint gives the start point of a journey
+func journeyStartPoint(journey *domain.Journey) (orb.Geometry, bool) {
+	point := orb.Point{journey.JourneyStartLon, journey.JourneyStartLat}
+	return point, !point.Equal(orb.Point{})
+}
+
+// journeyEndPoint gives the end point of a journey
+func journeyEndPoint(journey *domain.Journey) (orb.Geometry, bool) {
+	point := orb.Point{journey.JourneyEndLon, journey.JourneyEndLat}
+	return point, !point.Equal(orb.Point{})
+}
+
+// journeyLine gives the line from the start point to the end point of a journey
+func journeyLine(journey *domain.Journey) (orb.Geometry, bool) {
+	start, startOk := journeyStartPoint(journey)
+	end, endOk := journeyEndPoint(journey)
+	if !startOk || !endOk {
+		return nil, false
+	}
+	return orb.LineString{start.(orb.Point), end.(orb.Point)}, true
+}
+
+// journeyProperties gives the fields of a journey, named as the columns of the official files. Unset dates are nulls
+func journeyProperties(journey *domain.Journey) geojson.Properties {
+	return geojson.Properties{
+		"journey_id":               journey.JourneyId,
+		"trip_id":                  journey.TripId.String(),
+		"journey_start_datetime":   formatProperty(journey.JourneyStartDatetime, time.RFC3339),
+		"journey_start_date":       formatProperty(journey.JourneyStartDate, "2006-01-02"),
+		"journey_start_time":       formatProperty(journey.JourneyStartTime, "15:04:05"),
+		"journey_start_insee":      journey.JourneyStartInsee,
+		"journey_start_postalcode": journey.JourneyStartPostalcode,
+		"journey_start_department": journey.JourneyStartDepartment,
+		"journey_start_town":       journey.JourneyStartTown,
+		"journey_start_towngroup":  journey.JourneyStartTowngroup,
+		"journey_start_country":    journey.JourneyStartCountry,
+		"journey_start_region":     journey.JourneyStartRegion,
+		"journey_start_epci":       journey.JourneyStartEpci,
+		"journey_start_population": journey.JourneyStartPopulation,
+		"journey_end_datetime":     formatProperty(journey.JourneyEndDatetime, time.RFC3339),
+		"journey_end_date":         formatProperty(journey.JourneyEndDate, "2006-01-02"),
+		"journey_end_time":         formatProperty(journey.JourneyEndTime, "15:04:05"),
+		"journey_end_insee":        journey.JourneyEndInsee,
+		"journey_end_postalcode":   journey.JourneyEndPostalcode,
+		"journey_end_department":   journey.JourneyEndDepartment,
+		"journey_end_town":         journey.JourneyEndTown,
+		"journey_end_towngroup":    journey.JourneyEndTowngroup,
+		"journey_end_country":      journey.JourneyEndCountry,
+		"journey_end_region":       journey.JourneyEndRegion,
+		"journey_end_epci":         journey.JourneyEndEpci,
+		"journey_end_population":   journey.JourneyEndPopulation,
+		"passenger_seats":          journey.PassengerSeats,
+		"operator_class":           journey.OperatorClass,
+		"journey_distance":         journey.JourneyDistance,
+		"journey_duration":         journey.JourneyDuration,
+		"has_incentive":            journey.HasIncentive,
+		"flags":                    journey.Flags,
+	}
+}
+
+func formatProperty(value time.Time, layout string) interface{} {
+	if value.IsZero() {
+		return nil
+	}
+	return value.Format(layout)
+}
@@ -0,0 +1,97 @@
+package service_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/service"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportGeoJson(t *testing.T) {
+	schemas, err := service.LoadJourneySchemas(&logger, config)
+	assert.NoError(t, err)
+	registry := service.NewJourneyExporterRegistry(&logger, config, schemas)
+
+	journeys := []*domain.Journey{
+		{
+			JourneyId:              1,
+			JourneyStartDatetime:   time.Date(2023, 2, 1, 8, 30, 0, 0, time.UTC),
+			JourneyStartLon:        -1.678,
+			JourneyStartLat:        48.117,
+			JourneyStartDepartment: "35",
+			JourneyEndLon:          -1.513,
+			JourneyEndLat:          48.195,
+			OperatorClass:          "C",
+			HasIncentive:           true,
+		},
+		{JourneyId: 2, JourneyStartLon: -1.6, JourneyStartLat: 48.1},
+		{JourneyId: 3},
+	}
+
+	var tests = []struct {
+		format             string
+		expectedGeometries []orb.Geometry
+	}{
+		{domain.JourneyFormatGeoJSONStart, []orb.Geometry{orb.Point{-1.678, 48.117}, orb.Point{-1.6, 48.1}}},
+		{domain.JourneyFormatGeoJSONEnd, []orb.Geometry{orb.Point{-1.513, 48.195}}},
+		{domain.JourneyFormatGeoJSONLine, []orb.Geometry{orb.LineString{{-1.678, 48.117}, {-1.513, 48.195}}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			exporter, err := registry.Get(test.format)
+			assert.NoError(t, err)
+			assert.Equal(t, "application/geo+json", exporter.ContentType())
+			assert.Equal(t, int64(3), exporter.(domain.LimitedJourneyExporter).MaxJourneys())
+
+			journeyChan := make(chan *domain.Journey, len(journeys))
+			for _, journey := range journeys {
+				journeyChan <- journey
+			}
+			close(journeyChan)
+
+			buffer := &bytes.Buffer{}
+			nbExported, err := exporter.Export(buffer, journeyChan)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(test.expectedGeometries)), nbExported)
+
+			collection, err := geojson.UnmarshalFeatureCollection(buffer.Bytes())
+			assert.NoError(t, err)
+			assert.Len(t, collection.Features, len(test.expectedGeometries))
+			for i, feature := range collection.Features {
+				assert.Equal(t, test.expectedGeometries[i], feature.Geometry)
+			}
+
+			properties := collection.Features[0].Properties
+			assert.Equal(t, float64(1), properties["journey_id"])
+			assert.Equal(t, "2023-02-01T08:30:00Z", properties["journey_start_datetime"])
+			assert.Nil(t, properties["journey_end_datetime"])
+			assert.Equal(t, "35", properties["journey_start_department"])
+			assert.Equal(t, "C", properties["operator_class"])
+			assert.Equal(t, true, properties["has_incentive"])
+		})
+	}
+}
+
+func TestExportGeoJson_empty(t *testing.T) {
+	schemas, err := service.LoadJourneySchemas(&logger, config)
+	assert.NoError(t, err)
+	exporter, err := service.NewJourneyExporterRegistry(&logger, config, schemas).Get(domain.JourneyFormatGeoJSONStart)
+	assert.NoError(t, err)
+
+	journeyChan := make(chan *domain.Journey)
+	close(journeyChan)
+	buffer := &bytes.Buffer{}
+	_, err = exporter.Export(buffer, journeyChan)
+	assert.NoError(t, err)
+
+	collection, err := geojson.UnmarshalFeatureCollection(buffer.Bytes())
+	assert.NoError(t, err)
+	assert.Empty(t, collection.Features)
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.uber.org/zap"
+)
+
+func init() {
+	RegisterJourneyParser(JourneyParserFormat{
+		Name:         domain.JourneyFormatJSON,
+		Extensions:   []string{".json", ".ndjson", ".jsonl"},
+		ContentTypes: []string{"application/json", "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines"},
+		Sniff:        sniffJson,
+		Factory:      NewJourneyJsonParser,
+	})
+}
+
+type journeyJsonParser struct {
+	logger  *zap.SugaredLogger
+	cfg     *configuration.Config
+	schemas []*JourneySchema
+}
+
+// NewJourneyJsonParser returns a parser for newline-delimited JSON files and JSON arrays.
+// Each journey is an object whose keys are the column names of one of the known schemas.
+//
+// @param logger - the logger to use for logging errors. Must not be nil.
+// @param cfg - the configuration. Config to use for parsing the file
+// @param schemas - the known layouts of the files, see LoadJourneySchemas
+func NewJourneyJsonParser(logger *zap.SugaredLogger, cfg *configuration.Config, schemas []*JourneySchema) domain.JourneyParser {
+	return &journeyJsonParser{
+		logger,
+		cfg,
+		schemas,
+	}
+}
+
+type jsonJob struct {
+	object     json.RawMessage
+	lineNumber int
+}
+
+// Parse a journey JSON file and send the results to the given channel
+//
+// @param p - The parser to use for parsing
+// @param reader - JSON File reader, holding either one object per line or an array of objects
+// @param journeyChan - Channel which will be used to send imported journeys
+// @param errorChan - Channel which will be used to send errors
+func (p *journeyJsonParser) Parse(reader io.Reader, journeyChan chan<- *domain.Journey, errorChan chan<- string) {
+	go p.parse(reader, journeyChan, errorChan)
+}
+
+func (p *journeyJsonParser) parse(reader io.Reader, journeyChan chan<- *domain.Journey, errorChan chan<- string) {
+	bufReader := bufio.NewReader(reader)
+	if bom, _ := bufReader.Peek(3); string(bom) == "\ufeff" {
+		bufReader.Discard(len(bom))
+	}
+	lines := &lineCounter{reader: bufReader}
+	decoder := json.NewDecoder(lines)
+
+	numWorkers := p.cfg.Journey.Parser.WorkerPoolSize
+	jobs := make(chan *jsonJob, numWorkers)
+
+	// The schema is selected by the reader from the first journey, before sending any job
+	var schema *JourneySchema
+	var workerGroup sync.WaitGroup
+
+	worker := func(jobs <-chan *jsonJob, results chan<- *domain.Journey, errorChan chan<- string) {
+		p.logger.Debug("Worker started")
+		for job := range jobs {
+			fields, err := readJsonObject(job.object)
+			if err != nil {
+				errorChan <- fmt.Sprintf("problem while parsing a journey: line %d: %s", job.lineNumber, err.Error())
+				continue
+			}
+
+			res, err := p.parseJourney(schema, fields, job.lineNumber)
+			if err != nil {
+				errorChan <- err.Error()
+			} else {
+				results <- res
+			}
+		}
+		p.logger.Debug("Worker ended")
+	}
+
+	p.logger.Debug("Workers Initializing")
+	for w := 0; w < numWorkers; w++ {
+		workerGroup.Add(1)
+		go func() {
+			defer workerGroup.Done()
+			worker(jobs, journeyChan, errorChan)
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		if err := p.read(decoder, lines, &schema, jobs); err != nil {
+			p.logger.Error("Error reading json file:", err.Error())
+			errorChan <- err.Error()
+		}
+	}()
+
+	go func() {
+		workerGroup.Wait()
+		p.logger.Debug("Closing channels")
+		close(journeyChan)
+		close(errorChan)
+	}()
+}
+
+// read sends the objects of the file to the workers.
+// A file starting with '[' is an array of journeys, otherwise the file is a stream of journeys.
+// The schema of the file is the smallest one holding all the fields of the first journey.
+func (p *journeyJsonParser) read(decoder *json.Decoder, lines *lineCounter, schema **JourneySchema, jobs chan<- *jsonJob) error {
+	isArray := false
+	if decoder.More() {
+		if delim, ok := peekJsonDelim(decoder); ok && delim == '[' {
+			if _, err := decoder.Token(); err != nil {
+				return err
+			}
+			isArray = true
+		}
+	}
+
+	for decoder.More() {
+		var object json.RawMessage
+		if err := decoder.Decode(&object); err != nil {
+			return fmt.Errorf("problem while reading the file: line %d: %w", lines.line(jsonErrorOffset(decoder, err)), err)
+		}
+		// The decoder stops at the end of the object, after the separator and the spaces before it
+		lineNumber := lines.line(decoder.InputOffset() - int64(len(object)))
+
+		if *schema == nil {
+			fields, err := readJsonObject(object)
+			if err != nil {
+				return fmt.Errorf("problem while parsing a journey: line %d: %w", lineNumber, err)
+			}
+			if *schema = p.selectSchema(fields); *schema == nil {
+				return fmt.Errorf("the fields of the journeys are uncompatible with all the known schemas")
+			}
+			p.logger.Debugw("Schema selected",
+				"schema", (*schema).Name,
+			)
+		}
+
+		jobs <- &jsonJob{
+			object:     object,
+			lineNumber: lineNumber,
+		}
+	}
+
+	if isArray {
+		if _, err := decoder.Token(); err != nil {
+			return fmt.Errorf("problem while reading the file: line %d: %w", lines.line(decoder.InputOffset()), err)
+		}
+	}
+	return nil
+}
+
+// selectSchema finds the smallest schema holding all the fields of a journey
+func (p *journeyJsonParser) selectSchema(fields map[string]string) *JourneySchema {
+	var selected *JourneySchema
+	for _, schema := range p.schemas {
+		if unknownJsonField(schema, fields) != "" {
+			continue
+		}
+		if selected == nil || len(schema.Columns) < len(selected.Columns) {
+			selected = schema
+		}
+	}
+	return selected
+}
+
+// parseJourney converts an object of the file into a journey. Missing fields are empty values.
+//
+// @param schema - Schema of the file
+// @param fields - Fields of the object, as text
+// @param lineNumber - Number of the line where the object starts, used in errors
+func (p *journeyJsonParser) parseJourney(schema *JourneySchema, fields map[string]string, lineNumber int) (*domain.Journey, error) {
+	if field := unknownJsonField(schema, fields); field != "" {
+		return nil, fmt.Errorf("problem while parsing a journey: line %d, field %s is unknown in schema %s", lineNumber, field, schema.Name)
+	}
+
+	values := map[string]string{}
+	for name, value := range fields {
+		values[strings.ToLower(name)] = value
+	}
+
+	journey := &domain.Journey{}
+	for _, column := range schema.Columns {
+		if err := column.Set(journey, values[strings.ToLower(column.Name)]); err != nil {
+			p.logger.Errorw("Problem while parsing a journey",
+				"line", lineNumber,
+				"error", err,
+			)
+			return nil, fmt.Errorf("problem while parsing a journey: line %d, field %s: %w", lineNumber, column.Name, err)
+		}
+	}
+
+	return journey, nil
+}
+
+// unknownJsonField returns the first field, in alphabetical order, which is not a column of the schema
+func unknownJsonField(schema *JourneySchema, fields map[string]string) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := schema.Column(name); !ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// readJsonObject reads a journey object and converts its values into the text expected by the columns of the schemas.
+// Numbers keep their textual form, booleans become OUI/NON and nulls are empty values.
+func readJsonObject(object json.RawMessage) (map[string]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(object))
+	decoder.UseNumber()
+
+	var values map[string]interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("a journey must be an object: %w", err)
+	}
+
+	fields := make(map[string]string, len(values))
+	for name, value := range values {
+		switch v := value.(type) {
+		case nil:
+			fields[name] = ""
+		case string:
+			fields[name] = v
+		case json.Number:
+			fields[name] = v.String()
+		case bool:
+			if v {
+				fields[name] = domain.BooleanTrue
+			} else {
+				fields[name] = domain.BooleanFalse
+			}
+		default:
+			return nil, fmt.Errorf("field %s must be a string, a number or a boolean", name)
+		}
+	}
+	return fields, nil
+}
+
+// jsonErrorOffset returns the position of a decoding error: the position of the invalid character for a syntax error,
+// otherwise the position of the decoder
+func jsonErrorOffset(decoder *json.Decoder, err error) int64 {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return syntaxErr.Offset
+	}
+	return decoder.InputOffset()
+}
+
+// peekJsonDelim returns the first character of the next value without consuming it
+func peekJsonDelim(decoder *json.Decoder) (byte, bool) {
+	buffered, _ := io.ReadAll(io.LimitReader(decoder.Buffered(), 1))
+	if len(buffered) == 0 {
+		return 0, false
+	}
+	return buffered[0], true
+}
+
+// lineCounter counts the lines of the read content, to give the line of a position of a JSON decoder.
+// Positions must be asked in increasing order, the newlines before the asked position are forgotten.
+type lineCounter struct {
+	reader   io.Reader
+	read     int64
+	newlines []int64
+	current  int
+}
+
+func (l *lineCounter) Read(b []byte) (int, error) {
+	n, err := l.reader.Read(b)
+	for i := 0; i < n; i++ {
+		if b[i] == '\n' {
+			l.newlines = append(l.newlines, l.read+int64(i))
+		}
+	}
+	l.read += int64(n)
+	return n, err
+}
+
+// line returns the number of the line, starting at 1, of a position of the content
+func (l *lineCounter) line(offset int64) int {
+	for len(l.newlines) > 0 && l.newlines[0] < offset {
+		l.newlines = l.newlines[1:]
+		l.current++
+	}
+	return l.current + 1
+}
+
+// sniffJson recognizes a file starting with an object or an array
+func sniffJson(head []byte, schemas []*JourneySchema) bool {
+	head = trimJourneyFileHead(head)
+	return len(head) > 0 && (head[0] == '{' || head[0] == '[')
+}
@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJson(t *testing.T) {
+	cfg := *config
+	cfg.Journey.Parser.SchemaDirectory = filepath.Join("testdata", "schemas")
+	schemas, err := service.LoadJourneySchemas(&logger, &cfg)
+	assert.NoError(t, err)
+
+	expectedJourneys := []*domain.Journey{
+		{
+			JourneyId:            1,
+			JourneyStartDatetime: time.Date(2023, 2, 1, 8, 30, 0, 0, time.UTC),
+			OperatorClass:        "B",
+			PassengerSeats:       2,
+			HasIncentive:         true,
+		},
+		{
+			JourneyId:     2,
+			OperatorClass: "C",
+		},
+	}
+
+	t.Run("ndjson", func(t *testing.T) {
+		f, _ := os.Open(filepath.Join("testdata", "dataset_partner.ndjson"))
+		defer f.Close()
+
+		journeys, errors := parseAll(service.NewJourneyJsonParser(&logger, &cfg, schemas), f)
+
+		assert.Equal(t, expectedJourneys, journeys)
+		assert.Len(t, errors, 4)
+		assert.Contains(t, errors[0], "line 4, field class")
+		assert.Contains(t, errors[1], "line 5, field color is unknown")
+		assert.Contains(t, errors[2], "line 6: a journey must be an object")
+		assert.Contains(t, errors[3], "line 7, field incentive")
+	})
+
+	t.Run("array", func(t *testing.T) {
+		f, _ := os.Open(filepath.Join("testdata", "dataset_partner_array.json"))
+		defer f.Close()
+
+		journeys, errors := parseAll(service.NewJourneyJsonParser(&logger, &cfg, schemas), f)
+
+		assert.Equal(t, expectedJourneys, journeys)
+		assert.Len(t, errors, 1)
+		assert.Contains(t, errors[0], "line 13, field id")
+	})
+}
+
+func TestParseJson_unknownLayout(t *testing.T) {
+	schemas, err := service.LoadJourneySchemas(&logger, config)
+	assert.NoError(t, err)
+
+	f, _ := os.Open(filepath.Join("testdata", "dataset_partner.ndjson"))
+	defer f.Close()
+
+	journeys, errors := parseAll(service.NewJourneyJsonParser(&logger, config, schemas), f)
+
+	assert.Empty(t, journeys)
+	assert.Len(t, errors, 1)
+	assert.Contains(t, errors[0], "uncompatible")
+}
+
+func TestParseJson_malformed(t *testing.T) {
+	schemas, err := service.LoadJourneySchemas(&logger, config)
+	assert.NoError(t, err)
+
+	journeys, errors := parseAll(service.NewJourneyJsonParser(&logger, config, schemas), strings.NewReader("{\"journey_id\": 1}\n{\"journey_id\": "))
+
+	assert.Empty(t, journeys)
+	assert.Len(t, errors, 2)
+}
+
+func TestParseJson_arrayLineNumbers(t *testing.T) {
+	cfg := *config
+	cfg.Journey.Parser.SchemaDirectory = filepath.Join("testdata", "schemas")
+	schemas, err := service.LoadJourneySchemas(&logger, &cfg)
+	assert.NoError(t, err)
+
+	journeys, errors := parseAll(service.NewJourneyJsonParser(&logger, &cfg, schemas), strings.NewReader(
+		"[\n  {\"id\": 1, \"class\": \"B\"},\n\n  {\n    \"id\": 2,\n    \"class\": \"D\"\n  },\n  {\"id\": 3, \"class\": \"C\"}\n]"))
+
+	assert.Len(t, journeys, 2)
+	assert.Len(t, errors, 1)
+	assert.Contains(t, errors[0], "line 4, field class")
+
+	journeys, errors = parseAll(service.NewJourneyJsonParser(&logger, &cfg, schemas), strings.NewReader(
+		"[\n  {\"id\": 1, \"class\": \"B\"}\n  ,\n  {\"id\": 2,\n    \"class\": C}\n]"))
+
+	assert.Len(t, journeys, 1)
+	assert.Len(t, errors, 1)
+	assert.Contains(t, errors[0], "line 5: invalid character")
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"io"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/apache/arrow/go/v14/parquet"
+	"github.com/apache/arrow/go/v14/parquet/compress"
+	"github.com/apache/arrow/go/v14/parquet/file"
+	"github.com/apache/arrow/go/v14/parquet/schema"
+	"go.uber.org/zap"
+)
+
+// Number of journeys of a row group when the configuration doesn't give it
+const defaultParquetRowGroupSize = 100000
+
+func init() {
+	RegisterJourneyExporter(domain.JourneyFormatParquet, NewJourneyParquetExporter)
+}
+
+// parquetExportColumn describes a column of the exported files and how to write it from journeys
+type parquetExportColumn struct {
+	node  schema.Node
+	write func(column file.ColumnChunkWriter, journeys []*domain.Journey) error
+}
+
+type journeyParquetExporter struct {
+	logger       *zap.SugaredLogger
+	rowGroupSize int
+	columns      []parquetExportColumn
+}
+
+// NewJourneyParquetExporter returns an exporter writing Parquet files with the columns of the official files.
+// Datetimes are UTC timestamps, dates and times use their logical types, trip ids are UUIDs and passenger seats are 16 bits integers.
+// Empty strings and unset dates are written as nulls. The exported files can be imported again.
+//
+// @param logger - the logger to use. Must not be nil.
+// @param cfg - the configuration, giving the number of journeys of a row group
+// @param schemas - the known layouts of the files, unused
+func NewJourneyParquetExporter(logger *zap.SugaredLogger, cfg *configuration.Config, schemas []*JourneySchema) domain.JourneyExporter {
+	rowGroupSize := cfg.Journey.Export.Parquet.RowGroupSize
+	if rowGroupSize <= 0 {
+		rowGroupSize = defaultParquetRowGroupSize
+	}
+
+	return &journeyParquetExporter{
+		logger:       logger,
+		rowGroupSize: rowGroupSize,
+		columns: []parquetExportColumn{
+			parquetInt64Column("journey_id", func(j *domain.Journey) int64 { return j.JourneyId }),
+			parquetUUIDColumn("trip_id", func(j *domain.Journey) [16]byte { return j.TripId }),
+			parquetTimestampColumn("journey_start_datetime", func(j *domain.Journey) time.Time { return j.JourneyStartDatetime }),
+			parquetDateColumn("journey_start_date", func(j *domain.Journey) time.Time { return j.JourneyStartDate }),
+			parquetTimeColumn("journey_start_time", func(j *domain.Journey) time.Time { return j.JourneyStartTime }),
+			parquetDoubleColumn("journey_start_lon", func(j *domain.Journey) float64 { return j.JourneyStartLon }),
+			parquetDoubleColumn("journey_start_lat", func(j *domain.Journey) float64 { return j.JourneyStartLat }),
+			parquetInt64Column("journey_start_insee", func(j *domain.Journey) int64 { return j.JourneyStartInsee }),
+			parquetStringColumn("journey_start_postalcode", func(j *domain.Journey) string { return j.JourneyStartPostalcode }),
+			parquetStringColumn("journey_start_department", func(j *domain.Journey) string { return j.JourneyStartDepartment }),
+			parquetStringColumn("journey_start_town", func(j *domain.Journey) string { return j.JourneyStartTown }),
+			parquetStringColumn("journey_start_towngroup", func(j *domain.Journey) string { return j.JourneyStartTowngroup }),
+			parquetStringColumn("journey_start_country", func(j *domain.Journey) string { return j.JourneyStartCountry }),
+			parquetTimestampColumn("journey_end_datetime", func(j *domain.Journey) time.Time { return j.JourneyEndDatetime }),
+			parquetDateColumn("journey_end_date", func(j *domain.Journey) time.Time { return j.JourneyEndDate }),
+			parquetTimeColumn("journey_end_time", func(j *domain.Journey) time.Time { return j.JourneyEndTime }),
+			parquetDoubleColumn("journey_end_lon", func(j *domain.Journey) float64 { return j.JourneyEndLon }),
+			parquetDoubleColumn("journey_end_lat", func(j *domain.Journey) float64 { return j.JourneyEndLat }),
+			parquetInt64Column("journey_end_insee", func(j *domain.Journey) int64 { return j.JourneyEndInsee }),
+			parquetStringColumn("journey_end_postalcode", func(j *domain.Journey) string { return j.JourneyEndPostalcode }),
+			parquetStringColumn("journey_end_department", func(j *domain.Journey) string { return j.JourneyEndDepartment }),
+			parquetStringColumn("journey_end_town", func(j *domain.Journey) string { return j.JourneyEndTown }),
+			parquetStringColumn("journey_end_towngroup", func(j *domain.Journey) string { return j.JourneyEndTowngroup }),
+			parquetStringColumn("journey_end_country", func(j *domain.Journey) string { return j.JourneyEndCountry }),
+			parquetInt16Column("passenger_seats", func(j *domain.Journey) int16 { return j.PassengerSeats }),
+			parquetStringColumn("operator_class", func(j *domain.Journey) string { return j.OperatorClass }),
+			parquetInt64Column("journey_distance", func(j *domain.Journey) int64 { return j.JourneyDistance }),
+			parquetInt64Column("journey_duration", func(j *domain.Journey) int64 { return j.JourneyDuration }),
+			parquetBooleanColumn("has_incentive", func(j *domain.Journey) bool { return j.HasIncentive }),
+		},
+	}
+}
+
+// ContentType returns the MIME type of Parquet files
+func (e *journeyParquetExporter) ContentType() string {
+	return "application/vnd.apache.parquet"
+}
+
+// Extension returns the extension of Parquet files
+func (e *journeyParquetExporter) Extension() string {
+	return ".parquet"
+}
+
+// Export writes the journeys into a Parquet file, one row group being written each time enough journeys are received
+//
+// @param writer - Destination of the file
+// @param journeyChan - Channel of the journeys to export
+func (e *journeyParquetExporter) Export(writer io.Writer, journeyChan <-chan *domain.Journey) (int64, error) {
+	fields := make(schema.FieldList, 0, len(e.columns))
+	for _, column := range e.columns {
+		fields = append(fields, column.node)
+	}
+	root, err := schema.NewGroupNode("journey", parquet.Repetitions.Required, fields, -1)
+	if err != nil {
+		return 0, err
+	}
+
+	parquetWriter := file.NewParquetWriter(writer, root, file.WithWriterProps(parquet.NewWriterProperties(
+		parquet.WithCompression(compress.Codecs.Snappy),
+		parquet.WithMaxRowGroupLength(int64(e.rowGroupSize)),
+	)))
+
+	var nbExported int64
+	rowGroup := make([]*domain.Journey, 0, e.rowGroupSize)
+	for journey := range journeyChan {
+		rowGroup = append(rowGroup, journey)
+		if len(rowGroup) == e.rowGroupSize {
+			if err := e.writeRowGroup(parquetWriter, rowGroup); err != nil {
+				return nbExported, err
+			}
+			nbExported += int64(len(rowGroup))
+			rowGroup = rowGroup[:0]
+		}
+	}
+	if len(rowGroup) > 0 {
+		if err := e.writeRowGroup(parquetWriter, rowGroup); err != nil {
+			return nbExported, err
+		}
+		nbExported += int64(len(rowGroup))
+	}
+
+	e.logger.Debugw("Journeys exported",
+		"format", domain.JourneyFormatParquet,
+		"nbJourneys", nbExported,
+	)
+	return nbExported, parquetWriter.Close()
+}
+
+func (e *journeyParquetExporter) writeRowGroup(parquetWriter *file.Writer, journeys []*domain.Journey) error {
+	rowGroupWriter := parquetWriter.AppendRowGroup()
+	for _, column := range e.columns {
+		columnWriter, err := rowGroupWriter.NextColumn()
+		if err != nil {
+			return err
+		}
+		if err := column.write(columnWriter, journeys); err != nil {
+			return err
+		}
+	}
+	return rowGroupWriter.Close()
+}
+
+func parquetInt64Column(name string, value func(*domain.Journey) int64) parquetExportColumn {
+	return parquetExportColumn{
+		node: schema.NewInt64Node(name, parquet.Repetitions.Required, -1),
+		write: func(column file.ColumnChunkWriter, journeys []*domain.Journey) error {
+			return writeParquetValues(column.(*file.Int64ColumnChunkWriter).WriteBatch, journeys, false, func(j *domain.Journey) (int64, bool) {
+				return value(j), true
+			})
+		},
+	}
+}
+
+func parquetInt16Column(name string, value func(*domain.Journey) int16) parquetExportColumn {
+	node, _ := schema.NewPrimitiveNodeLogical(name, parquet.Repetitions.Required, schema.NewIntLogicalType(16, true), parquet.Types.Int32, -1, -1)
+	return parquetExportColumn{
+		node: node,
+		write: func(column file.ColumnChunkWriter, journeys []*domain.Journey) error {
+			return writeParquetValues(column.(*file.Int32ColumnChunkWriter).WriteBatch, journeys, false, func(j *domain.Journey) (int32, bool) {
+				return int32(value(j)), true
+			})
+		},
+	}
+}
+
+func parquetDoubleColumn(name string, value func(*domain.Journey) float64) parquetExportColumn {
+	return parquetExportColumn{
+		node: schema.NewFloat64Node(name, parquet.Repetitions.Required, -1),
+		write: func(column file.ColumnChunkWriter, journeys []*domain.Journey) error {
+			return writeParquetValues(column.(*file.Float64ColumnChunkWriter).WriteBatch, journeys, false, func(j *domain.Journey) (float64, bool) {
+				return value(j), true
+			})
+		},
+	}
+}
+
+func parquetBooleanColumn(name string, value func(*domain.Journey) bool) parquetExportColumn {
+	return parquetExportColumn{
+		node: schema.NewBooleanNode(name, parquet.Repetitions.Required, -1),
+		write: func(column file.ColumnChunkWriter, journeys []*domain.Journey) error {
+			return writeParquetValues(column.(*file.BooleanColumnChunkWriter).WriteBatch, journeys, false, func(j *domain.Journey) (bool, bool) {
+				return value(j), true
+			})
+		},
+	}
+}
+
+func parquetStringColumn(name string, value func(*domain.Journey) string) parquetExportColumn {
+	node, _ := schema.NewPrimitiveNodeLogical(name, parquet.Repetitions.Optional, schema.StringLogicalType{}, parquet.Types.ByteArray, -1, -1)
+	return parquetExportColumn{
+		node: node,
+		write: func(column file.ColumnChunkWriter, journeys []*domain.Journey) error {
+			return writeParquetValues(column.(*file.ByteArrayColumnChunkWriter).WriteBatch, journeys, true, func(j *domain.Journey) (parquet.ByteArray, bool) {
+				text := value(j)
+				return parquet.ByteArray(text), text != ""
+			})
+		},
+	}
+}
+
+func parquetUUIDColumn(name string, value func(*domain.Journey) [16]byte) parquetExportColumn {
+	node, _ := schema.NewPrimitiveNodeLogical(name, parquet.Repetitions.Required, schema.UUIDLogicalType{}, parquet.Types.FixedLenByteArray, 16, -1)
+	return parquetExportColumn{
+		node: node,
+		write: func(column file.ColumnChunkWriter, journeys []*domain.Journey) error {
+			return writeParquetValues(column.(*file.FixedLenByteArrayColumnChunkWriter).WriteBatch, journeys, false, func(j *domain.Journey) (parquet.FixedLenByteArray, bool) {
+				id := value(j)
+				return parquet.FixedLenByteArray(id[:]), true
+			})
+		},
+	}
+}
+
+func parquetTimestampColumn(name string, value func(*domain.Journey) time.Time) parquetExportColumn {
+	node, _ := schema.NewPrimitiveNodeLogical(name, parquet.Repetitions.Optional, schema.NewTimestampLogicalType(true, schema.TimeUnitMillis), parquet.Types.Int64, -1, -1)
+	return parquetExportColumn{
+		node: node,
+		write: func(column file.ColumnChunkWriter, journeys []*domain.Journey) error {
+			return writeParquetValues(column.(*file.Int64ColumnChunkWriter).WriteBatch, journeys, true, func(j *domain.Journey) (int64, bool) {
+				datetime := value(j)
+				return datetime.UnixMilli(), !datetime.IsZero()
+			})
+		},
+	}
+}
+
+func parquetDateColumn(name string, value func(*domain.Journey) time.Time) parquetExportColumn {
+	node, _ := schema.NewPrimitiveNodeLogical(name, parquet.Repetitions.Optional, schema.DateLogicalType{}, parquet.Types.Int32, -1, -1)
+	return parquetExportColumn{
+		node: node,
+		write: func(column file.ColumnChunkWriter, journeys []*domain.Journey) error {
+			return writeParquetValues(column.(*file.Int32ColumnChunkWriter).WriteBatch, journeys, true, func(j *domain.Journey) (int32, bool) {
+				date := value(j)
+				year, month, day := date.Date()
+				days := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / (24 * 3600)
+				return int32(days), !date.IsZero()
+			})
+		},
+	}
+}
+
+func parquetTimeColumn(name string, value func(*domain.Journey) time.Time) parquetExportColumn {
+	node, _ := schema.NewPrimitiveNodeLogical(name, parquet.Repetitions.Optional, schema.NewTimeLogicalType(false, schema.TimeUnitMillis), parquet.Types.Int32, -1, -1)
+	return parquetExportColumn{
+		node: node,
+		write: func(column file.ColumnChunkWriter, journeys []*domain.Journey) error {
+			return writeParquetValues(column.(*file.Int32ColumnChunkWriter).WriteBatch, journeys, true, func(j *domain.Journey) (int32, bool) {
+				timeOfDay := value(j)
+				hour, minute, second := timeOfDay.Clock()
+				millis := ((hour*60+minute)*60+second)*1000 + timeOfDay.Nanosecond()/int(time.Millisecond)
+				return int32(millis), !timeOfDay.IsZero()
+			})
+		},
+	}
+}
+
+// writeParquetValues writes a value of each journey into a column chunk
+//
+// @param writeBatch - WriteBatch method of the typed column chunk writer
+// @param journeys - Journeys of the row group
+// @param optional - Whether the column accepts nulls
+// @param value - Value of a journey, and false when it is null
+func writeParquetValues[T any](writeBatch func([]T, []int16, []int16) (int64, error), journeys []*domain.Journey, optional bool, value func(*domain.Journey) (T, bool)) error {
+	values := make([]T, 0, len(journeys))
+	var definitionLevels []int16
+	if optional {
+		definitionLevels = make([]int16, len(journeys))
+	}
+
+	for i, journey := range journeys {
+		v, ok := value(journey)
+		if !ok && optional {
+			continue
+		}
+		values = append(values, v)
+		if optional {
+			definitionLevels[i] = 1
+		}
+	}
+
+	_, err := writeBatch(values, definitionLevels, nil)
+	return err
+}
@@ -0,0 +1,99 @@
+package service_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/service"
+
+	"github.com/apache/arrow/go/v14/parquet/file"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// exportAll exports the journeys and returns the written file
+func exportAll(t *testing.T, exporter domain.JourneyExporter, journeys []*domain.Journey) *bytes.Reader {
+	journeyChan := make(chan *domain.Journey, len(journeys))
+	for _, journey := range journeys {
+		journeyChan <- journey
+	}
+	close(journeyChan)
+
+	buffer := &bytes.Buffer{}
+	nbExported, err := exporter.Export(buffer, journeyChan)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(journeys)), nbExported)
+	return bytes.NewReader(buffer.Bytes())
+}
+
+func TestExportParquet(t *testing.T) {
+	schemas, err := service.LoadJourneySchemas(&logger, config)
+	assert.NoError(t, err)
+
+	start := time.Date(2023, 2, 1, 8, 30, 0, 0, time.UTC)
+	end := start.Add(25 * time.Minute)
+	journeys := []*domain.Journey{
+		{
+			JourneyId:              1,
+			TripId:                 uuid.MustParse("4d5e2a3c-8f1b-4c6a-9e3d-1a2b3c4d5e6f"),
+			JourneyStartDatetime:   start,
+			JourneyStartDate:       time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+			JourneyStartTime:       time.Date(0, 1, 1, 8, 30, 0, 0, time.UTC),
+			JourneyStartLon:        -1.678,
+			JourneyStartLat:        48.117,
+			JourneyStartInsee:      35238,
+			JourneyStartPostalcode: "35000",
+			JourneyStartDepartment: "35",
+			JourneyStartTown:       "Rennes",
+			JourneyStartTowngroup:  "Rennes Métropole",
+			JourneyStartCountry:    "France",
+			JourneyEndDatetime:     end,
+			JourneyEndDate:         time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+			JourneyEndTime:         time.Date(0, 1, 1, 8, 55, 0, 0, time.UTC),
+			JourneyEndLon:          -1.513,
+			JourneyEndLat:          48.195,
+			JourneyEndInsee:        35176,
+			JourneyEndDepartment:   "35",
+			JourneyEndCountry:      "France",
+			PassengerSeats:         2,
+			OperatorClass:          "C",
+			JourneyDistance:        15230,
+			JourneyDuration:        1500,
+			HasIncentive:           true,
+		},
+		{JourneyId: 2, TripId: uuid.New(), OperatorClass: "A"},
+		{JourneyId: 3, TripId: uuid.New(), OperatorClass: "B", PassengerSeats: 1},
+	}
+
+	exporter := service.NewJourneyParquetExporter(&logger, config, schemas)
+	assert.Equal(t, ".parquet", exporter.Extension())
+	reader := exportAll(t, exporter, journeys)
+
+	parquetReader, err := file.NewParquetReader(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, 29, parquetReader.MetaData().Schema.NumColumns())
+	// The test configuration writes row groups of 2 journeys
+	assert.Equal(t, 2, parquetReader.NumRowGroups())
+
+	reader.Seek(0, 0)
+	imported, errors := parseAll(service.NewJourneyParquetParser(&logger, config, schemas), reader)
+	assert.Empty(t, errors)
+	for _, journey := range imported {
+		journey.JourneyStartDatetime = journey.JourneyStartDatetime.UTC()
+		journey.JourneyEndDatetime = journey.JourneyEndDatetime.UTC()
+	}
+	assert.Equal(t, journeys, imported)
+}
+
+func TestExportParquet_empty(t *testing.T) {
+	schemas, err := service.LoadJourneySchemas(&logger, config)
+	assert.NoError(t, err)
+
+	reader := exportAll(t, service.NewJourneyParquetExporter(&logger, config, schemas), nil)
+
+	parquetReader, err := file.NewParquetReader(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), parquetReader.NumRows())
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/apache/arrow/go/v14/parquet"
+	"github.com/apache/arrow/go/v14/parquet/file"
+	"github.com/apache/arrow/go/v14/parquet/schema"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func init() {
+	RegisterJourneyParser(JourneyParserFormat{
+		Name:         domain.JourneyFormatParquet,
+		Extensions:   []string{".parquet"},
+		ContentTypes: []string{"application/vnd.apache.parquet", "application/x-parquet"},
+		Sniff:        sniffParquet,
+		Factory:      NewJourneyParquetParser,
+	})
+}
+
+// Magic number at the beginning and at the end of Parquet files
+var parquetMagic = []byte("PAR1")
+
+type journeyParquetParser struct {
+	logger  *zap.SugaredLogger
+	cfg     *configuration.Config
+	schemas []*JourneySchema
+}
+
+// parquetColumn binds a leaf column of a Parquet file to the column of a schema
+type parquetColumn struct {
+	column *SchemaColumn
+	leaf   *schema.Column
+}
+
+// NewJourneyParquetParser returns a parser for Parquet files.
+// The columns of the file are named as the columns of one of the known schemas, the row groups are read concurrently.
+//
+// @param logger - the logger to use for logging errors. Must not be nil.
+// @param cfg - the configuration. Config to use for parsing the file
+// @param schemas - the known layouts of the files, see LoadJourneySchemas
+func NewJourneyParquetParser(logger *zap.SugaredLogger, cfg *configuration.Config, schemas []*JourneySchema) domain.JourneyParser {
+	return &journeyParquetParser{
+		logger,
+		cfg,
+		schemas,
+	}
+}
+
+// Parse a journey Parquet file and send the results to the given channel
+//
+// @param p - The parser to use for parsing
+// @param reader - Parquet File reader. Files which are not an io.ReaderAt and an io.Seeker are read in memory
+// @param journeyChan - Channel which will be used to send imported journeys
+// @param errorChan - Channel which will be used to send errors
+func (p *journeyParquetParser) Parse(reader io.Reader, journeyChan chan<- *domain.Journey, errorChan chan<- string) {
+	go p.parse(reader, journeyChan, errorChan)
+}
+
+func (p *journeyParquetParser) parse(reader io.Reader, journeyChan chan<- *domain.Journey, errorChan chan<- string) {
+	defer close(errorChan)
+	defer close(journeyChan)
+
+	parquetFile, err := openParquetFile(reader)
+	if err != nil {
+		p.logger.Errorw("Error opening parquet file",
+			"error", err,
+		)
+		errorChan <- err.Error()
+		return
+	}
+	defer parquetFile.Close()
+
+	journeySchema, columns, err := p.selectSchema(parquetFile.MetaData().Schema)
+	if err != nil {
+		p.logger.Errorw("Unknown file layout",
+			"error", err,
+		)
+		errorChan <- err.Error()
+		return
+	}
+	p.logger.Debugw("Schema selected",
+		"schema", journeySchema.Name,
+		"nbRowGroups", parquetFile.NumRowGroups(),
+	)
+
+	rowOffsets := make([]int64, parquetFile.NumRowGroups())
+	for i := 1; i < len(rowOffsets); i++ {
+		rowOffsets[i] = rowOffsets[i-1] + parquetFile.RowGroup(i-1).NumRows()
+	}
+
+	numWorkers := p.cfg.Journey.Parser.WorkerPoolSize
+	rowGroupChan := make(chan int)
+	var workerGroup sync.WaitGroup
+	p.logger.Debug("Workers Initializing")
+	for w := 0; w < numWorkers; w++ {
+		workerGroup.Add(1)
+		go func() {
+			defer workerGroup.Done()
+			for i := range rowGroupChan {
+				p.readRowGroup(parquetFile.RowGroup(i), rowOffsets[i], columns, journeyChan, errorChan)
+			}
+		}()
+	}
+
+	for i := 0; i < parquetFile.NumRowGroups(); i++ {
+		rowGroupChan <- i
+	}
+	close(rowGroupChan)
+
+	workerGroup.Wait()
+	p.logger.Debug("Closing channels")
+}
+
+// readRowGroup parses the rows of a row group, its columns being read one after the other
+//
+// @param rowGroup - Row group to read
+// @param rowOffset - Number of rows of the previous row groups, used to number the rows
+// @param columns - Columns of the schema, in the order of the columns of the file
+func (p *journeyParquetParser) readRowGroup(rowGroup *file.RowGroupReader, rowOffset int64, columns []*parquetColumn, journeyChan chan<- *domain.Journey, errorChan chan<- string) {
+	nbRows := int(rowGroup.NumRows())
+	values := make([][]string, len(columns))
+	for i, column := range columns {
+		chunk, err := rowGroup.Column(i)
+		if err == nil {
+			values[i], err = readParquetColumn(chunk, column, nbRows)
+		}
+		if err != nil {
+			p.logger.Error("Error reading parquet file:", err.Error())
+			errorChan <- fmt.Sprintf("problem while reading column %s from line %d: %s", column.column.Name, rowOffset+1, err.Error())
+			return
+		}
+	}
+
+	for row := 0; row < nbRows; row++ {
+		lineNumber := int(rowOffset) + row + 1
+		journey, err := p.parseJourney(columns, values, row, lineNumber)
+		if err != nil {
+			errorChan <- err.Error()
+		} else {
+			journeyChan <- journey
+		}
+	}
+}
+
+func (p *journeyParquetParser) parseJourney(columns []*parquetColumn, values [][]string, row int, lineNumber int) (*domain.Journey, error) {
+	journey := &domain.Journey{}
+	for i, column := range columns {
+		if err := column.column.Set(journey, values[i][row]); err != nil {
+			return nil, fmt.Errorf("problem while parsing a journey: line %d, field %s: %w", lineNumber, column.column.Name, err)
+		}
+	}
+	return journey, nil
+}
+
+// selectSchema finds the schema whose columns have the same names as the leaf columns of the file
+func (p *journeyParquetParser) selectSchema(fileSchema *schema.Schema) (*JourneySchema, []*parquetColumn, error) {
+	names := make([]string, fileSchema.NumColumns())
+	for i := range names {
+		leaf := fileSchema.Column(i)
+		if leaf.MaxRepetitionLevel() > 0 {
+			return nil, nil, fmt.Errorf("repeated column %s is not supported", leaf.Path())
+		}
+		names[i] = leaf.Path()
+	}
+
+	for _, journeySchema := range p.schemas {
+		if !journeySchema.MatchHeaders(names) {
+			continue
+		}
+
+		columns := make([]*parquetColumn, len(names))
+		for i, name := range names {
+			column, _ := journeySchema.Column(name)
+			columns[i] = &parquetColumn{
+				column: column,
+				leaf:   fileSchema.Column(i),
+			}
+		}
+		return journeySchema, columns, nil
+	}
+
+	return nil, nil, fmt.Errorf("the columns of the file are uncompatible with all the known schemas")
+}
+
+// openParquetFile opens a Parquet file, reading it in memory if it can't be read at random positions
+func openParquetFile(reader io.Reader) (*file.Reader, error) {
+	if readerAtSeeker, ok := reader.(parquet.ReaderAtSeeker); ok {
+		return file.NewParquetReader(readerAtSeeker)
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return file.NewParquetReader(bytes.NewReader(content))
+}
+
+// readParquetColumn reads the values of a column chunk as the text expected by the columns of the schemas.
+// Null values are read as empty strings.
+//
+// @param chunk - Reader of the column chunk
+// @param column - Column of the file and of the schema
+// @param nbRows - Number of rows of the row group
+func readParquetColumn(chunk file.ColumnChunkReader, column *parquetColumn, nbRows int) ([]string, error) {
+	maxDefinitionLevel := column.leaf.MaxDefinitionLevel()
+	logicalType := column.leaf.LogicalType()
+
+	switch reader := chunk.(type) {
+	case *file.BooleanColumnChunkReader:
+		return readParquetValues(reader.ReadBatch, nbRows, maxDefinitionLevel, func(value bool) string {
+			if value {
+				return domain.BooleanTrue
+			}
+			return domain.BooleanFalse
+		})
+	case *file.Int32ColumnChunkReader:
+		if logicalType.Equals(schema.DateLogicalType{}) {
+			return readParquetValues(reader.ReadBatch, nbRows, maxDefinitionLevel, func(value int32) string {
+				return column.formatTime(time.Unix(int64(value)*24*3600, 0).UTC())
+			})
+		}
+		if _, ok := logicalType.(interface{ TimeUnit() schema.TimeUnitType }); ok {
+			// An INT32 time of day is always in milliseconds
+			return readParquetValues(reader.ReadBatch, nbRows, maxDefinitionLevel, func(value int32) string {
+				return column.formatTime(time.UnixMilli(int64(value)).UTC())
+			})
+		}
+		return readParquetValues(reader.ReadBatch, nbRows, maxDefinitionLevel, func(value int32) string {
+			return strconv.FormatInt(int64(value), 10)
+		})
+	case *file.Int64ColumnChunkReader:
+		if timestamp, ok := logicalType.(interface{ TimeUnit() schema.TimeUnitType }); ok {
+			return readParquetValues(reader.ReadBatch, nbRows, maxDefinitionLevel, func(value int64) string {
+				return column.formatTime(parquetTimestamp(value, timestamp.TimeUnit()))
+			})
+		}
+		return readParquetValues(reader.ReadBatch, nbRows, maxDefinitionLevel, func(value int64) string {
+			return strconv.FormatInt(value, 10)
+		})
+	case *file.Int96ColumnChunkReader:
+		return readParquetValues(reader.ReadBatch, nbRows, maxDefinitionLevel, func(value parquet.Int96) string {
+			return column.formatTime(value.ToTime())
+		})
+	case *file.Float32ColumnChunkReader:
+		return readParquetValues(reader.ReadBatch, nbRows, maxDefinitionLevel, func(value float32) string {
+			return strconv.FormatFloat(float64(value), 'f', -1, 32)
+		})
+	case *file.Float64ColumnChunkReader:
+		return readParquetValues(reader.ReadBatch, nbRows, maxDefinitionLevel, func(value float64) string {
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		})
+	case *file.ByteArrayColumnChunkReader:
+		return readParquetValues(reader.ReadBatch, nbRows, maxDefinitionLevel, func(value parquet.ByteArray) string {
+			return string(value)
+		})
+	case *file.FixedLenByteArrayColumnChunkReader:
+		if logicalType.Equals(schema.UUIDLogicalType{}) {
+			return readParquetValues(reader.ReadBatch, nbRows, maxDefinitionLevel, func(value parquet.FixedLenByteArray) string {
+				id, err := uuid.FromBytes(value)
+				if err != nil {
+					return ""
+				}
+				return id.String()
+			})
+		}
+		return readParquetValues(reader.ReadBatch, nbRows, maxDefinitionLevel, func(value parquet.FixedLenByteArray) string {
+			return string(value)
+		})
+	default:
+		return nil, fmt.Errorf("unsupported column type %s", column.leaf.PhysicalType())
+	}
+}
+
+// readParquetValues reads all the values of a column chunk and converts them to text
+//
+// @param readBatch - ReadBatch method of the typed column chunk reader
+// @param nbRows - Number of rows of the row group
+// @param maxDefinitionLevel - Definition level of the non null values
+// @param text - Conversion of a value to text
+func readParquetValues[T any](readBatch func(int64, []T, []int16, []int16) (int64, int, error), nbRows int, maxDefinitionLevel int16, text func(T) string) ([]string, error) {
+	values := make([]T, nbRows)
+	definitionLevels := make([]int16, nbRows)
+	nbLevels, nbValues := 0, 0
+	for nbLevels < nbRows {
+		total, read, err := readBatch(int64(nbRows-nbLevels), values[nbValues:], definitionLevels[nbLevels:], nil)
+		if err != nil {
+			return nil, err
+		}
+		if total == 0 {
+			break
+		}
+		nbLevels += int(total)
+		nbValues += read
+	}
+
+	texts := make([]string, nbRows)
+	value := 0
+	for i := 0; i < nbLevels; i++ {
+		if maxDefinitionLevel > 0 && definitionLevels[i] < maxDefinitionLevel {
+			continue
+		}
+		texts[i] = text(values[value])
+		value++
+	}
+	return texts, nil
+}
+
+func parquetTimestamp(timestamp int64, unit schema.TimeUnitType) time.Time {
+	switch unit {
+	case schema.TimeUnitMillis:
+		return time.UnixMilli(timestamp).UTC()
+	case schema.TimeUnitMicros:
+		return time.UnixMicro(timestamp).UTC()
+	default:
+		return time.Unix(0, timestamp).UTC()
+	}
+}
+
+// formatTime formats a time with the layout of the column of the schema, RFC 3339 for columns which are not datetimes
+func (column *parquetColumn) formatTime(value time.Time) string {
+	if column.column.Type == ColumnTypeDatetime {
+		return value.Format(column.column.Layout)
+	}
+	return value.Format(time.RFC3339)
+}
+
+// sniffParquet recognizes the magic number of Parquet files
+func sniffParquet(head []byte, schemas []*JourneySchema) bool {
+	return bytes.HasPrefix(head, parquetMagic)
+}
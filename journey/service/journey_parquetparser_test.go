@@ -0,0 +1,166 @@
+package service_test
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/service"
+
+	"github.com/apache/arrow/go/v14/parquet"
+	"github.com/apache/arrow/go/v14/parquet/file"
+	"github.com/apache/arrow/go/v14/parquet/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+type partnerRow struct {
+	Class     string
+	Id        *int64
+	Start     *time.Time
+	Seats     *int32
+	Incentive bool
+}
+
+// writePartnerParquet writes a Parquet file with the columns of the partner schema, one row group per slice of rows
+func writePartnerParquet(t *testing.T, rowGroups ...[]partnerRow) *bytes.Reader {
+	start, _ := schema.NewPrimitiveNodeLogical("start", parquet.Repetitions.Optional, schema.NewTimestampLogicalType(true, schema.TimeUnitMillis), parquet.Types.Int64, -1, -1)
+	class, _ := schema.NewPrimitiveNodeLogical("class", parquet.Repetitions.Required, schema.StringLogicalType{}, parquet.Types.ByteArray, -1, -1)
+	root, err := schema.NewGroupNode("schema", parquet.Repetitions.Required, schema.FieldList{
+		class,
+		schema.NewInt64Node("id", parquet.Repetitions.Optional, -1),
+		start,
+		schema.NewInt32Node("seats", parquet.Repetitions.Optional, -1),
+		schema.NewBooleanNode("incentive", parquet.Repetitions.Required, -1),
+	}, -1)
+	assert.NoError(t, err)
+
+	buffer := &bytes.Buffer{}
+	writer := file.NewParquetWriter(buffer, root)
+	for _, rows := range rowGroups {
+		var classes []parquet.ByteArray
+		var ids, starts []int64
+		var seats []int32
+		var incentives []bool
+		idLevels, startLevels, seatLevels := make([]int16, len(rows)), make([]int16, len(rows)), make([]int16, len(rows))
+		for i, row := range rows {
+			classes = append(classes, parquet.ByteArray(row.Class))
+			incentives = append(incentives, row.Incentive)
+			if row.Id != nil {
+				ids = append(ids, *row.Id)
+				idLevels[i] = 1
+			}
+			if row.Start != nil {
+				starts = append(starts, row.Start.UnixMilli())
+				startLevels[i] = 1
+			}
+			if row.Seats != nil {
+				seats = append(seats, *row.Seats)
+				seatLevels[i] = 1
+			}
+		}
+
+		rowGroup := writer.AppendRowGroup()
+		column, _ := rowGroup.NextColumn()
+		column.(*file.ByteArrayColumnChunkWriter).WriteBatch(classes, nil, nil)
+		column, _ = rowGroup.NextColumn()
+		column.(*file.Int64ColumnChunkWriter).WriteBatch(ids, idLevels, nil)
+		column, _ = rowGroup.NextColumn()
+		column.(*file.Int64ColumnChunkWriter).WriteBatch(starts, startLevels, nil)
+		column, _ = rowGroup.NextColumn()
+		column.(*file.Int32ColumnChunkWriter).WriteBatch(seats, seatLevels, nil)
+		column, _ = rowGroup.NextColumn()
+		column.(*file.BooleanColumnChunkWriter).WriteBatch(incentives, nil, nil)
+		assert.NoError(t, rowGroup.Close())
+	}
+	assert.NoError(t, writer.Close())
+
+	return bytes.NewReader(buffer.Bytes())
+}
+
+func parseAll(parser domain.JourneyParser, reader io.Reader) ([]*domain.Journey, []string) {
+	journeyChan := make(chan *domain.Journey)
+	errorChan := make(chan string)
+	parser.Parse(reader, journeyChan, errorChan)
+
+	var errors []string
+	errorsDone := make(chan bool)
+	go func() {
+		for e := range errorChan {
+			errors = append(errors, e)
+		}
+		errorsDone <- true
+	}()
+
+	var journeys []*domain.Journey
+	for journey := range journeyChan {
+		journeys = append(journeys, journey)
+	}
+	<-errorsDone
+
+	sort.Slice(journeys, func(i, j int) bool { return journeys[i].JourneyId < journeys[j].JourneyId })
+	sort.Strings(errors)
+	return journeys, errors
+}
+
+func TestParseParquet(t *testing.T) {
+	cfg := *config
+	cfg.Journey.Parser.SchemaDirectory = filepath.Join("testdata", "schemas")
+	schemas, err := service.LoadJourneySchemas(&logger, &cfg)
+	assert.NoError(t, err)
+
+	id := func(id int64) *int64 { return &id }
+	seats := int32(2)
+	start := time.Date(2023, 2, 1, 8, 30, 0, 0, time.UTC)
+	reader := writePartnerParquet(t, []partnerRow{
+		{Class: "b", Id: id(1), Start: &start, Seats: &seats, Incentive: true},
+		{Class: "C", Id: id(2)},
+	}, []partnerRow{
+		{Class: "D", Id: id(3), Start: &start},
+		{Class: "A", Start: &start},
+	})
+
+	journeys, errors := parseAll(service.NewJourneyParquetParser(&logger, &cfg, schemas), reader)
+
+	assert.Equal(t, []*domain.Journey{
+		{
+			JourneyId:            1,
+			JourneyStartDatetime: start,
+			OperatorClass:        "B",
+			PassengerSeats:       2,
+			HasIncentive:         true,
+		},
+		{
+			JourneyId:     2,
+			OperatorClass: "C",
+		},
+	}, journeys)
+	assert.Len(t, errors, 2)
+	assert.Contains(t, errors[0], "line 3, field class")
+	assert.Contains(t, errors[1], "line 4, field id")
+}
+
+func TestParseParquet_unknownLayout(t *testing.T) {
+	schemas, err := service.LoadJourneySchemas(&logger, config)
+	assert.NoError(t, err)
+
+	// Without the custom schema directory, the partner layout is unknown
+	reader := writePartnerParquet(t, []partnerRow{{Class: "A"}})
+	journeys, errors := parseAll(service.NewJourneyParquetParser(&logger, config, schemas), reader)
+
+	assert.Empty(t, journeys)
+	assert.Len(t, errors, 1)
+}
+
+func TestParseParquet_notParquet(t *testing.T) {
+	schemas, err := service.LoadJourneySchemas(&logger, config)
+	assert.NoError(t, err)
+
+	journeys, errors := parseAll(service.NewJourneyParquetParser(&logger, config, schemas), bytes.NewReader([]byte("journey_id;trip_id\n")))
+
+	assert.Empty(t, journeys)
+	assert.Len(t, errors, 1)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.uber.org/zap"
+)
+
+// Number of bytes read at the beginning of a file to recognize its format
+const journeyFileHeadSize = 4096
+
+// JourneyParserFactory creates a parser from the configuration and the known schemas
+type JourneyParserFactory func(logger *zap.SugaredLogger, cfg *configuration.Config, schemas []*JourneySchema) domain.JourneyParser
+
+// JourneyParserFormat describes how to recognize a file format and how to create its parser
+type JourneyParserFormat struct {
+	Name         string
+	Extensions   []string
+	ContentTypes []string
+	// Sniff recognizes the format from the first bytes of a file. Can be nil
+	Sniff   func(head []byte, schemas []*JourneySchema) bool
+	Factory JourneyParserFactory
+}
+
+var journeyParserFormats []JourneyParserFormat
+
+// RegisterJourneyParser makes a file format available for the imports.
+//
+// @param format - Description of the format. Its name must be unique
+func RegisterJourneyParser(format JourneyParserFormat) {
+	for _, registered := range journeyParserFormats {
+		if registered.Name == format.Name {
+			panic(fmt.Sprintf("journey parser %s is already registered", format.Name))
+		}
+	}
+	journeyParserFormats = append(journeyParserFormats, format)
+}
+
+type journeyParser struct {
+	format JourneyParserFormat
+	parser domain.JourneyParser
+}
+
+type journeyParserRegistry struct {
+	logger  *zap.SugaredLogger
+	schemas []*JourneySchema
+	parsers []journeyParser
+}
+
+// NewJourneyParserRegistry creates a parser for each registered format.
+//
+// @param logger - Logger to use. Must not be nil.
+// @param cfg - Configuration given to the parsers
+// @param schemas - the known layouts of the files, see LoadJourneySchemas
+func NewJourneyParserRegistry(logger *zap.SugaredLogger, cfg *configuration.Config, schemas []*JourneySchema) domain.JourneyParserRegistry {
+	registry := &journeyParserRegistry{
+		logger:  logger,
+		schemas: schemas,
+	}
+	for _, format := range journeyParserFormats {
+		registry.parsers = append(registry.parsers, journeyParser{
+			format: format,
+			parser: format.Factory(logger, cfg, schemas),
+		})
+	}
+
+	logger.Infow("Journey parsers registered",
+		"formats", registry.formats(),
+	)
+	return registry
+}
+
+// Select returns the format and the parser of a file.
+// The first bytes of the file are checked first, then its content type, then the extension of its name.
+//
+// @param file - File to import. Its reader is replaced if it can't be rewound after reading its first bytes
+func (r *journeyParserRegistry) Select(file *domain.JourneyFile) (string, domain.JourneyParser, error) {
+	head, err := readJourneyFileHead(file)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, p := range r.parsers {
+		if p.format.Sniff != nil && p.format.Sniff(head, r.schemas) {
+			return p.format.Name, p.parser, nil
+		}
+	}
+
+	contentType, _, _ := mime.ParseMediaType(file.ContentType)
+	for _, p := range r.parsers {
+		if domain.Contains(p.format.ContentTypes, contentType) {
+			return p.format.Name, p.parser, nil
+		}
+	}
+
+	extension := strings.ToLower(filepath.Ext(file.Name))
+	for _, p := range r.parsers {
+		if extension != "" && domain.Contains(p.format.Extensions, extension) {
+			return p.format.Name, p.parser, nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("unsupported format for file %s (content type: %s, available formats: %s)", file.Name, file.ContentType, strings.Join(r.formats(), ", "))
+}
+
+func (r *journeyParserRegistry) formats() []string {
+	names := make([]string, 0, len(r.parsers))
+	for _, p := range r.parsers {
+		names = append(names, p.format.Name)
+	}
+	return names
+}
+
+// readJourneyFileHead reads the first bytes of a file, rewinding it when possible
+func readJourneyFileHead(file *domain.JourneyFile) ([]byte, error) {
+	if seeker, ok := file.Reader.(io.ReadSeeker); ok {
+		head := make([]byte, journeyFileHeadSize)
+		n, err := io.ReadFull(seeker, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return head[:n], nil
+	}
+
+	bufReader := bufio.NewReaderSize(file.Reader, journeyFileHeadSize)
+	file.Reader = bufReader
+	head, err := bufReader.Peek(journeyFileHeadSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	return head, nil
+}
+
+// trimJourneyFileHead removes the byte order mark and the leading blanks of the first bytes of a file
+func trimJourneyFileHead(head []byte) []byte {
+	return bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\ufeff")), " \t\r\n")
+}
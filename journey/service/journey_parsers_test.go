@@ -0,0 +1,93 @@
+package service_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJourneyParserRegistry_Select(t *testing.T) {
+	schemas, err := service.LoadJourneySchemas(&logger, config)
+	assert.NoError(t, err)
+	registry := service.NewJourneyParserRegistry(&logger, config, schemas)
+
+	csvHeaders, err := os.ReadFile(filepath.Join("..", "usecase", "testdata", "dataset_headersOnly.csv"))
+	assert.NoError(t, err)
+	parquetFile := writePartnerParquet(t, []partnerRow{{Class: "A"}})
+
+	var tests = []struct {
+		name           string
+		file           domain.JourneyFile
+		expectedFormat string
+	}{
+		{"csv_headers", domain.JourneyFile{Name: "journeys.json", Reader: strings.NewReader(string(csvHeaders))}, domain.JourneyFormatCSV},
+		{"json_object", domain.JourneyFile{Name: "journeys.csv", Reader: strings.NewReader("\ufeff  {\"journey_id\": 1}")}, domain.JourneyFormatJSON},
+		{"json_array", domain.JourneyFile{Name: "journeys", Reader: strings.NewReader("\n[]")}, domain.JourneyFormatJSON},
+		{"parquet_magic", domain.JourneyFile{Name: "journeys.csv", Reader: parquetFile}, domain.JourneyFormatParquet},
+		{"content_type", domain.JourneyFile{Name: "journeys.json", ContentType: "text/csv; charset=utf-8", Reader: strings.NewReader("1;2")}, domain.JourneyFormatCSV},
+		{"extension", domain.JourneyFile{Name: "journeys.PARQUET", ContentType: "application/octet-stream", Reader: strings.NewReader("")}, domain.JourneyFormatParquet},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := test.file
+			format, parser, err := registry.Select(&file)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedFormat, format)
+			assert.NotNil(t, parser)
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, _, err := registry.Select(&domain.JourneyFile{Name: "journeys.xlsx", Reader: strings.NewReader("PK")})
+		assert.ErrorContains(t, err, "unsupported format for file journeys.xlsx")
+	})
+
+	t.Run("not_seekable", func(t *testing.T) {
+		reader, writer := io.Pipe()
+		go func() {
+			writer.Write([]byte("{\"journey_id\": 1}"))
+			writer.Close()
+		}()
+
+		file := &domain.JourneyFile{Name: "journeys", Reader: reader}
+		format, _, err := registry.Select(file)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.JourneyFormatJSON, format)
+
+		content, _ := io.ReadAll(file.Reader)
+		assert.Equal(t, "{\"journey_id\": 1}", string(content))
+	})
+}
+
+func TestRegisterJourneyParser_duplicate(t *testing.T) {
+	assert.Panics(t, func() {
+		service.RegisterJourneyParser(service.JourneyParserFormat{Name: domain.JourneyFormatCSV})
+	})
+}
+
+func TestJourneyExporterRegistry_Get(t *testing.T) {
+	schemas, err := service.LoadJourneySchemas(&logger, config)
+	assert.NoError(t, err)
+	registry := service.NewJourneyExporterRegistry(&logger, config, schemas)
+
+	exporter, err := registry.Get(domain.JourneyFormatParquet)
+	assert.NoError(t, err)
+	assert.Equal(t, "application/vnd.apache.parquet", exporter.ContentType())
+
+	_, err = registry.Get("xlsx")
+	assert.ErrorContains(t, err, "unsupported export format 'xlsx'")
+}
+
+func TestRegisterJourneyExporter_duplicate(t *testing.T) {
+	assert.Panics(t, func() {
+		service.RegisterJourneyExporter(domain.JourneyFormatParquet, service.NewJourneyParquetExporter)
+	})
+}
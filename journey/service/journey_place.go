@@ -0,0 +1,54 @@
+package service
+
+import "github.com/coutcout/covoiturage-csvreader/domain"
+
+// journeyPlace gives access to the fields describing the start or the end of a journey
+type journeyPlace struct {
+	name       string
+	lon        *float64
+	lat        *float64
+	insee      *int64
+	postalcode *string
+	department *string
+	town       *string
+	region     *string
+	epci       *string
+	population *int64
+}
+
+// journeyPlaces returns the start and the end of a journey
+//
+// @param journey - Journey whose places are returned
+func journeyPlaces(journey *domain.Journey) []journeyPlace {
+	return []journeyPlace{
+		{
+			name:       "start",
+			lon:        &journey.JourneyStartLon,
+			lat:        &journey.JourneyStartLat,
+			insee:      &journey.JourneyStartInsee,
+			postalcode: &journey.JourneyStartPostalcode,
+			department: &journey.JourneyStartDepartment,
+			town:       &journey.JourneyStartTown,
+			region:     &journey.JourneyStartRegion,
+			epci:       &journey.JourneyStartEpci,
+			population: &journey.JourneyStartPopulation,
+		},
+		{
+			name:       "end",
+			lon:        &journey.JourneyEndLon,
+			lat:        &journey.JourneyEndLat,
+			insee:      &journey.JourneyEndInsee,
+			postalcode: &journey.JourneyEndPostalcode,
+			department: &journey.JourneyEndDepartment,
+			town:       &journey.JourneyEndTown,
+			region:     &journey.JourneyEndRegion,
+			epci:       &journey.JourneyEndEpci,
+			population: &journey.JourneyEndPopulation,
+		},
+	}
+}
+
+// flag adds a flag on the journey, prefixed by the name of the place
+func (p journeyPlace) flag(journey *domain.Journey, flag string) {
+	journey.Flags = append(journey.Flags, p.name+"_"+flag)
+}
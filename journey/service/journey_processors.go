@@ -0,0 +1,65 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.uber.org/zap"
+)
+
+// JourneyProcessorFactory creates a processing step from the configuration
+type JourneyProcessorFactory func(logger *zap.SugaredLogger, cfg *configuration.Config) (domain.JourneyProcessor, error)
+
+var journeyProcessorFactories = map[string]JourneyProcessorFactory{}
+
+// RegisterJourneyProcessor makes a processing step available in the configuration.
+//
+// @param name - Name of the step in the configuration. Must be unique
+// @param factory - Factory creating the step
+func RegisterJourneyProcessor(name string, factory JourneyProcessorFactory) {
+	if _, ok := journeyProcessorFactories[name]; ok {
+		panic(fmt.Sprintf("journey processor %s is already registered", name))
+	}
+	journeyProcessorFactories[name] = factory
+}
+
+// NewJourneyProcessorChain creates the processing chain from the steps listed in the configuration.
+//
+// @param logger - Logger to use. Must not be nil.
+// @param cfg - Configuration giving the names of the steps, in order
+func NewJourneyProcessorChain(logger *zap.SugaredLogger, cfg *configuration.Config) (*domain.JourneyProcessorChain, error) {
+	var steps []domain.NamedJourneyProcessor
+	for _, name := range cfg.Journey.Processing.Steps {
+		factory, ok := journeyProcessorFactories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown journey processor %s (available: %s)", name, strings.Join(registeredJourneyProcessors(), ", "))
+		}
+
+		processor, err := factory(logger, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("problem while creating journey processor %s: %w", name, err)
+		}
+		steps = append(steps, domain.NamedJourneyProcessor{
+			Name:      name,
+			Processor: processor,
+		})
+	}
+
+	logger.Infow("Journey processing chain created",
+		"steps", cfg.Journey.Processing.Steps,
+	)
+	return domain.NewJourneyProcessorChain(steps...), nil
+}
+
+func registeredJourneyProcessors() []string {
+	names := make([]string, 0, len(journeyProcessorFactories))
+	for name := range journeyProcessorFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,40 @@
+package service_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/journey/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJourneyProcessorChain(t *testing.T) {
+	t.Run("configured_steps", func(t *testing.T) {
+		cfg := *config
+		cfg.Journey.Processing.Steps = []string{"reverse-geocoding", "insee"}
+		cfg.Journey.Referential.Boundaries.File = filepath.Join("testdata", "communes.geojson")
+		cfg.Journey.Referential.Insee.File = filepath.Join("testdata", "communes.csv")
+
+		chain, err := service.NewJourneyProcessorChain(&logger, &cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"reverse-geocoding", "insee"}, chain.Names())
+	})
+
+	t.Run("unknown_step", func(t *testing.T) {
+		cfg := *config
+		cfg.Journey.Processing.Steps = []string{"unknown"}
+
+		_, err := service.NewJourneyProcessorChain(&logger, &cfg)
+		assert.ErrorContains(t, err, "unknown journey processor unknown")
+	})
+
+	t.Run("failing_step", func(t *testing.T) {
+		cfg := *config
+		cfg.Journey.Processing.Steps = []string{"insee"}
+		cfg.Journey.Referential.Insee.File = filepath.Join("testdata", "unknown.csv")
+
+		_, err := service.NewJourneyProcessorChain(&logger, &cfg)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed schemas/*.yaml
+var builtinSchemas embed.FS
+
+// Types of the columns of a schema
+const (
+	ColumnTypeString   = "string"
+	ColumnTypeInt      = "int"
+	ColumnTypeFloat    = "float"
+	ColumnTypeUUID     = "uuid"
+	ColumnTypeDatetime = "datetime"
+	ColumnTypeBoolean  = "boolean"
+	ColumnTypeEnum     = "enum"
+)
+
+// JourneySchema describes a layout of journey files: its separator and its columns, in order
+type JourneySchema struct {
+	Name      string          `yaml:"name"`
+	Separator string          `yaml:"separator"`
+	Columns   []*SchemaColumn `yaml:"columns"`
+}
+
+// SchemaColumn describes a column of a journey file and the Journey field it is stored into
+type SchemaColumn struct {
+	Name        string   `yaml:"name"`
+	Field       string   `yaml:"field"`
+	Type        string   `yaml:"type"`
+	Layout      string   `yaml:"layout"`
+	TrueTokens  []string `yaml:"true-tokens"`
+	FalseTokens []string `yaml:"false-tokens"`
+	Values      []string `yaml:"values"`
+	Required    bool     `yaml:"required"`
+
+	enum  *domain.Enum
+	index []int
+}
+
+// LoadJourneySchemas loads the built-in schemas and the ones of the schema directory of the configuration.
+// A schema of the directory replaces the built-in schema with the same name.
+//
+// @param logger - Logger to use. Must not be nil.
+// @param cfg - Configuration giving the schema directory. The directory is optional
+func LoadJourneySchemas(logger *zap.SugaredLogger, cfg *configuration.Config) ([]*JourneySchema, error) {
+	schemas := map[string]*JourneySchema{}
+
+	builtinFiles, _ := fs.Glob(builtinSchemas, "schemas/*.yaml")
+	for _, file := range builtinFiles {
+		content, err := builtinSchemas.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := ReadJourneySchema(content)
+		if err != nil {
+			return nil, fmt.Errorf("problem while loading schema %s: %w", file, err)
+		}
+		schemas[schema.Name] = schema
+	}
+
+	if directory := cfg.Journey.Parser.SchemaDirectory; directory != "" {
+		files, err := filepath.Glob(filepath.Join(directory, "*.yaml"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			schema, err := ReadJourneySchema(content)
+			if err != nil {
+				return nil, fmt.Errorf("problem while loading schema %s: %w", file, err)
+			}
+			schemas[schema.Name] = schema
+		}
+	}
+
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]*JourneySchema, 0, len(schemas))
+	for _, name := range names {
+		result = append(result, schemas[name])
+	}
+
+	logger.Infow("Journey schemas loaded",
+		"schemas", names,
+	)
+	return result, nil
+}
+
+// ReadJourneySchema reads and checks a YAML schema definition.
+//
+// @param content - YAML content of the schema
+func ReadJourneySchema(content []byte) (*JourneySchema, error) {
+	schema := &JourneySchema{}
+	if err := yaml.Unmarshal(content, schema); err != nil {
+		return nil, err
+	}
+
+	if schema.Name == "" {
+		return nil, fmt.Errorf("the schema has no name")
+	}
+	if schema.Separator == "" {
+		schema.Separator = ";"
+	}
+	if len([]rune(schema.Separator)) != 1 {
+		return nil, fmt.Errorf("the separator of schema %s must be a single character", schema.Name)
+	}
+	if len(schema.Columns) == 0 {
+		return nil, fmt.Errorf("schema %s has no columns", schema.Name)
+	}
+
+	journeyType := reflect.TypeOf(domain.Journey{})
+	for _, column := range schema.Columns {
+		if err := column.compile(journeyType); err != nil {
+			return nil, fmt.Errorf("schema %s, column %s: %w", schema.Name, column.Name, err)
+		}
+	}
+
+	return schema, nil
+}
+
+// SeparatorRune returns the separator of the columns
+func (s *JourneySchema) SeparatorRune() rune {
+	return []rune(s.Separator)[0]
+}
+
+// Column returns the column with the given name
+//
+// @param name - Name of the column, case insensitive
+func (s *JourneySchema) Column(name string) (*SchemaColumn, bool) {
+	for _, column := range s.Columns {
+		if strings.EqualFold(column.Name, name) {
+			return column, true
+		}
+	}
+	return nil, false
+}
+
+// MatchHeaders checks if the headers of a file are the columns of the schema, in any order
+//
+// @param headers - Headers of the file
+func (s *JourneySchema) MatchHeaders(headers []string) bool {
+	if len(headers) != len(s.Columns) {
+		return false
+	}
+	for _, header := range headers {
+		if _, ok := s.Column(strings.TrimSpace(header)); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectJourneySchema finds the schema of a file from its first line.
+// Schemas whose columns have the same names as the headers are preferred. Otherwise, the first schema with the same number of columns is used, its columns being read in order.
+//
+// @param schemas - Known schemas
+// @param firstLine - First line of the file
+//
+// @return the schema and the order of its columns in the file, nil if no schema matches
+func SelectJourneySchema(schemas []*JourneySchema, firstLine string) (*JourneySchema, []*SchemaColumn) {
+	firstLine = strings.TrimPrefix(strings.TrimRight(firstLine, "\r\n"), "\ufeff")
+	for _, schema := range schemas {
+		headers := strings.Split(firstLine, schema.Separator)
+		if schema.MatchHeaders(headers) {
+			columns := make([]*SchemaColumn, len(headers))
+			for i, header := range headers {
+				columns[i], _ = schema.Column(strings.TrimSpace(header))
+			}
+			return schema, columns
+		}
+	}
+
+	for _, schema := range schemas {
+		if len(strings.Split(firstLine, schema.Separator)) == len(schema.Columns) {
+			return schema, schema.Columns
+		}
+	}
+
+	return nil, nil
+}
+
+// Set converts a value of the column and stores it into the journey.
+// An empty value leaves the field unset, unless the column is required.
+// Values of optional columns which can't be converted are ignored, except enumerations and booleans which must hold a known value.
+//
+// @param journey - Journey to fill
+// @param raw - Value read from the file
+func (column *SchemaColumn) Set(journey *domain.Journey, raw string) error {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		if column.Required {
+			return fmt.Errorf("value is required")
+		}
+		return nil
+	}
+
+	value, err := column.convert(raw)
+	if err != nil {
+		if column.Required || column.Type == ColumnTypeEnum || column.Type == ColumnTypeBoolean {
+			return err
+		}
+		return nil
+	}
+
+	field := reflect.ValueOf(journey).Elem().FieldByIndex(column.index)
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number := value.(int64)
+		if field.OverflowInt(number) {
+			if column.Required {
+				return fmt.Errorf("value %d is out of range", number)
+			}
+			return nil
+		}
+		field.SetInt(number)
+	default:
+		field.Set(reflect.ValueOf(value))
+	}
+	return nil
+}
+
+// Format reads the field of the column from the journey and writes it as in the files of the schema, so that Set reads it back.
+// Unset datetimes are empty values and booleans are written with their first token.
+//
+// @param journey - Journey to read
+func (column *SchemaColumn) Format(journey *domain.Journey) string {
+	field := reflect.ValueOf(journey).Elem().FieldByIndex(column.index)
+	switch column.Type {
+	case ColumnTypeInt:
+		return strconv.FormatInt(field.Int(), 10)
+	case ColumnTypeFloat:
+		return strconv.FormatFloat(field.Float(), 'f', -1, 64)
+	case ColumnTypeUUID:
+		return field.Interface().(uuid.UUID).String()
+	case ColumnTypeDatetime:
+		value := field.Interface().(time.Time)
+		if value.IsZero() {
+			return ""
+		}
+		return value.Format(column.Layout)
+	case ColumnTypeBoolean:
+		if field.Bool() {
+			return column.booleanToken(domain.BooleanTrue, column.TrueTokens)
+		}
+		return column.booleanToken(domain.BooleanFalse, column.FalseTokens)
+	default:
+		return field.String()
+	}
+}
+
+func (column *SchemaColumn) booleanToken(value string, tokens []string) string {
+	if len(tokens) > 0 {
+		return tokens[0]
+	}
+	return value
+}
+
+func (column *SchemaColumn) convert(raw string) (interface{}, error) {
+	switch column.Type {
+	case ColumnTypeInt:
+		return strconv.ParseInt(raw, 10, 64)
+	case ColumnTypeFloat:
+		return strconv.ParseFloat(raw, 64)
+	case ColumnTypeUUID:
+		return uuid.Parse(raw)
+	case ColumnTypeDatetime:
+		return time.Parse(column.Layout, raw)
+	case ColumnTypeBoolean:
+		return column.enum.ParseBool(raw)
+	case ColumnTypeEnum:
+		return column.enum.Parse(raw)
+	default:
+		return raw, nil
+	}
+}
+
+// compile checks the column against the Journey type and prepares its conversion
+func (column *SchemaColumn) compile(journeyType reflect.Type) error {
+	if column.Name == "" {
+		return fmt.Errorf("the column has no name")
+	}
+	if column.Type == "" {
+		column.Type = ColumnTypeString
+	}
+
+	field, ok := journeyType.FieldByName(column.Field)
+	if !ok {
+		return fmt.Errorf("unknown journey field '%s'", column.Field)
+	}
+	column.index = field.Index
+
+	var expectedKinds []reflect.Kind
+	var expectedType reflect.Type
+	switch column.Type {
+	case ColumnTypeString, ColumnTypeEnum:
+		expectedKinds = []reflect.Kind{reflect.String}
+	case ColumnTypeInt:
+		expectedKinds = []reflect.Kind{reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64}
+	case ColumnTypeFloat:
+		expectedKinds = []reflect.Kind{reflect.Float64}
+	case ColumnTypeBoolean:
+		expectedKinds = []reflect.Kind{reflect.Bool}
+	case ColumnTypeUUID:
+		expectedType = reflect.TypeOf(uuid.UUID{})
+	case ColumnTypeDatetime:
+		expectedType = reflect.TypeOf(time.Time{})
+		if column.Layout == "" {
+			column.Layout = time.RFC3339
+		}
+	default:
+		return fmt.Errorf("unknown type '%s'", column.Type)
+	}
+
+	if expectedType != nil && field.Type != expectedType {
+		return fmt.Errorf("type %s can't be stored into field %s of type %s", column.Type, column.Field, field.Type)
+	}
+	if expectedKinds != nil && !containsKind(expectedKinds, field.Type.Kind()) {
+		return fmt.Errorf("type %s can't be stored into field %s of type %s", column.Type, column.Field, field.Type)
+	}
+
+	switch column.Type {
+	case ColumnTypeBoolean:
+		if len(column.TrueTokens) == 0 && len(column.FalseTokens) == 0 {
+			column.enum = domain.BooleanEnum
+		} else if len(column.TrueTokens) == 0 || len(column.FalseTokens) == 0 {
+			return fmt.Errorf("both true and false tokens are required")
+		} else {
+			column.enum = domain.NewEnum(column.Name, map[string][]string{
+				domain.BooleanTrue:  column.TrueTokens,
+				domain.BooleanFalse: column.FalseTokens,
+			})
+		}
+	case ColumnTypeEnum:
+		if len(column.Values) == 0 {
+			return fmt.Errorf("an enumeration needs values")
+		}
+		values := map[string][]string{}
+		for _, value := range column.Values {
+			values[value] = nil
+		}
+		column.enum = domain.NewEnum(column.Name, values)
+	}
+
+	return nil
+}
+
+func containsKind(kinds []reflect.Kind, kind reflect.Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadJourneySchemas(t *testing.T) {
+	cfg := *config
+	cfg.Journey.Parser.SchemaDirectory = filepath.Join("testdata", "schemas")
+
+	schemas, err := service.LoadJourneySchemas(&logger, &cfg)
+	assert.NoError(t, err)
+
+	var names []string
+	for _, schema := range schemas {
+		names = append(names, schema.Name)
+	}
+	assert.Equal(t, []string{"journey-27", "journey-29", "partner"}, names)
+}
+
+func TestReadJourneySchema(t *testing.T) {
+	var tests = []struct {
+		name    string
+		content string
+	}{
+		{"no_name", "columns: [{name: id, field: JourneyId, type: int}]"},
+		{"no_columns", "name: test"},
+		{"unknown_field", "name: test\ncolumns: [{name: id, field: Unknown, type: int}]"},
+		{"unknown_type", "name: test\ncolumns: [{name: id, field: JourneyId, type: decimal}]"},
+		{"wrong_type", "name: test\ncolumns: [{name: id, field: JourneyId, type: uuid}]"},
+		{"enum_without_values", "name: test\ncolumns: [{name: class, field: OperatorClass, type: enum}]"},
+		{"missing_false_tokens", "name: test\ncolumns: [{name: incentive, field: HasIncentive, type: boolean, true-tokens: [yes]}]"},
+		{"wrong_separator", "name: test\nseparator: '||'\ncolumns: [{name: id, field: JourneyId, type: int}]"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := service.ReadJourneySchema([]byte(test.content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseWithCustomSchema(t *testing.T) {
+	cfg := *config
+	cfg.Journey.Parser.SchemaDirectory = filepath.Join("testdata", "schemas")
+	schemas, err := service.LoadJourneySchemas(&logger, &cfg)
+	assert.NoError(t, err)
+
+	f, _ := os.Open(filepath.Join("testdata", "dataset_partner.csv"))
+	defer f.Close()
+
+	journeyChan := make(chan *domain.Journey)
+	errorChan := make(chan string)
+	service.NewJourneyCsvParser(&logger, &cfg, schemas).Parse(f, journeyChan, errorChan)
+
+	var errors []string
+	errorsDone := make(chan bool)
+	go func() {
+		for e := range errorChan {
+			errors = append(errors, e)
+		}
+		errorsDone <- true
+	}()
+
+	var journeys []*domain.Journey
+	for journey := range journeyChan {
+		journeys = append(journeys, journey)
+	}
+	<-errorsDone
+
+	sort.Slice(journeys, func(i, j int) bool { return journeys[i].JourneyId < journeys[j].JourneyId })
+	assert.Equal(t, []*domain.Journey{
+		{
+			JourneyId:            1,
+			JourneyStartDatetime: time.Date(2023, 2, 1, 8, 30, 0, 0, time.UTC),
+			OperatorClass:        "B",
+			PassengerSeats:       2,
+			HasIncentive:         true,
+		},
+		{
+			JourneyId:     2,
+			OperatorClass: "C",
+		},
+	}, journeys)
+
+	sort.Strings(errors)
+	assert.Len(t, errors, 3)
+	assert.Contains(t, errors[0], "line 3, field class")
+	assert.Contains(t, errors[1], "line 4, field id")
+	assert.Contains(t, errors[2], "line 5, field incentive")
+}
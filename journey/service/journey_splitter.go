@@ -0,0 +1,221 @@
+package service
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.uber.org/zap"
+)
+
+// Number of journeys waiting to be written into each part of a split
+const splitPartBufferSize = 100
+
+// Name of the file of a split archive listing the lines which couldn't be read
+const splitErrorsFilename = "errors.txt"
+
+var unsafeFilenameCharacters = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+type journeySplitter struct {
+	logger  *zap.SugaredLogger
+	parsers domain.JourneyParserRegistry
+}
+
+// splitPart is a part of a split being written into a temporary file
+type splitPart struct {
+	key         string
+	file        *os.File
+	journeyChan chan *domain.Journey
+	nbJourneys  int64
+	done        chan error
+}
+
+// NewJourneySplitter returns a splitter reading the files with the parser of their format.
+// The parts are written into temporary files while the file is read, then gathered into the archive.
+//
+// @param logger - Logger to use. Must not be nil.
+// @param parsers - Registry of the journey parsers. Must not be nil
+func NewJourneySplitter(logger *zap.SugaredLogger, parsers domain.JourneyParserRegistry) domain.JourneySplitter {
+	return &journeySplitter{
+		logger:  logger,
+		parsers: parsers,
+	}
+}
+
+// Split reads the file once and writes a zip archive holding a file per value of the dimension.
+// The lines which couldn't be read are listed in an errors.txt file of the archive.
+//
+// @param file - File to split
+// @param dimension - Dimension of the split, one of the domain.JourneySplit constants
+// @param exporter - Exporter writing the parts
+// @param writer - Destination of the zip archive
+func (s *journeySplitter) Split(file *domain.JourneyFile, dimension string, exporter domain.JourneyExporter, writer io.Writer) (*domain.SplitSummary, error) {
+	if err := domain.ValidateJourneySplitDimension(dimension); err != nil {
+		return nil, err
+	}
+	format, parser, err := s.parsers.Select(file)
+	if err != nil {
+		return nil, err
+	}
+
+	tempDir, err := os.MkdirTemp("", "journey-split-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	summary := &domain.SplitSummary{
+		Format: format,
+		Errors: []string{},
+	}
+	journeyChan := make(chan *domain.Journey)
+	errorChan := make(chan string)
+	parser.Parse(file.Reader, journeyChan, errorChan)
+
+	errorsDone := make(chan bool)
+	go func() {
+		for e := range errorChan {
+			summary.Errors = append(summary.Errors, e)
+		}
+		errorsDone <- true
+	}()
+
+	parts := map[string]*splitPart{}
+	defer func() {
+		for _, part := range parts {
+			part.file.Close()
+		}
+	}()
+
+	var partErr error
+	for journey := range journeyChan {
+		if partErr != nil {
+			// The parser must be drained to end
+			continue
+		}
+
+		key, _ := domain.JourneySplitKey(dimension, journey)
+		part, ok := parts[key]
+		if !ok {
+			if part, partErr = s.newPart(tempDir, key, exporter); partErr != nil {
+				continue
+			}
+			parts[key] = part
+		}
+		part.journeyChan <- journey
+		part.nbJourneys++
+		summary.NbJourneys++
+	}
+	<-errorsDone
+
+	for _, part := range parts {
+		close(part.journeyChan)
+	}
+	for _, part := range parts {
+		if err := <-part.done; err != nil && partErr == nil {
+			partErr = err
+		}
+	}
+	if partErr != nil {
+		return nil, partErr
+	}
+	if summary.NbJourneys == 0 && len(summary.Errors) > 0 {
+		return summary, fmt.Errorf("no journey can be read from file %s: %s", file.Name, summary.Errors[0])
+	}
+
+	keys := make([]string, 0, len(parts))
+	for key := range parts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		summary.Parts = append(summary.Parts, domain.SplitPart{
+			Key:        key,
+			Filename:   splitPartFilename(dimension, key, exporter),
+			NbJourneys: parts[key].nbJourneys,
+		})
+	}
+
+	if err := s.writeArchive(writer, summary, parts); err != nil {
+		return summary, err
+	}
+
+	s.logger.Infow("File split",
+		"filename", file.Name,
+		"dimension", dimension,
+		"nbJourneys", summary.NbJourneys,
+		"nbParts", len(summary.Parts),
+		"nbErrors", len(summary.Errors),
+	)
+	return summary, nil
+}
+
+// newPart creates the temporary file of a part and starts its exporter
+func (s *journeySplitter) newPart(tempDir string, key string, exporter domain.JourneyExporter) (*splitPart, error) {
+	file, err := os.CreateTemp(tempDir, "part-*")
+	if err != nil {
+		return nil, err
+	}
+
+	part := &splitPart{
+		key:         key,
+		file:        file,
+		journeyChan: make(chan *domain.Journey, splitPartBufferSize),
+		done:        make(chan error, 1),
+	}
+	go func() {
+		_, err := exporter.Export(part.file, part.journeyChan)
+		for range part.journeyChan {
+			// The journeys sent after a failure are dropped
+		}
+		part.done <- err
+	}()
+
+	s.logger.Debugw("Split part created",
+		"key", key,
+	)
+	return part, nil
+}
+
+// writeArchive gathers the parts, in the order of the summary, and the errors into a zip archive
+func (s *journeySplitter) writeArchive(writer io.Writer, summary *domain.SplitSummary, parts map[string]*splitPart) error {
+	archive := zip.NewWriter(writer)
+	modified := time.Now()
+	for _, summaryPart := range summary.Parts {
+		part := parts[summaryPart.Key]
+		if _, err := part.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		entry, err := archive.CreateHeader(&zip.FileHeader{Name: summaryPart.Filename, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(entry, part.file); err != nil {
+			return err
+		}
+	}
+
+	if len(summary.Errors) > 0 {
+		entry, err := archive.CreateHeader(&zip.FileHeader{Name: splitErrorsFilename, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(entry, strings.Join(summary.Errors, "\n")+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// splitPartFilename names the file of a part after the dimension and its key
+func splitPartFilename(dimension string, key string, exporter domain.JourneyExporter) string {
+	return "journeys-" + dimension + "-" + unsafeFilenameCharacters.ReplaceAllString(key, "_") + exporter.Extension()
+}
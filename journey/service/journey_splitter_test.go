@@ -0,0 +1,88 @@
+package service_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	schemas, err := service.LoadJourneySchemas(&logger, config)
+	assert.NoError(t, err)
+	parsers := service.NewJourneyParserRegistry(&logger, config, schemas)
+	exporter, err := service.NewJourneyExporterRegistry(&logger, config, schemas).Get(domain.JourneyFormatCSV)
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join("..", "usecase", "testdata", "dataset_1.csv"))
+	assert.NoError(t, err)
+	// A line which can't be read is reported in the archive
+	content = append(content, []byte("\nwrong line\n")...)
+
+	buffer := &bytes.Buffer{}
+	summary, err := service.NewJourneySplitter(&logger, parsers).Split(&domain.JourneyFile{
+		Name:   "dataset_1.csv",
+		Reader: bytes.NewReader(content),
+	}, domain.JourneySplitOperatorClass, exporter, buffer)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.JourneyFormatCSV, summary.Format)
+	assert.Equal(t, int64(3), summary.NbJourneys)
+	assert.Equal(t, []domain.SplitPart{
+		{Key: "B", Filename: "journeys-operator-class-B.csv", NbJourneys: 1},
+		{Key: "C", Filename: "journeys-operator-class-C.csv", NbJourneys: 2},
+	}, summary.Parts)
+	assert.Len(t, summary.Errors, 1)
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	assert.NoError(t, err)
+	assert.Len(t, archive.File, 3)
+	assert.Equal(t, "errors.txt", archive.File[2].Name)
+
+	csvParser := service.NewJourneyCsvParser(&logger, config, schemas)
+	for i, part := range summary.Parts {
+		entry, err := archive.File[i].Open()
+		assert.NoError(t, err)
+		journeys, errors := parseAll(csvParser, entry)
+		assert.Empty(t, errors)
+		assert.Len(t, journeys, int(part.NbJourneys))
+		for _, journey := range journeys {
+			assert.Equal(t, part.Key, journey.OperatorClass)
+		}
+	}
+}
+
+func TestSplit_wrongFile(t *testing.T) {
+	schemas, err := service.LoadJourneySchemas(&logger, config)
+	assert.NoError(t, err)
+	splitter := service.NewJourneySplitter(&logger, service.NewJourneyParserRegistry(&logger, config, schemas))
+	exporter, err := service.NewJourneyExporterRegistry(&logger, config, schemas).Get(domain.JourneyFormatCSV)
+	assert.NoError(t, err)
+
+	var tests = []struct {
+		name      string
+		file      *domain.JourneyFile
+		dimension string
+	}{
+		{"unknown_dimension", &domain.JourneyFile{Name: "journeys.csv", Reader: strings.NewReader("")}, "town"},
+		{"unsupported_format", &domain.JourneyFile{Name: "journeys.xlsx", Reader: strings.NewReader("PK\x03\x04")}, domain.JourneySplitMonth},
+		{"no_journey", &domain.JourneyFile{Name: "journeys.json", Reader: strings.NewReader("{\"unknown\": 1}\n")}, domain.JourneySplitMonth},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			_, err := splitter.Split(test.file, test.dimension, exporter, buffer)
+
+			assert.Error(t, err)
+			assert.Empty(t, buffer.Bytes())
+		})
+	}
+}
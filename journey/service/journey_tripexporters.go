@@ -0,0 +1,170 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+
+	"go.uber.org/zap"
+)
+
+type tripExporterRegistry struct {
+	exporters map[string]domain.TripExporter
+}
+
+// NewTripExporterRegistry creates the exporters of the trips: csv and json.
+//
+// @param logger - Logger to use. Must not be nil.
+func NewTripExporterRegistry(logger *zap.SugaredLogger) domain.TripExporterRegistry {
+	return &tripExporterRegistry{
+		exporters: map[string]domain.TripExporter{
+			domain.JourneyFormatCSV:  &tripCsvExporter{logger: logger},
+			domain.JourneyFormatJSON: &tripJsonExporter{logger: logger},
+		},
+	}
+}
+
+// Get returns the exporter of a format
+//
+// @param format - Name of the format
+func (r *tripExporterRegistry) Get(format string) (domain.TripExporter, error) {
+	exporter, ok := r.exporters[format]
+	if !ok {
+		names := make([]string, 0, len(r.exporters))
+		for name := range r.exporters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unsupported export format '%s' (available: %s)", format, strings.Join(names, ", "))
+	}
+	return exporter, nil
+}
+
+// tripColumns are the columns of the trip exports
+var tripColumns = []string{"trip_id", "nb_passengers", "passenger_seats", "trip_start_datetime", "trip_end_datetime", "journey_ids", "warnings"}
+
+type tripCsvExporter struct {
+	logger *zap.SugaredLogger
+}
+
+// ContentType returns the MIME type of CSV files
+func (e *tripCsvExporter) ContentType() string {
+	return "text/csv"
+}
+
+// Extension returns the extension of CSV files
+func (e *tripCsvExporter) Extension() string {
+	return ".csv"
+}
+
+// Export writes a line per trip, with the separator of the official journey files.
+// The journey ids are separated by commas, the warnings by pipes.
+//
+// @param writer - Destination of the file
+// @param tripChan - Channel of the trips to export
+func (e *tripCsvExporter) Export(writer io.Writer, tripChan <-chan *domain.Trip) (int64, error) {
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Comma = ';'
+	if err := csvWriter.Write(tripColumns); err != nil {
+		return 0, err
+	}
+
+	var nbExported int64
+	for trip := range tripChan {
+		journeyIds := make([]string, 0, len(trip.JourneyIds))
+		for _, journeyId := range trip.JourneyIds {
+			journeyIds = append(journeyIds, strconv.FormatInt(journeyId, 10))
+		}
+		start, _ := formatProperty(trip.StartDatetime, time.RFC3339).(string)
+		end, _ := formatProperty(trip.EndDatetime, time.RFC3339).(string)
+
+		if err := csvWriter.Write([]string{
+			trip.TripId.String(),
+			strconv.FormatInt(trip.NbPassengers, 10),
+			strconv.FormatInt(trip.PassengerSeats, 10),
+			start,
+			end,
+			strings.Join(journeyIds, ","),
+			strings.Join(trip.Warnings, "|"),
+		}); err != nil {
+			return nbExported, err
+		}
+		nbExported++
+	}
+
+	csvWriter.Flush()
+	e.logger.Debugw("Trips exported",
+		"format", domain.JourneyFormatCSV,
+		"nbTrips", nbExported,
+	)
+	return nbExported, csvWriter.Error()
+}
+
+type tripJsonExporter struct {
+	logger *zap.SugaredLogger
+}
+
+// ContentType returns the MIME type of JSON files
+func (e *tripJsonExporter) ContentType() string {
+	return "application/json"
+}
+
+// Extension returns the extension of JSON files
+func (e *tripJsonExporter) Extension() string {
+	return ".json"
+}
+
+// Export writes the trips as an array of objects whose fields are named as the columns of the CSV export,
+// each trip being written as soon as it is received
+//
+// @param writer - Destination of the file
+// @param tripChan - Channel of the trips to export
+func (e *tripJsonExporter) Export(writer io.Writer, tripChan <-chan *domain.Trip) (int64, error) {
+	bufWriter := bufio.NewWriter(writer)
+	if err := bufWriter.WriteByte('['); err != nil {
+		return 0, err
+	}
+
+	var nbExported int64
+	for trip := range tripChan {
+		content, err := json.Marshal(map[string]interface{}{
+			"trip_id":             trip.TripId.String(),
+			"nb_passengers":       trip.NbPassengers,
+			"passenger_seats":     trip.PassengerSeats,
+			"trip_start_datetime": formatProperty(trip.StartDatetime, time.RFC3339),
+			"trip_end_datetime":   formatProperty(trip.EndDatetime, time.RFC3339),
+			"journey_ids":         trip.JourneyIds,
+			"warnings":            trip.Warnings,
+		})
+		if err != nil {
+			return nbExported, err
+		}
+
+		if nbExported > 0 {
+			bufWriter.WriteByte(',')
+		}
+		bufWriter.WriteByte('\n')
+		if _, err := bufWriter.Write(content); err != nil {
+			return nbExported, err
+		}
+		nbExported++
+	}
+
+	if _, err := bufWriter.WriteString("\n]\n"); err != nil {
+		return nbExported, err
+	}
+
+	e.logger.Debugw("Trips exported",
+		"format", domain.JourneyFormatJSON,
+		"nbTrips", nbExported,
+	)
+	return nbExported, bufWriter.Flush()
+}
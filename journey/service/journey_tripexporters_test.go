@@ -0,0 +1,87 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func exportTrips(t *testing.T, exporter domain.TripExporter, trips ...*domain.Trip) string {
+	tripChan := make(chan *domain.Trip, len(trips))
+	for _, trip := range trips {
+		tripChan <- trip
+	}
+	close(tripChan)
+
+	buffer := &bytes.Buffer{}
+	nbExported, err := exporter.Export(buffer, tripChan)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(trips)), nbExported)
+	return buffer.String()
+}
+
+func tripsToExport() []*domain.Trip {
+	return []*domain.Trip{
+		{
+			TripId:         uuid.MustParse("5a280bc3-f42d-4d3b-9554-c6fe5322edb5"),
+			NbPassengers:   2,
+			PassengerSeats: 3,
+			StartDatetime:  time.Date(2023, 1, 2, 8, 0, 0, 0, time.UTC),
+			EndDatetime:    time.Date(2023, 1, 2, 8, 40, 0, 0, time.UTC),
+			JourneyIds:     []int64{1, 2},
+			Warnings:       []string{"journeys start 1h0m0s apart"},
+		},
+		{
+			TripId:       uuid.MustParse("0c1fd78a-9373-4c32-85d6-0dd327f6b641"),
+			NbPassengers: 1,
+			JourneyIds:   []int64{3},
+			Warnings:     []string{},
+		},
+	}
+}
+
+func TestExportTripsCsv(t *testing.T) {
+	exporter, err := service.NewTripExporterRegistry(&logger).Get(domain.JourneyFormatCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, ".csv", exporter.Extension())
+
+	content := exportTrips(t, exporter, tripsToExport()...)
+
+	assert.Equal(t, strings.Join([]string{
+		"trip_id;nb_passengers;passenger_seats;trip_start_datetime;trip_end_datetime;journey_ids;warnings",
+		"5a280bc3-f42d-4d3b-9554-c6fe5322edb5;2;3;2023-01-02T08:00:00Z;2023-01-02T08:40:00Z;1,2;journeys start 1h0m0s apart",
+		"0c1fd78a-9373-4c32-85d6-0dd327f6b641;1;0;;;3;",
+	}, "\n")+"\n", content)
+}
+
+func TestExportTripsJson(t *testing.T) {
+	exporter, err := service.NewTripExporterRegistry(&logger).Get(domain.JourneyFormatJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, "application/json", exporter.ContentType())
+
+	content := exportTrips(t, exporter, tripsToExport()...)
+
+	var trips []map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(content), &trips))
+	assert.Len(t, trips, 2)
+	assert.Equal(t, "5a280bc3-f42d-4d3b-9554-c6fe5322edb5", trips[0]["trip_id"])
+	assert.Equal(t, "2023-01-02T08:00:00Z", trips[0]["trip_start_datetime"])
+	assert.Equal(t, []interface{}{float64(1), float64(2)}, trips[0]["journey_ids"])
+	assert.Nil(t, trips[1]["trip_end_datetime"])
+
+	assert.Equal(t, "[\n]\n", exportTrips(t, exporter))
+}
+
+func TestTripExporterRegistry_unknownFormat(t *testing.T) {
+	_, err := service.NewTripExporterRegistry(&logger).Get(domain.JourneyFormatParquet)
+
+	assert.EqualError(t, err, "unsupported export format 'parquet' (available: csv, json)")
+}
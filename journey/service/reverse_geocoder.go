@@ -0,0 +1,182 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/geo"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"go.uber.org/zap"
+)
+
+// Size of the cells of the spatial index of the communes, in degrees
+const boundariesCellSize = 0.05
+
+func init() {
+	RegisterJourneyProcessor("reverse-geocoding", NewReverseGeocoder)
+}
+
+type communeBoundary struct {
+	insee      int64
+	department string
+	town       string
+}
+
+type reverseGeocoder struct {
+	logger    *zap.SugaredLogger
+	index     *geo.GridIndex
+	communes  []communeBoundary
+	byInsee   map[int64]int
+	tolerance float64
+}
+
+// NewReverseGeocoder creates a processing step locating journeys with the boundaries of the communes.
+//
+// The boundaries are read from the GeoJSON file of the configuration, each feature being a commune.
+// Journeys with coordinates but without INSEE code get the code, the department and the town of the commune
+// containing their coordinates. Journeys whose declared commune doesn't contain their coordinates are flagged.
+//
+// @param logger - Logger to use. Must not be nil.
+// @param cfg - Configuration giving the file, the names of its properties and the tolerance in meters
+func NewReverseGeocoder(logger *zap.SugaredLogger, cfg *configuration.Config) (domain.JourneyProcessor, error) {
+	boundariesCfg := cfg.Journey.Referential.Boundaries
+	content, err := os.ReadFile(boundariesCfg.File)
+	if err != nil {
+		return nil, err
+	}
+
+	featureCollection, err := geojson.UnmarshalFeatureCollection(content)
+	if err != nil {
+		return nil, fmt.Errorf("problem while loading commune boundaries %s: %w", boundariesCfg.File, err)
+	}
+
+	inseeProperty := propertyOrDefault(boundariesCfg.InseeProperty, "code")
+	departmentProperty := propertyOrDefault(boundariesCfg.DepartmentProperty, "departement")
+	townProperty := propertyOrDefault(boundariesCfg.TownProperty, "nom")
+
+	geocoder := &reverseGeocoder{
+		logger:    logger,
+		index:     geo.NewGridIndex(boundariesCellSize),
+		byInsee:   map[int64]int{},
+		tolerance: boundariesCfg.Tolerance,
+	}
+	for _, feature := range featureCollection.Features {
+		code := propertyString(feature.Properties, inseeProperty)
+		insee, err := strconv.ParseInt(code, 10, 64)
+		if err != nil {
+			logger.Debugw("Commune ignored",
+				"insee", code,
+			)
+			continue
+		}
+
+		switch feature.Geometry.(type) {
+		case orb.Polygon, orb.MultiPolygon:
+		default:
+			logger.Debugw("Commune without polygon ignored",
+				"insee", code,
+			)
+			continue
+		}
+
+		department := propertyString(feature.Properties, departmentProperty)
+		if department == "" {
+			department = departmentOfInsee(fmt.Sprintf("%05d", insee))
+		}
+
+		position := geocoder.index.Add(feature.Geometry)
+		geocoder.communes = append(geocoder.communes, communeBoundary{
+			insee:      insee,
+			department: department,
+			town:       propertyString(feature.Properties, townProperty),
+		})
+		geocoder.byInsee[insee] = position
+	}
+
+	logger.Infow("Commune boundaries loaded",
+		"file", boundariesCfg.File,
+		"nbCommunes", geocoder.index.Len(),
+	)
+	return geocoder, nil
+}
+
+// Process locates the start and the end of a journey
+//
+// @param journey - Journey to enrich
+func (g *reverseGeocoder) Process(journey *domain.Journey) (bool, error) {
+	for _, place := range journeyPlaces(journey) {
+		g.enrichPlace(journey, place)
+	}
+	return true, nil
+}
+
+func (g *reverseGeocoder) enrichPlace(journey *domain.Journey, place journeyPlace) {
+	if *place.lon == 0 && *place.lat == 0 {
+		return
+	}
+	point := orb.Point{*place.lon, *place.lat}
+
+	var commune *communeBoundary
+	if *place.insee == 0 {
+		positions := g.index.Locate(point)
+		if len(positions) == 0 {
+			place.flag(journey, "insee_unresolved")
+			return
+		}
+		commune = &g.communes[positions[0]]
+		*place.insee = commune.insee
+		place.flag(journey, "insee_geocoded")
+	} else {
+		position, ok := g.byInsee[*place.insee]
+		if !ok {
+			return
+		}
+		commune = &g.communes[position]
+		if !geo.NearlyContains(g.index.Geometry(position), point, g.tolerance) {
+			place.flag(journey, "outside_declared_commune")
+		}
+	}
+
+	if *place.department == "" {
+		*place.department = commune.department
+	}
+	if *place.town == "" && commune.town != "" {
+		*place.town = fmt.Sprintf("%s (%s)", commune.town, commune.department)
+	}
+}
+
+func propertyOrDefault(property string, defaultProperty string) string {
+	if property == "" {
+		return defaultProperty
+	}
+	return property
+}
+
+// propertyString returns a property of a feature as a string, whatever its JSON type
+func propertyString(properties geojson.Properties, key string) string {
+	value, ok := properties[key]
+	if !ok || value == nil {
+		return ""
+	}
+	if number, ok := value.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return strings.TrimSpace(fmt.Sprint(value))
+}
+
+// departmentOfInsee deduces the department from an INSEE code. Overseas departments have 3 digits
+func departmentOfInsee(insee string) string {
+	if len(insee) < 3 {
+		return ""
+	}
+	if strings.HasPrefix(insee, "97") {
+		return insee[:3]
+	}
+	return insee[:2]
+}
@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/journey/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReverseGeocoder(t *testing.T) {
+	cfg := *config
+	cfg.Journey.Referential.Boundaries.File = filepath.Join("testdata", "communes.geojson")
+	cfg.Journey.Referential.Boundaries.Tolerance = 1000
+	geocoder, err := service.NewReverseGeocoder(&logger, &cfg)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		journey       domain.Journey
+		expected      domain.Journey
+		expectedFlags []string
+	}{
+		{
+			"missing_insee",
+			domain.Journey{JourneyStartLon: 1.68, JourneyStartLat: 49.00, JourneyEndLon: 2.23, JourneyEndLat: 48.73},
+			domain.Journey{
+				JourneyStartLon: 1.68, JourneyStartLat: 49.00, JourneyStartInsee: 78361, JourneyStartDepartment: "78", JourneyStartTown: "Mantes-la-Jolie (78)",
+				JourneyEndLon: 2.23, JourneyEndLat: 48.73, JourneyEndInsee: 91312, JourneyEndDepartment: "91", JourneyEndTown: "Igny (91)",
+			},
+			[]string{"start_insee_geocoded", "end_insee_geocoded"},
+		},
+		{
+			"unresolved_and_missing_coordinates",
+			domain.Journey{JourneyStartLon: 5.0, JourneyStartLat: 45.0},
+			domain.Journey{JourneyStartLon: 5.0, JourneyStartLat: 45.0},
+			[]string{"start_insee_unresolved"},
+		},
+		{
+			"declared_commune",
+			domain.Journey{
+				JourneyStartLon: 1.725, JourneyStartLat: 49.00, JourneyStartInsee: 78361, JourneyStartDepartment: "78", JourneyStartTown: "Mantes-la-Jolie (78)",
+				JourneyEndLon: 2.10, JourneyEndLat: 49.04, JourneyEndInsee: 91312, JourneyEndDepartment: "91", JourneyEndTown: "Igny (91)",
+			},
+			domain.Journey{
+				JourneyStartLon: 1.725, JourneyStartLat: 49.00, JourneyStartInsee: 78361, JourneyStartDepartment: "78", JourneyStartTown: "Mantes-la-Jolie (78)",
+				JourneyEndLon: 2.10, JourneyEndLat: 49.04, JourneyEndInsee: 91312, JourneyEndDepartment: "91", JourneyEndTown: "Igny (91)",
+			},
+			[]string{"end_outside_declared_commune"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			journey := test.journey
+			keep, err := geocoder.Process(&journey)
+			assert.NoError(t, err)
+			assert.True(t, keep)
+			assert.Equal(t, test.expectedFlags, journey.Flags)
+
+			journey.Flags = nil
+			assert.Equal(t, test.expected, journey)
+		})
+	}
+
+	t.Run("unknown_file", func(t *testing.T) {
+		cfg.Journey.Referential.Boundaries.File = filepath.Join("testdata", "unknown.geojson")
+		_, err := service.NewReverseGeocoder(&logger, &cfg)
+		assert.Error(t, err)
+	})
+}
@@ -2,49 +2,125 @@
 package usecase
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"io"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/coutcout/covoiturage-csvreader/configuration"
 	"github.com/coutcout/covoiturage-csvreader/domain"
+	"github.com/coutcout/covoiturage-csvreader/geo"
 	"github.com/gin-gonic/gin"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
 
 	"go.uber.org/zap"
 )
 
+// Number of journeys read from the repository ahead of the exporter
+const exportBufferSize = 1000
+
 type journeyUsecase struct {
 	logger           *zap.SugaredLogger
 	cfg              *configuration.Config
 	journeyRepo      domain.JourneyRepositoryInterface
-	journeyCsvParser domain.JourneyParser
+	dailyStatsRepo   domain.DailyStatsRepositoryInterface
+	zoneRepo         domain.ZoneRepositoryInterface
+	journeyParsers   domain.JourneyParserRegistry
+	journeyExporters domain.JourneyExporterRegistry
+	tripExporters    domain.TripExporterRegistry
+	journeySplitter  domain.JourneySplitter
+	processors       *domain.JourneyProcessorChain
+	tiles            *tileCache
 }
 
-// NewJourneyUsecase creates a new journey usecase.
+// NewJourneyUsecase creates a new journey usecase. An error is returned when its configuration is invalid.
 //
 // @param logger - Logger to log to. Must not be nil.
 // @param cfg - Configuration for the journey repository. Must not be nil.
 // @param jRepo - Journey repository to use. Must not be nil.
-// @param jCsvParser - Journey parser to use. Must not be nil
-func NewJourneyUsecase(logger *zap.SugaredLogger, cfg *configuration.Config, jRepo domain.JourneyRepositoryInterface, jCsvParser domain.JourneyParser) domain.JourneyUsecase {
+// @param dsRepo - Repository of the daily counters, updated with the stored journeys. Must not be nil
+// @param zRepo - Repository of the zones, with which the imported journeys are tagged. Must not be nil
+// @param jParsers - Registry of the journey parsers, consulted for each file. Must not be nil
+// @param jExporters - Registry of the journey exporters, by format. Must not be nil
+// @param tExporters - Registry of the trip exporters, by format. Must not be nil
+// @param jSplitter - Splitter of journey files. Must not be nil
+// @param processors - Processing chain applied to each journey before its insertion. Must not be nil
+func NewJourneyUsecase(logger *zap.SugaredLogger, cfg *configuration.Config, jRepo domain.JourneyRepositoryInterface, dsRepo domain.DailyStatsRepositoryInterface, zRepo domain.ZoneRepositoryInterface, jParsers domain.JourneyParserRegistry, jExporters domain.JourneyExporterRegistry, tExporters domain.TripExporterRegistry, jSplitter domain.JourneySplitter, processors *domain.JourneyProcessorChain) (domain.JourneyUsecase, error) {
+	if err := domain.ValidateClusterDepth(cfg.Journey.Tiles.ClusterDepth); err != nil {
+		return nil, fmt.Errorf("invalid journey.tiles.cluster-depth: %w", err)
+	}
+	if err := domain.ValidateTileMaxJourneys(cfg.Journey.Tiles.MaxJourneys); err != nil {
+		return nil, fmt.Errorf("invalid journey.tiles.max-journeys: %w", err)
+	}
+	if err := domain.ValidateTileCacheSize(cfg.Journey.Tiles.CacheSize); err != nil {
+		return nil, fmt.Errorf("invalid journey.tiles.cache-size: %w", err)
+	}
+	if cfg.Journey.Tiles.CacheSize == 0 {
+		logger.Infow("Cache of the vector tiles disabled",
+			"cache-size", cfg.Journey.Tiles.CacheSize,
+		)
+	}
 	return &journeyUsecase{
 		logger:           logger,
 		cfg:              cfg,
 		journeyRepo:      jRepo,
-		journeyCsvParser: jCsvParser,
-	}
+		dailyStatsRepo:   dsRepo,
+		zoneRepo:         zRepo,
+		journeyParsers:   jParsers,
+		journeyExporters: jExporters,
+		tripExporters:    tExporters,
+		journeySplitter:  jSplitter,
+		processors:       processors,
+		tiles:            newTileCache(cfg.Journey.Tiles.CacheSize, cfg.Journey.Tiles.CacheTTL),
+	}, nil
 }
 
-// ImportFromCSVFile imports journeys from a CSV file.
+// ImportFromFile imports journeys from a file, read by the parser of its format.
 //
-// @param reader - the reader to read the csv file
-func (ucase *journeyUsecase) ImportFromCSVFile(c *gin.Context, reader io.Reader) (int64, []string) {
+// @param file - the file to import
+// @param filter - criteria of the journeys to store, checked after the processing chain. Can be nil
+func (ucase *journeyUsecase) ImportFromFile(c *gin.Context, file *domain.JourneyFile, filter *domain.JourneyFilter) (*domain.ImportSummary, []string) {
+	format, parser, err := ucase.journeyParsers.Select(file)
+	if err != nil {
+		ucase.logger.Errorw("Error importing file",
+			"error", err.Error(),
+			"filename", file.Name,
+		)
+		return &domain.ImportSummary{}, []string{err.Error()}
+	}
+	ucase.logger.Debugw("Parser selected",
+		"filename", file.Name,
+		"format", format,
+	)
+
+	zones, err := ucase.zoneRepo.List(requestContext(c))
+	if err != nil {
+		ucase.logger.Errorw("Error reading zones",
+			"error", err.Error(),
+			"filename", file.Name,
+		)
+		return &domain.ImportSummary{Format: format}, []string{err.Error()}
+	}
+
 	journeyChan := make(chan *domain.Journey)
 	insertedJourneyCounterChan := make(chan int)
 	errorChan := make(chan string)
-	ucase.journeyCsvParser.Parse(reader, journeyChan, errorChan)
+	processingErrorChan := make(chan string)
+	processorRun := ucase.processors.NewRun()
+	parser.Parse(file.Reader, journeyChan, errorChan)
 	errors := []string{}
+	processingErrors := []string{}
 
 	nbJourneyImported := 0
+	var nbJourneyFilteredOut atomic.Int64
+	if filter != nil && filter.IsEmpty() {
+		filter = nil
+	}
 	var workerGroup sync.WaitGroup
 	var insertionWorkerGroup sync.WaitGroup
 
@@ -55,18 +131,31 @@ func (ucase *journeyUsecase) ImportFromCSVFile(c *gin.Context, reader io.Reader)
 			case journey, ok := <-journeyChan:
 				if !ok {
 					ucase.logger.Debug("Worker ended, flushing buffer")
-					repo.Add(c, journeyBuffer)
-					insertedJourneyCounter <- len(journeyBuffer)
+					insertedJourneyCounter <- ucase.insert(c, repo, journeyBuffer, processingErrorChan)
 					return
 				}
 
+				keep, err := processorRun.Process(journey)
+				if err != nil {
+					processingErrorChan <- err.Error()
+					continue
+				}
+				if !keep {
+					continue
+				}
+				domain.TagZones(journey, zones)
+
+				if filter != nil && !filter.Match(journey) {
+					nbJourneyFilteredOut.Add(1)
+					continue
+				}
+
 				journeyBuffer = append(journeyBuffer, *journey)
 				if len(journeyBuffer) == bufferSize {
 					ucase.logger.Debugw("Buffer is full, flushing it",
 						"bufferSize", bufferSize,
 					)
-					repo.Add(c, journeyBuffer)
-					insertedJourneyCounter <- len(journeyBuffer)
+					insertedJourneyCounter <- ucase.insert(c, repo, journeyBuffer, processingErrorChan)
 					journeyBuffer = nil
 				}
 			}
@@ -89,6 +178,7 @@ func (ucase *journeyUsecase) ImportFromCSVFile(c *gin.Context, reader io.Reader)
 		defer workerGroup.Done()
 		insertionWorkerGroup.Wait()
 		close(insertedJourneyCounterChan)
+		close(processingErrorChan)
 	}()
 
 	workerGroup.Add(1)
@@ -107,7 +197,781 @@ func (ucase *journeyUsecase) ImportFromCSVFile(c *gin.Context, reader io.Reader)
 		}
 	}()
 
+	workerGroup.Add(1)
+	go func() {
+		defer workerGroup.Done()
+		for e := range processingErrorChan {
+			processingErrors = append(processingErrors, e)
+		}
+	}()
+
 	workerGroup.Wait()
+	if nbJourneyImported > 0 {
+		ucase.tiles.clear()
+	}
+
+	summary := &domain.ImportSummary{
+		Format:               format,
+		NbJourneyImported:    int64(nbJourneyImported),
+		NbJourneyFilteredOut: nbJourneyFilteredOut.Load(),
+		Processors:           processorRun.Stats(),
+	}
+	return summary, append(errors, processingErrors...)
+}
+
+// insert stores journeys and adds the stored ones to the daily counters, and returns the number of stored journeys.
+// Only the journeys stored as new documents are counted: the ones already stored by a previous import, or failing, aren't.
+func (ucase *journeyUsecase) insert(c *gin.Context, repo domain.JourneyRepositoryInterface, journeys []domain.Journey, errorChan chan<- string) int {
+	if len(journeys) == 0 {
+		return 0
+	}
+
+	inserted, err := repo.Add(c, journeys)
+	if err != nil {
+		ucase.logger.Errorw("Error storing journeys",
+			"error", err.Error(),
+			"nbJourneys", len(journeys),
+			"nbInserted", len(inserted),
+		)
+		errorChan <- fmt.Sprintf("%d of %d journeys stored: %s", len(inserted), len(journeys), err.Error())
+	} else if len(inserted) < len(journeys) {
+		ucase.logger.Infow("Journeys already stored skipped",
+			"nbJourneys", len(journeys),
+			"nbInserted", len(inserted),
+		)
+	}
+	if len(inserted) == 0 {
+		return 0
+	}
+	if err := ucase.dailyStatsRepo.Increment(requestContext(c), inserted); err != nil {
+		ucase.logger.Errorw("Error updating the daily statistics",
+			"error", err.Error(),
+			"nbJourneys", len(inserted),
+		)
+		errorChan <- "daily statistics not updated, they must be rebuilt: " + err.Error()
+	}
+	return len(inserted)
+}
+
+// Exporter returns the exporter of a format
+//
+// @param format - Name of the export format
+func (ucase *journeyUsecase) Exporter(format string) (domain.JourneyExporter, error) {
+	return ucase.journeyExporters.Get(format)
+}
+
+// Export writes the stored journeys matching the filter with an exporter.
+// The journeys are read from the repository while they are written, the search stops if the export fails.
+// Exporters with a limit are refused selections above it, with a domain.ExportTooLargeError.
+//
+// @param exporter - Exporter of the requested format, see Exporter
+// @param filter - criteria of the journeys to export. Can be nil
+// @param writer - destination of the exported file
+func (ucase *journeyUsecase) Export(c *gin.Context, exporter domain.JourneyExporter, filter *domain.JourneyFilter, writer io.Writer) (int64, error) {
+	ctx, cancel := context.WithCancel(requestContext(c))
+	defer cancel()
+
+	if filter != nil && filter.IsEmpty() {
+		filter = nil
+	}
+
+	if limited, ok := exporter.(domain.LimitedJourneyExporter); ok && limited.MaxJourneys() > 0 {
+		nbJourneys, err := ucase.journeyRepo.Count(ctx, filter)
+		if err != nil {
+			return 0, err
+		}
+		if nbJourneys > limited.MaxJourneys() {
+			return 0, &domain.ExportTooLargeError{
+				NbJourneys:  nbJourneys,
+				MaxJourneys: limited.MaxJourneys(),
+			}
+		}
+	}
+
+	journeyChan := make(chan *domain.Journey, exportBufferSize)
+	findErrorChan := make(chan error, 1)
+	go func() {
+		findErrorChan <- ucase.journeyRepo.Find(ctx, filter, journeyChan)
+	}()
+
+	nbExported, err := exporter.Export(writer, journeyChan)
+	cancel()
+	for range journeyChan {
+		// The search stops on cancellation, the remaining journeys are dropped
+	}
+	findErr := <-findErrorChan
+
+	if err != nil {
+		ucase.logger.Errorw("Error exporting journeys",
+			"error", err.Error(),
+		)
+		return nbExported, err
+	}
+	if findErr != nil {
+		ucase.logger.Errorw("Error reading journeys to export",
+			"error", findErr.Error(),
+		)
+		return nbExported, findErr
+	}
+
+	ucase.logger.Infow("Journeys exported",
+		"nbJourneys", nbExported,
+	)
+	return nbExported, nil
+}
+
+// Split dispatches the journeys of a file into a file per value of a dimension, gathered into a zip archive.
+// The journeys are not stored.
+//
+// @param file - the file to split
+// @param dimension - dimension of the split, one of the domain.JourneySplit constants
+// @param exporter - exporter writing the parts, see Exporter
+// @param writer - destination of the zip archive
+func (ucase *journeyUsecase) Split(c *gin.Context, file *domain.JourneyFile, dimension string, exporter domain.JourneyExporter, writer io.Writer) (*domain.SplitSummary, error) {
+	summary, err := ucase.journeySplitter.Split(file, dimension, exporter, writer)
+	if err != nil {
+		ucase.logger.Errorw("Error splitting file",
+			"error", err.Error(),
+			"filename", file.Name,
+		)
+	}
+	return summary, err
+}
+
+// ODMatrix counts the stored journeys matching the filter by origin and destination
+//
+// @param level - geographic level of the origins and destinations, one of the domain.ODLevel constants
+// @param filter - criteria of the journeys. Can be nil
+func (ucase *journeyUsecase) ODMatrix(c *gin.Context, level string, filter *domain.JourneyFilter) ([]domain.ODPair, error) {
+	if err := domain.ValidateODLevel(level); err != nil {
+		return nil, err
+	}
+	if filter != nil && filter.IsEmpty() {
+		filter = nil
+	}
+
+	pairs, err := ucase.journeyRepo.ODMatrix(requestContext(c), level, filter)
+	if err != nil {
+		ucase.logger.Errorw("Error computing the OD matrix",
+			"error", err.Error(),
+			"level", level,
+		)
+		return nil, err
+	}
+	return pairs, nil
+}
+
+// Stats counts the stored journeys matching the filter by bucket of a statistic, with the share of each bucket.
+// Without filter, the domain.DailyStatsStats are summed from the daily counters, where the journeys without start
+// datetime are counted on their start date.
+//
+// @param stat - statistic to compute, one of the domain.Stat constants
+// @param filter - criteria of the journeys. Can be nil
+func (ucase *journeyUsecase) Stats(c *gin.Context, stat string, filter *domain.JourneyFilter) ([]domain.StatBucket, error) {
+	if err := domain.ValidateStat(stat); err != nil {
+		return nil, err
+	}
+	if filter != nil && filter.IsEmpty() {
+		filter = nil
+	}
+
+	var buckets []domain.StatBucket
+	var err error
+	if filter == nil && domain.IsDailyStatsStat(stat) {
+		// Summing the daily counters kept up to date by the imports is much cheaper than aggregating all the journeys
+		buckets, err = ucase.dailyStatsRepo.Stats(requestContext(c), stat)
+	} else {
+		buckets, err = ucase.journeyRepo.Stats(requestContext(c), stat, filter)
+	}
+	if err != nil {
+		ucase.logger.Errorw("Error computing statistics",
+			"error", err.Error(),
+			"stat", stat,
+		)
+		return nil, err
+	}
+	domain.ComputeStatShares(buckets)
+	return buckets, nil
+}
+
+// Trips gathers the stored journeys matching the filter by TripId, sorted by TripId
+//
+// @param filter - criteria of the journeys. Can be nil
+// @param offset - number of trips to skip
+// @param limit - maximum number of trips, 0 meaning no limit
+func (ucase *journeyUsecase) Trips(c *gin.Context, filter *domain.JourneyFilter, offset int64, limit int64) ([]domain.Trip, error) {
+	if filter != nil && filter.IsEmpty() {
+		filter = nil
+	}
+
+	tripChan := make(chan *domain.Trip)
+	findErrorChan := make(chan error, 1)
+	go func() {
+		findErrorChan <- ucase.journeyRepo.FindTrips(requestContext(c), filter, offset, limit, tripChan)
+	}()
+
+	trips := []domain.Trip{}
+	for trip := range tripChan {
+		trips = append(trips, *trip)
+	}
+	if err := <-findErrorChan; err != nil {
+		ucase.logger.Errorw("Error reading trips",
+			"error", err.Error(),
+		)
+		return nil, err
+	}
+	return trips, nil
+}
+
+// TripExporter returns the trip exporter of a format
+//
+// @param format - Name of the export format
+func (ucase *journeyUsecase) TripExporter(format string) (domain.TripExporter, error) {
+	return ucase.tripExporters.Get(format)
+}
+
+// ExportTrips writes the trips of the stored journeys matching the filter with an exporter.
+// The trips are read from the repository while they are written, the search stops if the export fails.
+//
+// @param exporter - Exporter of the requested format, see TripExporter
+// @param filter - criteria of the journeys. Can be nil
+// @param writer - destination of the exported file
+func (ucase *journeyUsecase) ExportTrips(c *gin.Context, exporter domain.TripExporter, filter *domain.JourneyFilter, writer io.Writer) (int64, error) {
+	ctx, cancel := context.WithCancel(requestContext(c))
+	defer cancel()
+
+	if filter != nil && filter.IsEmpty() {
+		filter = nil
+	}
+
+	tripChan := make(chan *domain.Trip, exportBufferSize)
+	findErrorChan := make(chan error, 1)
+	go func() {
+		findErrorChan <- ucase.journeyRepo.FindTrips(ctx, filter, 0, 0, tripChan)
+	}()
+
+	nbExported, err := exporter.Export(writer, tripChan)
+	cancel()
+	for range tripChan {
+		// The search stops on cancellation, the remaining trips are dropped
+	}
+	findErr := <-findErrorChan
+
+	if err != nil {
+		ucase.logger.Errorw("Error exporting trips",
+			"error", err.Error(),
+		)
+		return nbExported, err
+	}
+	if findErr != nil {
+		ucase.logger.Errorw("Error reading trips to export",
+			"error", findErr.Error(),
+		)
+		return nbExported, findErr
+	}
+
+	ucase.logger.Infow("Trips exported",
+		"nbTrips", nbExported,
+	)
+	return nbExported, nil
+}
+
+// Co2 estimates the emissions avoided by the stored journeys matching the filter, by value of a dimension.
+// The estimates are computed with the requested version of the emission factors, so that past reports can be reproduced.
+//
+// @param dimension - dimension of the buckets, one of the domain.Co2Dimensions. A single bucket is computed when empty
+// @param version - version of the emission factors, the one of the configuration when empty
+// @param filter - criteria of the journeys. Can be nil
+func (ucase *journeyUsecase) Co2(c *gin.Context, dimension string, version string, filter *domain.JourneyFilter) (*domain.Co2Report, error) {
+	if err := domain.ValidateCo2Dimension(dimension); err != nil {
+		return nil, err
+	}
+	if version == "" {
+		version = ucase.cfg.Journey.Co2.Version
+	}
+	factors, ok := ucase.cfg.Journey.Co2.Factors[version]
+	if !ok {
+		return nil, fmt.Errorf("unknown emission factors version '%s'", version)
+	}
+	if filter != nil && filter.IsEmpty() {
+		filter = nil
+	}
+
+	buckets, err := ucase.journeyRepo.Co2(requestContext(c), dimension, filter)
+	if err != nil {
+		ucase.logger.Errorw("Error estimating the CO2 savings",
+			"error", err.Error(),
+			"dimension", dimension,
+		)
+		return nil, err
+	}
+	factors.Estimate(buckets)
+	return &domain.Co2Report{
+		Version: version,
+		Factors: factors,
+		Buckets: buckets,
+	}, nil
+}
+
+// SimulateIncentive runs an incentive policy over the stored journeys matching the filter, read in the order
+// of their starts. The result holds a page of the paid journeys, all of them can be sent to a function as they are paid.
+//
+// @param policy - the rules of the policy
+// @param filter - criteria of the journeys, such as the simulated period. Can be nil
+// @param offset - number of paid journeys skipped before the page of the result
+// @param limit - number of paid journeys of the page of the result, 0 for none
+// @param paid - function receiving each paid journey, stopping the simulation on error. Can be nil
+func (ucase *journeyUsecase) SimulateIncentive(c *gin.Context, policy *domain.IncentivePolicy, filter *domain.JourneyFilter, offset int64, limit int64, paid func(*domain.IncentiveJourney) error) (*domain.IncentiveSimulationResult, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(ucase.cfg.Journey.Stats.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	if filter != nil && filter.IsEmpty() {
+		filter = nil
+	}
+
+	ctx, cancel := context.WithCancel(requestContext(c))
+	defer cancel()
+	journeyChan := make(chan *domain.Journey, exportBufferSize)
+	findErrorChan := make(chan error, 1)
+	go func() {
+		findErrorChan <- ucase.journeyRepo.FindByStart(ctx, filter, journeyChan)
+	}()
+
+	simulation := domain.NewIncentiveSimulation(policy, location, offset, limit)
+	var paidErr error
+	for journey := range journeyChan {
+		if paidErr != nil {
+			continue
+		}
+		if journey, ok := simulation.Add(journey); ok && paid != nil {
+			if paidErr = paid(journey); paidErr != nil {
+				cancel()
+			}
+		}
+	}
+	if paidErr != nil {
+		<-findErrorChan
+		ucase.logger.Errorw("Error sending the journeys paid by an incentive policy",
+			"error", paidErr.Error(),
+			"policy", policy.Name,
+		)
+		return nil, paidErr
+	}
+	if err := <-findErrorChan; err != nil {
+		ucase.logger.Errorw("Error reading journeys to simulate an incentive policy",
+			"error", err.Error(),
+			"policy", policy.Name,
+		)
+		return nil, err
+	}
+
+	result := simulation.Result()
+	ucase.logger.Infow("Incentive policy simulated",
+		"policy", policy.Name,
+		"nbJourneys", result.NbJourneys,
+		"nbPaid", result.NbPaid,
+		"totalCost", result.TotalCost,
+	)
+	return result, nil
+}
+
+// DetectAnomalies checks the stored journeys matching the filter, alone and compared to each other, and stores
+// their anomalies and scores. The anomalies previously stored on these journeys are replaced.
+//
+// @param filter - criteria of the journeys, such as the checked period. Can be nil
+func (ucase *journeyUsecase) DetectAnomalies(c *gin.Context, filter *domain.JourneyFilter) (*domain.AnomalySummary, error) {
+	if filter != nil && filter.IsEmpty() {
+		filter = nil
+	}
+
+	journeyChan := make(chan *domain.Journey, exportBufferSize)
+	findErrorChan := make(chan error, 1)
+	go func() {
+		findErrorChan <- ucase.journeyRepo.Find(requestContext(c), filter, journeyChan)
+	}()
+
+	detector := domain.NewAnomalyDetector(&ucase.cfg.Journey.Anomalies)
+	for journey := range journeyChan {
+		detector.Add(journey)
+	}
+	if err := <-findErrorChan; err != nil {
+		ucase.logger.Errorw("Error reading journeys to detect anomalies",
+			"error", err.Error(),
+		)
+		return nil, err
+	}
 
-	return int64(nbJourneyImported), errors
+	summary := &domain.AnomalySummary{ByAnomaly: map[string]int64{}}
+	for _, anomaly := range domain.Anomalies {
+		summary.ByAnomaly[anomaly] = 0
+	}
+	flagged := []domain.JourneyAnomalies{}
+	for _, journeyAnomalies := range detector.Result() {
+		summary.NbJourneys++
+		if len(journeyAnomalies.Anomalies) == 0 {
+			continue
+		}
+		summary.NbFlagged++
+		for _, anomaly := range journeyAnomalies.Anomalies {
+			summary.ByAnomaly[anomaly]++
+		}
+		flagged = append(flagged, journeyAnomalies)
+	}
+
+	err := ucase.journeyRepo.ReplaceAnomalies(requestContext(c), filter, flagged)
+	// The anomalies are criteria of the tiles: even a partial replacement makes the cached tiles stale
+	ucase.tiles.clear()
+	if err != nil {
+		ucase.logger.Errorw("Error storing anomalies",
+			"error", err.Error(),
+		)
+		return nil, err
+	}
+
+	ucase.logger.Infow("Anomalies detected",
+		"nbJourneys", summary.NbJourneys,
+		"nbFlagged", summary.NbFlagged,
+	)
+	return summary, nil
+}
+
+// Anomalies returns the stored journeys matching the filter with an anomaly, by decreasing score
+//
+// @param filter - criteria of the journeys. Can be nil
+// @param anomaly - anomaly of the journeys, one of the domain.Anomalies. Any anomaly when empty
+// @param offset - number of journeys to skip
+// @param limit - maximum number of journeys, 0 meaning no limit
+func (ucase *journeyUsecase) Anomalies(c *gin.Context, filter *domain.JourneyFilter, anomaly string, offset int64, limit int64) ([]domain.Journey, error) {
+	if anomaly != "" {
+		if err := domain.ValidateAnomaly(anomaly); err != nil {
+			return nil, err
+		}
+	}
+	if filter != nil && filter.IsEmpty() {
+		filter = nil
+	}
+
+	journeys, err := ucase.journeyRepo.FindAnomalies(requestContext(c), filter, anomaly, offset, limit)
+	if err != nil {
+		ucase.logger.Errorw("Error reading anomalies",
+			"error", err.Error(),
+			"anomaly", anomaly,
+		)
+		return nil, err
+	}
+	return journeys, nil
+}
+
+// CreateZone stores a new zone and tags the stored journeys starting or ending in it.
+// The zone is removed when the journeys can't be tagged.
+//
+// @param zone - the zone to create
+func (ucase *journeyUsecase) CreateZone(c *gin.Context, zone *domain.Zone) error {
+	if err := zone.Validate(); err != nil {
+		return err
+	}
+	if err := ucase.zoneRepo.Create(requestContext(c), zone); err != nil {
+		return err
+	}
+	// The zones are criteria of the tiles: tagging, even undone, makes the cached tiles stale
+	defer ucase.tiles.clear()
+	if err := ucase.tagZone(c, zone); err != nil {
+		return ucase.rollbackZone(zone.Name, nil, err)
+	}
+	return nil
+}
+
+// Zones returns all the zones, sorted by name
+func (ucase *journeyUsecase) Zones(c *gin.Context) ([]domain.Zone, error) {
+	zones, err := ucase.zoneRepo.List(requestContext(c))
+	if err != nil {
+		ucase.logger.Errorw("Error reading zones",
+			"error", err.Error(),
+		)
+		return nil, err
+	}
+	return zones, nil
+}
+
+// Zone returns a zone, domain.ErrZoneNotFound is returned when it doesn't exist
+//
+// @param name - the name of the zone
+func (ucase *journeyUsecase) Zone(c *gin.Context, name string) (*domain.Zone, error) {
+	return ucase.zoneRepo.Get(requestContext(c), name)
+}
+
+// UpdateZone replaces a zone and tags the stored journeys again.
+// The previous version of the zone is restored when the journeys can't be tagged.
+//
+// @param zone - the new version of the zone
+func (ucase *journeyUsecase) UpdateZone(c *gin.Context, zone *domain.Zone) error {
+	if err := zone.Validate(); err != nil {
+		return err
+	}
+	previous, err := ucase.zoneRepo.Get(requestContext(c), zone.Name)
+	if err != nil {
+		return err
+	}
+	if err := ucase.zoneRepo.Update(requestContext(c), zone); err != nil {
+		return err
+	}
+	defer ucase.tiles.clear()
+	if err := ucase.tagZone(c, zone); err != nil {
+		return ucase.rollbackZone(zone.Name, previous, err)
+	}
+	return nil
+}
+
+// DeleteZone removes a zone and removes it from the stored journeys
+//
+// @param name - the name of the zone
+func (ucase *journeyUsecase) DeleteZone(c *gin.Context, name string) error {
+	if err := ucase.zoneRepo.Delete(requestContext(c), name); err != nil {
+		return err
+	}
+	defer ucase.tiles.clear()
+	if err := ucase.journeyRepo.UntagZone(requestContext(c), name); err != nil {
+		ucase.logger.Errorw("Error removing a zone from the journeys",
+			"error", err.Error(),
+			"zone", name,
+		)
+		return err
+	}
+	ucase.logger.Infow("Zone deleted",
+		"zone", name,
+	)
+	return nil
+}
+
+// tagZone tags the stored journeys with a zone which has just been stored
+func (ucase *journeyUsecase) tagZone(c *gin.Context, zone *domain.Zone) error {
+	if err := ucase.journeyRepo.TagZone(requestContext(c), zone); err != nil {
+		ucase.logger.Errorw("Error tagging the journeys with a zone",
+			"error", err.Error(),
+			"zone", zone.Name,
+		)
+		return err
+	}
+	ucase.logger.Infow("Journeys tagged with a zone",
+		"zone", zone.Name,
+	)
+	return nil
+}
+
+// rollbackZone restores the previous version of a zone whose journeys couldn't be tagged, and its tags,
+// or removes the zone when it has just been created. It runs even if the request has been cancelled.
+// The returned error tells whether the zone change has been undone.
+//
+// @param name - the name of the zone
+// @param previous - the previous version of the zone, nil for a new zone
+// @param tagErr - the error tagging the journeys
+func (ucase *journeyUsecase) rollbackZone(name string, previous *domain.Zone, tagErr error) error {
+	ctx := context.Background()
+	var err error
+	if previous == nil {
+		if err = ucase.zoneRepo.Delete(ctx, name); err == nil {
+			err = ucase.journeyRepo.UntagZone(ctx, name)
+		}
+	} else {
+		if err = ucase.zoneRepo.Update(ctx, previous); err == nil {
+			err = ucase.journeyRepo.TagZone(ctx, previous)
+		}
+	}
+	if err != nil {
+		ucase.logger.Errorw("Error undoing a zone change",
+			"error", err.Error(),
+			"zone", name,
+		)
+		return fmt.Errorf("zone '%s' saved but its journeys are not tagged: %w", name, tagErr)
+	}
+	return fmt.Errorf("zone '%s' not saved, its journeys couldn't be tagged: %w", name, tagErr)
+}
+
+// requestContext returns the context of the request, cancelled when the client goes away
+func requestContext(c *gin.Context) context.Context {
+	if c.Request != nil {
+		return c.Request.Context()
+	}
+	return context.Background()
+}
+
+// Heatmap counts the stored journeys matching the filter by cell of a grid, where they start or end
+// in the bounding box of the request. Grids with more cells than configured are refused with a domain.HeatmapTooLargeError.
+//
+// @param request - grid, resolution and bounding box of the heatmap
+// @param filter - criteria of the journeys. Can be nil
+func (ucase *journeyUsecase) Heatmap(c *gin.Context, request *domain.HeatmapRequest, filter *domain.JourneyFilter) ([]domain.HeatmapCell, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	grid := heatmapGrid(request)
+	if nbCells := grid.Count(request.Bound); nbCells > ucase.cfg.Journey.Heatmap.MaxCells {
+		return nil, &domain.HeatmapTooLargeError{
+			NbCells:  nbCells,
+			MaxCells: ucase.cfg.Journey.Heatmap.MaxCells,
+		}
+	}
+
+	locationChan := make(chan *domain.JourneyLocation, exportBufferSize)
+	findErrorChan := make(chan error, 1)
+	go func() {
+		findErrorChan <- ucase.journeyRepo.FindLocations(requestContext(c), boundedFilter(filter, request.Side, request.Bound), locationChan)
+	}()
+
+	counts := map[geo.GridCell]int64{}
+	for location := range locationChan {
+		point := location.Point(request.Side)
+		if point != nil && request.Bound.Contains(*point) {
+			counts[grid.Cell(*point)]++
+		}
+	}
+	if err := <-findErrorChan; err != nil {
+		ucase.logger.Errorw("Error reading journeys to compute a heatmap",
+			"error", err.Error(),
+			"grid", request.Grid,
+		)
+		return nil, err
+	}
+
+	cells := make([]domain.HeatmapCell, 0, len(counts))
+	for cell, count := range counts {
+		cells = append(cells, domain.HeatmapCell{
+			Cell:       grid.Name(cell),
+			Polygon:    grid.Polygon(cell),
+			NbJourneys: count,
+		})
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		return cells[i].Cell < cells[j].Cell
+	})
+	return cells, nil
+}
+
+// heatmapGrid creates the grid of a heatmap, square and hexagonal cells are measured at the center of its bounding box
+func heatmapGrid(request *domain.HeatmapRequest) geo.Grid {
+	latitude := request.Bound.Center().Lat()
+	switch request.Grid {
+	case domain.HeatmapGridHex:
+		return geo.NewHexGrid(request.Resolution, latitude)
+	case domain.HeatmapGridGeohash:
+		return geo.NewGeohashGrid(int(request.Resolution))
+	default:
+		return geo.NewSquareGrid(request.Resolution, latitude)
+	}
+}
+
+// boundedFilter restricts the filter to the journeys starting or ending in a bounding box, unless it already
+// has a bounding box there, so that the search uses the location indexes. The points must still be checked.
+//
+// @param filter - criteria of the journeys. Can be nil
+// @param side - domain.LocationStart or domain.LocationEnd
+// @param bound - bounding box of the heatmap or of the tile
+func boundedFilter(filter *domain.JourneyFilter, side string, bound orb.Bound) *domain.JourneyFilter {
+	bounded := domain.JourneyFilter{}
+	if filter != nil {
+		bounded = *filter
+	}
+	if side == domain.LocationEnd {
+		if bounded.EndBbox == nil {
+			bounded.EndBbox = &domain.GeoBound{Bound: bound}
+		}
+	} else if bounded.StartBbox == nil {
+		bounded.StartBbox = &domain.GeoBound{Bound: bound}
+	}
+	return &bounded
+}
+
+// Tile extent in which the clusters of a vector tile are kept: the tile and a margin, so that lines leaving the tile
+// are drawn up to its edge
+var tileClipBound = orb.Bound{
+	Min: orb.Point{-mvt.DefaultExtent / 16, -mvt.DefaultExtent / 16},
+	Max: orb.Point{mvt.DefaultExtent * 17 / 16, mvt.DefaultExtent * 17 / 16},
+}
+
+// Tile generates the vector tile of a layer from the stored journeys matching the filter, with their clusters,
+// see domain.TileAggregator. Generated tiles are cached until the stored journeys change, by an import, a zone change
+// or an anomaly detection, or for the configured time.
+// Reading stops past the configured number of clustered journeys, larger tiles are refused with a domain.TileTooLargeError.
+//
+// @param request - layer and coordinates of the tile
+// @param filter - criteria of the journeys. Can be nil
+func (ucase *journeyUsecase) Tile(c *gin.Context, request *domain.TileRequest, filter *domain.JourneyFilter) ([]byte, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if filter != nil && filter.IsEmpty() {
+		filter = nil
+	}
+	key, err := json.Marshal(struct {
+		Request *domain.TileRequest
+		Filter  *domain.JourneyFilter
+	}{request, filter})
+	if err != nil {
+		return nil, err
+	}
+	if content, ok := ucase.tiles.get(string(key)); ok {
+		return content, nil
+	}
+
+	// OD lines are read with their start, then with their end when they start out of the tile
+	sides := []string{domain.LocationStart}
+	switch request.Layer {
+	case domain.TileLayerDestinations:
+		sides = []string{domain.LocationEnd}
+	case domain.TileLayerOD:
+		sides = []string{domain.LocationStart, domain.LocationEnd}
+	}
+	aggregator := domain.NewTileAggregator(request, ucase.cfg.Journey.Tiles.ClusterDepth)
+	ctx, cancel := context.WithCancel(requestContext(c))
+	defer cancel()
+	var nbJourneys int64
+	for _, side := range sides {
+		locationChan := make(chan *domain.JourneyLocation, exportBufferSize)
+		findErrorChan := make(chan error, 1)
+		go func(side string) {
+			findErrorChan <- ucase.journeyRepo.FindLocations(ctx, boundedFilter(filter, side, request.Tile.Bound()), locationChan)
+		}(side)
+
+		for location := range locationChan {
+			if side == domain.LocationEnd && request.Layer == domain.TileLayerOD && aggregator.Contains(location.Start) {
+				continue
+			}
+			if aggregator.Add(location) {
+				nbJourneys++
+			}
+			if nbJourneys > ucase.cfg.Journey.Tiles.MaxJourneys {
+				break
+			}
+		}
+		if nbJourneys > ucase.cfg.Journey.Tiles.MaxJourneys {
+			// The search stops on the cancellation, the locations already sent are dropped
+			cancel()
+			for range locationChan {
+			}
+			<-findErrorChan
+			return nil, &domain.TileTooLargeError{MaxJourneys: ucase.cfg.Journey.Tiles.MaxJourneys}
+		}
+		if err := <-findErrorChan; err != nil {
+			ucase.logger.Errorw("Error reading journeys to generate a tile",
+				"error", err.Error(),
+				"layer", request.Layer,
+				"tile", request.Tile,
+			)
+			return nil, err
+		}
+	}
+
+	layer := mvt.NewLayer(request.Layer, aggregator.Result())
+	layer.ProjectToTile(request.Tile)
+	layer.Clip(tileClipBound)
+	content, err := mvt.Marshal(mvt.Layers{layer})
+	if err != nil {
+		return nil, err
+	}
+	ucase.tiles.set(string(key), content)
+	return content, nil
 }
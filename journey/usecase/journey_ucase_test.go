@@ -2,24 +2,35 @@
 package usecase_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/coutcout/covoiturage-csvreader/configuration"
+	"github.com/coutcout/covoiturage-csvreader/domain"
 	"github.com/coutcout/covoiturage-csvreader/journey/service"
 	"github.com/coutcout/covoiturage-csvreader/journey/usecase"
 	"github.com/coutcout/covoiturage-csvreader/mocks"
 	"github.com/gin-gonic/gin"
 
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/maptile"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
 var logger zap.SugaredLogger
 var config *configuration.Config
+var schemas []*service.JourneySchema
 
 func init() {
 	newLogger, err := zap.NewDevelopment()
@@ -33,6 +44,88 @@ func init() {
 	if err != nil {
 		logger.Error(err)
 	}
+
+	schemas, err = service.LoadJourneySchemas(&logger, config)
+	if err != nil {
+		logger.Error(err)
+	}
+}
+
+func journeyParsers() domain.JourneyParserRegistry {
+	return service.NewJourneyParserRegistry(&logger, config, schemas)
+}
+
+func journeyExporters() domain.JourneyExporterRegistry {
+	return service.NewJourneyExporterRegistry(&logger, config, schemas)
+}
+
+func tripExporters() domain.TripExporterRegistry {
+	return service.NewTripExporterRegistry(&logger)
+}
+
+func journeySplitter() domain.JourneySplitter {
+	return service.NewJourneySplitter(&logger, journeyParsers())
+}
+
+// journeyUsecaseDeps holds the collaborators of the usecase which a test overrides, the zero values taking the defaults
+type journeyUsecaseDeps struct {
+	cfg        *configuration.Config
+	jRepo      domain.JourneyRepositoryInterface
+	dsRepo     domain.DailyStatsRepositoryInterface
+	zRepo      domain.ZoneRepositoryInterface
+	processors *domain.JourneyProcessorChain
+}
+
+// newJourneyUsecase builds the usecase with the test configuration and default collaborators, except the overridden ones
+func newJourneyUsecase(t *testing.T, deps journeyUsecaseDeps) domain.JourneyUsecase {
+	t.Helper()
+	if deps.cfg == nil {
+		deps.cfg = config
+	}
+	if deps.jRepo == nil {
+		deps.jRepo = new(mocks.JourneyRepositoryInterface)
+	}
+	if deps.dsRepo == nil {
+		deps.dsRepo = dailyStatsRepo()
+	}
+	if deps.zRepo == nil {
+		deps.zRepo = zoneRepo()
+	}
+	if deps.processors == nil {
+		deps.processors = domain.NewJourneyProcessorChain()
+	}
+
+	journeyUsecase, err := usecase.NewJourneyUsecase(
+		&logger,
+		deps.cfg,
+		deps.jRepo,
+		deps.dsRepo,
+		deps.zRepo,
+		journeyParsers(),
+		journeyExporters(),
+		tripExporters(),
+		journeySplitter(),
+		deps.processors,
+	)
+	require.NoError(t, err)
+	return journeyUsecase
+}
+
+func dailyStatsRepo() *mocks.DailyStatsRepositoryInterface {
+	dsRepo := new(mocks.DailyStatsRepositoryInterface)
+	dsRepo.On("Increment", mock.Anything, mock.AnythingOfType("[]domain.Journey")).Return(nil)
+	return dsRepo
+}
+
+// storeAll stores all the journeys given to the mocked repository
+func storeAll(_ *gin.Context, journeys []domain.Journey) []domain.Journey {
+	return journeys
+}
+
+func zoneRepo() *mocks.ZoneRepositoryInterface {
+	zRepo := new(mocks.ZoneRepositoryInterface)
+	zRepo.On("List", mock.Anything).Return([]domain.Zone{}, nil)
+	return zRepo
 }
 
 func TestImportFromCSVFile(t *testing.T) {
@@ -48,7 +141,8 @@ func TestImportFromCSVFile(t *testing.T) {
 		{"27fields_case", "dataset_27fields.csv", 5, false},
 		{"empty_file_case", "dataset_empty.csv", 0, false},
 		{"headers_only_case", "dataset_headersOnly.csv", 0, false},
-		{"json", "dataset_1.json", 0, true},
+		{"json", "dataset_1.json", 3, false},
+		{"enum_values_case", "dataset_enums.csv", 1, true},
 	}
 
 	for _, test := range tests {
@@ -57,23 +151,17 @@ func TestImportFromCSVFile(t *testing.T) {
 			defer f.Close()
 
 			jRepo := new(mocks.JourneyRepositoryInterface)
-			jRepo.On("Add", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("[]domain.Journey")).Return(test.nbAdded, nil)
-			jCsvParser := service.NewJourneyCsvParser(&logger, config)
+			jRepo.On("Add", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("[]domain.Journey")).Return(storeAll, nil)
 
-			journeyUsecase := usecase.NewJourneyUsecase(
-				&logger,
-				config,
-				jRepo,
-				jCsvParser,
-			)
-			nbJourneyImported, err := journeyUsecase.ImportFromCSVFile(&gin.Context{}, f)
+			journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+			summary, err := journeyUsecase.ImportFromFile(&gin.Context{}, &domain.JourneyFile{Name: test.filename, Reader: f}, nil)
 
 			if test.shouldHaveErrors {
 				assert.NotEmpty(t, err)
 			} else {
 				assert.Empty(t, err)
 			}
-			assert.Equal(t, test.nbAdded, int(nbJourneyImported))
+			assert.Equal(t, test.nbAdded, int(summary.NbJourneyImported))
 
 			logger.Debugw("End of the test",
 				"file", test.filename,
@@ -82,3 +170,788 @@ func TestImportFromCSVFile(t *testing.T) {
 	}
 
 }
+
+func TestImportFromCSVFile_withProcessors(t *testing.T) {
+	f, _ := os.Open(filepath.Join("testdata", "dataset_1.csv"))
+	defer f.Close()
+
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("Add", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("[]domain.Journey")).Return(storeAll, nil)
+
+	failingProcessor := new(mocks.JourneyProcessor)
+	failingProcessor.On("Process", mock.MatchedBy(func(j *domain.Journey) bool { return j.JourneyId == 5511504 })).Return(false, errors.New("processing error"))
+	failingProcessor.On("Process", mock.AnythingOfType("*domain.Journey")).Return(true, nil)
+
+	filteringProcessor := new(mocks.JourneyProcessor)
+	filteringProcessor.On("Process", mock.MatchedBy(func(j *domain.Journey) bool { return j.OperatorClass == "B" })).Return(false, nil)
+	filteringProcessor.On("Process", mock.AnythingOfType("*domain.Journey")).Return(true, nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{
+		jRepo: jRepo,
+		processors: domain.NewJourneyProcessorChain(
+			domain.NamedJourneyProcessor{Name: "failing", Processor: failingProcessor},
+			domain.NamedJourneyProcessor{Name: "filtering", Processor: filteringProcessor},
+		),
+	})
+	summary, err := journeyUsecase.ImportFromFile(&gin.Context{}, &domain.JourneyFile{Name: "dataset_1.csv", Reader: f}, nil)
+
+	assert.Len(t, err, 1)
+	assert.Contains(t, err[0], "5511504")
+	assert.Contains(t, err[0], "failing")
+	assert.Equal(t, 1, int(summary.NbJourneyImported))
+	assert.Equal(t, []domain.ProcessorStats{
+		{Name: "failing", NbProcessed: 3, NbDropped: 0, NbErrors: 1},
+		{Name: "filtering", NbProcessed: 2, NbDropped: 1, NbErrors: 0},
+	}, summary.Processors)
+}
+
+func TestImportFromCSVFile_withFilter(t *testing.T) {
+	f, _ := os.Open(filepath.Join("testdata", "dataset_1.csv"))
+	defer f.Close()
+
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("Add", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("[]domain.Journey")).Return(storeAll, nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+	summary, err := journeyUsecase.ImportFromFile(&gin.Context{}, &domain.JourneyFile{Name: "dataset_1.csv", Reader: f}, &domain.JourneyFilter{Departments: []string{"78"}})
+
+	assert.Empty(t, err)
+	assert.Equal(t, 2, int(summary.NbJourneyImported))
+	assert.Equal(t, 1, int(summary.NbJourneyFilteredOut))
+}
+
+func TestImportFromCSVFile_dailyStats(t *testing.T) {
+	f, _ := os.Open(filepath.Join("testdata", "dataset_1.csv"))
+	defer f.Close()
+
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("Add", mock.AnythingOfType("*gin.Context"), mock.MatchedBy(func(journeys []domain.Journey) bool {
+		return journeys[0].JourneyId == 5511504
+	})).Return(nil, errors.New("insertion error"))
+	jRepo.On("Add", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("[]domain.Journey")).Return(storeAll, nil)
+
+	dsRepo := new(mocks.DailyStatsRepositoryInterface)
+	dsRepo.On("Increment", mock.Anything, mock.MatchedBy(func(journeys []domain.Journey) bool {
+		return journeys[0].JourneyId == 5511507
+	})).Return(errors.New("update error"))
+	dsRepo.On("Increment", mock.Anything, mock.AnythingOfType("[]domain.Journey")).Return(nil)
+
+	// A bulk per journey
+	cfg := *config
+	cfg.Journey.Insertion.WorkerPoolSize = 1
+	cfg.Journey.Insertion.BulkInsertSize = 1
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{cfg: &cfg, jRepo: jRepo, dsRepo: dsRepo})
+	_, err := journeyUsecase.ImportFromFile(&gin.Context{}, &domain.JourneyFile{Name: "dataset_1.csv", Reader: f}, nil)
+
+	assert.Len(t, err, 2)
+	assert.Contains(t, strings.Join(err, "\n"), "0 of 1 journeys stored: insertion error")
+	assert.Contains(t, strings.Join(err, "\n"), "daily statistics not updated, they must be rebuilt: update error")
+
+	var incremented []int64
+	for _, call := range dsRepo.Calls {
+		for _, journey := range call.Arguments.Get(1).([]domain.Journey) {
+			incremented = append(incremented, journey.JourneyId)
+		}
+	}
+	assert.ElementsMatch(t, []int64{5492402, 5511507}, incremented)
+}
+
+func TestImportFromCSVFile_partialInsertion(t *testing.T) {
+	f, _ := os.Open(filepath.Join("testdata", "dataset_1.csv"))
+	defer f.Close()
+
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("Add", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("[]domain.Journey")).
+		Return(func(_ *gin.Context, journeys []domain.Journey) ([]domain.Journey, error) {
+			inserted := []domain.Journey{}
+			for _, journey := range journeys {
+				if journey.JourneyId != 5511504 {
+					inserted = append(inserted, journey)
+				}
+			}
+			return inserted, errors.New("validation error")
+		})
+
+	var incremented []domain.Journey
+	dsRepo := new(mocks.DailyStatsRepositoryInterface)
+	dsRepo.On("Increment", mock.Anything, mock.AnythingOfType("[]domain.Journey")).
+		Run(func(args mock.Arguments) {
+			incremented = args.Get(1).([]domain.Journey)
+		}).
+		Return(nil)
+
+	// A single bulk of the 3 journeys
+	cfg := *config
+	cfg.Journey.Insertion.WorkerPoolSize = 1
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{cfg: &cfg, jRepo: jRepo, dsRepo: dsRepo})
+	summary, err := journeyUsecase.ImportFromFile(&gin.Context{}, &domain.JourneyFile{Name: "dataset_1.csv", Reader: f}, nil)
+
+	assert.Len(t, err, 1)
+	assert.Contains(t, err[0], "2 of 3 journeys stored: validation error")
+	assert.Equal(t, 2, int(summary.NbJourneyImported))
+	assert.Len(t, incremented, 2)
+	assert.ElementsMatch(t, []int64{5492402, 5511507}, []int64{incremented[0].JourneyId, incremented[1].JourneyId})
+}
+
+func TestImportFromCSVFile_reimport(t *testing.T) {
+	// Repository skipping the journeys already stored, like the unique index on their id
+	var mutex sync.Mutex
+	stored := map[int64]bool{}
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("Add", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("[]domain.Journey")).
+		Return(func(_ *gin.Context, journeys []domain.Journey) []domain.Journey {
+			mutex.Lock()
+			defer mutex.Unlock()
+			inserted := []domain.Journey{}
+			for _, journey := range journeys {
+				if !stored[journey.JourneyId] {
+					stored[journey.JourneyId] = true
+					inserted = append(inserted, journey)
+				}
+			}
+			return inserted
+		}, nil)
+
+	var incremented []int64
+	dsRepo := new(mocks.DailyStatsRepositoryInterface)
+	dsRepo.On("Increment", mock.Anything, mock.AnythingOfType("[]domain.Journey")).
+		Run(func(args mock.Arguments) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, journey := range args.Get(1).([]domain.Journey) {
+				incremented = append(incremented, journey.JourneyId)
+			}
+		}).
+		Return(nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo, dsRepo: dsRepo})
+	for i, nbImported := range []int{3, 0} {
+		f, _ := os.Open(filepath.Join("testdata", "dataset_1.csv"))
+		summary, err := journeyUsecase.ImportFromFile(&gin.Context{}, &domain.JourneyFile{Name: "dataset_1.csv", Reader: f}, nil)
+		f.Close()
+
+		assert.Empty(t, err, "import %d", i)
+		assert.Equal(t, nbImported, int(summary.NbJourneyImported), "import %d", i)
+	}
+	assert.ElementsMatch(t, []int64{5492402, 5511504, 5511507}, incremented)
+}
+
+func TestImportFromFile_json(t *testing.T) {
+	f, _ := os.Open(filepath.Join("testdata", "dataset_1.json"))
+	defer f.Close()
+
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("Add", mock.AnythingOfType("*gin.Context"), mock.AnythingOfType("[]domain.Journey")).Return(storeAll, nil)
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+	summary, err := journeyUsecase.ImportFromFile(&gin.Context{}, &domain.JourneyFile{Name: "dataset_1.json", Reader: f}, nil)
+
+	assert.Empty(t, err)
+	assert.Equal(t, domain.JourneyFormatJSON, summary.Format)
+	assert.Equal(t, 3, int(summary.NbJourneyImported))
+}
+
+func TestImportFromFile_unsupportedFormat(t *testing.T) {
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+	summary, err := journeyUsecase.ImportFromFile(&gin.Context{}, &domain.JourneyFile{
+		Name:        "journeys.xlsx",
+		ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		Reader:      strings.NewReader("PK\x03\x04"),
+	}, nil)
+
+	assert.Len(t, err, 1)
+	assert.Contains(t, err[0], "unsupported format")
+	assert.Equal(t, 0, int(summary.NbJourneyImported))
+	jRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+}
+
+func TestExport(t *testing.T) {
+	filter := &domain.JourneyFilter{Departments: []string{"35"}}
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("Find", mock.Anything, filter, mock.Anything).
+		Run(func(args mock.Arguments) {
+			journeyChan := args.Get(2).(chan<- *domain.Journey)
+			journeyChan <- &domain.Journey{JourneyId: 1}
+			journeyChan <- &domain.Journey{JourneyId: 2}
+			close(journeyChan)
+		}).
+		Return(nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+	exporter, err := journeyUsecase.Exporter(domain.JourneyFormatParquet)
+	assert.NoError(t, err)
+
+	buffer := &bytes.Buffer{}
+	nbExported, err := journeyUsecase.Export(&gin.Context{}, exporter, filter, buffer)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), nbExported)
+	assert.True(t, bytes.HasPrefix(buffer.Bytes(), []byte("PAR1")))
+}
+
+func TestExport_findError(t *testing.T) {
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("Find", mock.Anything, (*domain.JourneyFilter)(nil), mock.Anything).
+		Run(func(args mock.Arguments) {
+			close(args.Get(2).(chan<- *domain.Journey))
+		}).
+		Return(errors.New("database unavailable"))
+
+	exporter := new(mocks.JourneyExporter)
+	exporter.On("Export", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			for range args.Get(1).(<-chan *domain.Journey) {
+			}
+		}).
+		Return(int64(0), nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+	_, err := journeyUsecase.Export(&gin.Context{}, exporter, &domain.JourneyFilter{}, &bytes.Buffer{})
+
+	assert.EqualError(t, err, "database unavailable")
+}
+
+func TestExport_tooLarge(t *testing.T) {
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("Count", mock.Anything, (*domain.JourneyFilter)(nil)).Return(int64(4), nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+	// The test configuration limits the GeoJSON exports to 3 journeys
+	exporter, err := journeyUsecase.Exporter(domain.JourneyFormatGeoJSONLine)
+	assert.NoError(t, err)
+
+	buffer := &bytes.Buffer{}
+	_, err = journeyUsecase.Export(&gin.Context{}, exporter, nil, buffer)
+
+	var tooLarge *domain.ExportTooLargeError
+	assert.ErrorAs(t, err, &tooLarge)
+	assert.Equal(t, int64(3), tooLarge.MaxJourneys)
+	assert.Empty(t, buffer.Bytes())
+	jRepo.AssertNotCalled(t, "Find", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSplit(t *testing.T) {
+	f, _ := os.Open(filepath.Join("testdata", "dataset_1.csv"))
+	defer f.Close()
+
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+	exporter, err := journeyUsecase.Exporter(domain.JourneyFormatCSV)
+	assert.NoError(t, err)
+
+	buffer := &bytes.Buffer{}
+	summary, err := journeyUsecase.Split(&gin.Context{}, &domain.JourneyFile{Name: "dataset_1.csv", Reader: f}, domain.JourneySplitStartDepartment, exporter, buffer)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), summary.NbJourneys)
+	assert.Len(t, summary.Parts, 3)
+	assert.NotEmpty(t, buffer.Bytes())
+	jRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+}
+
+func TestODMatrix(t *testing.T) {
+	pairs := []domain.ODPair{{Origin: "35", Destination: "56", NbJourneys: 2, PassengerSeats: 3, AverageDistance: 12000, AverageDuration: 900}}
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("ODMatrix", mock.Anything, domain.ODLevelDepartment, (*domain.JourneyFilter)(nil)).Return(pairs, nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+
+	result, err := journeyUsecase.ODMatrix(&gin.Context{}, domain.ODLevelDepartment, &domain.JourneyFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, pairs, result)
+
+	_, err = journeyUsecase.ODMatrix(&gin.Context{}, "region", nil)
+	assert.Error(t, err)
+	jRepo.AssertNumberOfCalls(t, "ODMatrix", 1)
+}
+
+func TestStats(t *testing.T) {
+	filter := &domain.JourneyFilter{Departments: []string{"35"}}
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("Stats", mock.Anything, domain.StatPerOperatorClass, filter).
+		Return([]domain.StatBucket{{Key: "B", NbJourneys: 1}, {Key: "C", NbJourneys: 3}}, nil)
+	jRepo.On("Stats", mock.Anything, domain.StatPerHour, (*domain.JourneyFilter)(nil)).
+		Return([]domain.StatBucket{{Key: "8", NbJourneys: 2}}, nil)
+	dsRepo := new(mocks.DailyStatsRepositoryInterface)
+	dsRepo.On("Stats", mock.Anything, domain.StatPerOperatorClass).
+		Return([]domain.StatBucket{{Key: "B", NbJourneys: 3}, {Key: "C", NbJourneys: 1}}, nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo, dsRepo: dsRepo})
+
+	buckets, err := journeyUsecase.Stats(&gin.Context{}, domain.StatPerOperatorClass, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.StatBucket{{Key: "B", NbJourneys: 1, Share: 0.25}, {Key: "C", NbJourneys: 3, Share: 0.75}}, buckets)
+
+	// Without filter, the daily counters are summed
+	buckets, err = journeyUsecase.Stats(&gin.Context{}, domain.StatPerOperatorClass, &domain.JourneyFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.StatBucket{{Key: "B", NbJourneys: 3, Share: 0.75}, {Key: "C", NbJourneys: 1, Share: 0.25}}, buckets)
+
+	buckets, err = journeyUsecase.Stats(&gin.Context{}, domain.StatPerHour, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.StatBucket{{Key: "8", NbJourneys: 2, Share: 1}}, buckets)
+}
+
+func TestTrips(t *testing.T) {
+	filter := &domain.JourneyFilter{Departments: []string{"35"}}
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("FindTrips", mock.Anything, filter, int64(10), int64(5), mock.Anything).
+		Run(func(args mock.Arguments) {
+			tripChan := args.Get(4).(chan<- *domain.Trip)
+			tripChan <- domain.NewTrip([]domain.Journey{{JourneyId: 1}, {JourneyId: 2}})
+			close(tripChan)
+		}).
+		Return(nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+	trips, err := journeyUsecase.Trips(&gin.Context{}, filter, 10, 5)
+
+	assert.NoError(t, err)
+	assert.Len(t, trips, 1)
+	assert.Equal(t, int64(2), trips[0].NbPassengers)
+}
+
+func TestExportTrips(t *testing.T) {
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("FindTrips", mock.Anything, (*domain.JourneyFilter)(nil), int64(0), int64(0), mock.Anything).
+		Run(func(args mock.Arguments) {
+			tripChan := args.Get(4).(chan<- *domain.Trip)
+			tripChan <- domain.NewTrip([]domain.Journey{{JourneyId: 1}})
+			tripChan <- domain.NewTrip([]domain.Journey{{JourneyId: 2}})
+			close(tripChan)
+		}).
+		Return(errors.New("cursor error"))
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+	exporter, err := journeyUsecase.TripExporter(domain.JourneyFormatCSV)
+	assert.NoError(t, err)
+
+	buffer := &bytes.Buffer{}
+	nbExported, err := journeyUsecase.ExportTrips(&gin.Context{}, exporter, &domain.JourneyFilter{}, buffer)
+
+	assert.EqualError(t, err, "cursor error")
+	assert.Equal(t, int64(2), nbExported)
+	assert.Equal(t, 3, strings.Count(buffer.String(), "\n"))
+}
+
+func TestCo2(t *testing.T) {
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("Co2", mock.Anything, domain.JourneySplitMonth, (*domain.JourneyFilter)(nil)).
+		Return([]domain.Co2Bucket{{Key: "2023-01", NbJourneys: 3, PassengerKm: 100}}, nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+
+	report, err := journeyUsecase.Co2(&gin.Context{}, domain.JourneySplitMonth, "", &domain.JourneyFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2023", report.Version)
+	assert.Equal(t, []domain.Co2Bucket{{Key: "2023-01", NbJourneys: 3, PassengerKm: 100, VehicleKmAvoided: 50, Co2Saved: 5}}, report.Buckets)
+
+	report, err = journeyUsecase.Co2(&gin.Context{}, domain.JourneySplitMonth, "2022", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Co2Bucket{{Key: "2023-01", NbJourneys: 3, PassengerKm: 100, VehicleKmAvoided: 100, Co2Saved: 20}}, report.Buckets)
+
+	_, err = journeyUsecase.Co2(&gin.Context{}, domain.JourneySplitMonth, "1990", nil)
+	assert.EqualError(t, err, "unknown emission factors version '1990'")
+}
+
+func TestSimulateIncentive(t *testing.T) {
+	filter := &domain.JourneyFilter{Departments: []string{"35"}}
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("FindByStart", mock.Anything, filter, mock.Anything).
+		Run(func(args mock.Arguments) {
+			journeyChan := args.Get(2).(chan<- *domain.Journey)
+			journeyChan <- &domain.Journey{JourneyId: 1, JourneyDistance: 10000, JourneyStartInsee: 35238}
+			journeyChan <- &domain.Journey{JourneyId: 2, JourneyDistance: 1000, JourneyStartInsee: 35238}
+			journeyChan <- &domain.Journey{JourneyId: 3, JourneyDistance: 20000, JourneyStartInsee: 35238}
+			close(journeyChan)
+		}).
+		Return(nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+	policy := &domain.IncentivePolicy{
+		DistanceBands: []domain.IncentiveDistanceBand{{MinDistance: 5000, Fixed: 2, PerKm: 0.1}},
+	}
+
+	result, err := journeyUsecase.SimulateIncentive(&gin.Context{}, policy, filter, 1, 10, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), result.NbJourneys)
+	assert.Equal(t, int64(2), result.NbEligible)
+	assert.Equal(t, int64(2), result.NbPaid)
+	assert.Equal(t, 7.0, result.TotalCost)
+	assert.Len(t, result.Journeys, 1)
+	assert.Equal(t, int64(3), result.Journeys[0].JourneyId)
+
+	// Every paid journey is sent, the first error stops the simulation
+	paid := []int64{}
+	_, err = journeyUsecase.SimulateIncentive(&gin.Context{}, policy, filter, 0, 0, func(journey *domain.IncentiveJourney) error {
+		paid = append(paid, journey.JourneyId)
+		return errors.New("write error")
+	})
+	assert.EqualError(t, err, "write error")
+	assert.Equal(t, []int64{1}, paid)
+
+	_, err = journeyUsecase.SimulateIncentive(&gin.Context{}, &domain.IncentivePolicy{}, filter, 0, 10, nil)
+	assert.Error(t, err)
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	filter := &domain.JourneyFilter{Departments: []string{"35"}}
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("Find", mock.Anything, filter, mock.Anything).
+		Run(func(args mock.Arguments) {
+			journeyChan := args.Get(2).(chan<- *domain.Journey)
+			journeyChan <- &domain.Journey{JourneyId: 2, JourneyDistance: 100000, JourneyDuration: 20}
+			journeyChan <- &domain.Journey{JourneyId: 1, JourneyDistance: 10000, JourneyDuration: 20}
+			close(journeyChan)
+		}).
+		Return(nil)
+	jRepo.On("ReplaceAnomalies", mock.Anything, filter, []domain.JourneyAnomalies{
+		{JourneyId: 2, Anomalies: []string{domain.AnomalySpeed}, Score: 1},
+	}).Return(nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+
+	summary, err := journeyUsecase.DetectAnomalies(&gin.Context{}, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.AnomalySummary{
+		NbJourneys: 2,
+		NbFlagged:  1,
+		ByAnomaly: map[string]int64{
+			domain.AnomalySpeed:       1,
+			domain.AnomalyDistance:    0,
+			domain.AnomalyTripOverlap: 0,
+			domain.AnomalyBurst:       0,
+		},
+	}, summary)
+	jRepo.AssertExpectations(t)
+}
+
+func TestAnomalies(t *testing.T) {
+	journeys := []domain.Journey{{JourneyId: 1, Anomalies: []string{domain.AnomalyBurst}, AnomalyScore: 1}}
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("FindAnomalies", mock.Anything, (*domain.JourneyFilter)(nil), domain.AnomalyBurst, int64(0), int64(10)).Return(journeys, nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+
+	result, err := journeyUsecase.Anomalies(&gin.Context{}, &domain.JourneyFilter{}, domain.AnomalyBurst, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, journeys, result)
+
+	_, err = journeyUsecase.Anomalies(&gin.Context{}, nil, "teleport", 0, 10)
+	assert.Error(t, err)
+}
+
+func TestImportFromCSVFile_zones(t *testing.T) {
+	f, _ := os.Open(filepath.Join("testdata", "dataset_1.csv"))
+	defer f.Close()
+
+	zRepo := new(mocks.ZoneRepositoryInterface)
+	zRepo.On("List", mock.Anything).Return([]domain.Zone{
+		{Name: "mantes", Geometry: orb.Polygon{{{1.6, 48.95}, {1.8, 48.95}, {1.8, 49.05}, {1.6, 49.05}, {1.6, 48.95}}}},
+	}, nil)
+	var added []domain.Journey
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("Add", mock.Anything, mock.AnythingOfType("[]domain.Journey")).
+		Run(func(args mock.Arguments) {
+			added = append(added, args.Get(1).([]domain.Journey)...)
+		}).
+		Return(storeAll, nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo, zRepo: zRepo})
+	_, err := journeyUsecase.ImportFromFile(&gin.Context{}, &domain.JourneyFile{Name: "dataset_1.csv", Reader: f}, nil)
+
+	assert.Empty(t, err)
+	assert.Len(t, added, 3)
+	for _, journey := range added {
+		if journey.JourneyId == 5492402 {
+			assert.Equal(t, []string{"mantes"}, journey.StartZones)
+		} else {
+			assert.Empty(t, journey.StartZones)
+		}
+		assert.Empty(t, journey.EndZones)
+	}
+}
+
+func TestCreateZone(t *testing.T) {
+	zone := &domain.Zone{Name: "vannes", Geometry: orb.Polygon{{{-2.8, 47.6}, {-2.7, 47.6}, {-2.7, 47.7}, {-2.8, 47.6}}}}
+	zRepo := new(mocks.ZoneRepositoryInterface)
+	zRepo.On("Create", mock.Anything, zone).Return(nil).Once()
+	zRepo.On("Create", mock.Anything, zone).Return(domain.ErrZoneExists)
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("TagZone", mock.Anything, zone).Return(nil).Once()
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo, zRepo: zRepo})
+
+	assert.NoError(t, journeyUsecase.CreateZone(&gin.Context{}, zone))
+	assert.ErrorIs(t, journeyUsecase.CreateZone(&gin.Context{}, zone), domain.ErrZoneExists)
+	assert.Error(t, journeyUsecase.CreateZone(&gin.Context{}, &domain.Zone{Name: "vannes centre", Geometry: zone.Geometry}))
+	jRepo.AssertExpectations(t)
+}
+
+func TestCreateZone_tagError(t *testing.T) {
+	zone := &domain.Zone{Name: "vannes", Geometry: orb.Polygon{{{-2.8, 47.6}, {-2.7, 47.6}, {-2.7, 47.7}, {-2.8, 47.6}}}}
+	zRepo := new(mocks.ZoneRepositoryInterface)
+	zRepo.On("Create", mock.Anything, zone).Return(nil)
+	zRepo.On("Delete", mock.Anything, "vannes").Return(nil)
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("TagZone", mock.Anything, zone).Return(errors.New("tag error"))
+	jRepo.On("UntagZone", mock.Anything, "vannes").Return(nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo, zRepo: zRepo})
+
+	err := journeyUsecase.CreateZone(&gin.Context{}, zone)
+	assert.ErrorContains(t, err, "not saved")
+	assert.ErrorContains(t, err, "tag error")
+	zRepo.AssertExpectations(t)
+	jRepo.AssertExpectations(t)
+}
+
+func TestUpdateZone(t *testing.T) {
+	previous := &domain.Zone{Name: "vannes", Geometry: orb.Polygon{{{-2.8, 47.6}, {-2.7, 47.6}, {-2.7, 47.7}, {-2.8, 47.6}}}}
+	zone := &domain.Zone{Name: "vannes", Geometry: orb.Polygon{{{-2.9, 47.5}, {-2.7, 47.5}, {-2.7, 47.7}, {-2.9, 47.5}}}}
+	zRepo := new(mocks.ZoneRepositoryInterface)
+	zRepo.On("Get", mock.Anything, "vannes").Return(previous, nil)
+	zRepo.On("Update", mock.Anything, zone).Return(nil)
+	zRepo.On("Update", mock.Anything, previous).Return(nil).Once()
+	zRepo.On("Update", mock.Anything, previous).Return(errors.New("update error"))
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("TagZone", mock.Anything, zone).Return(nil).Once()
+	jRepo.On("TagZone", mock.Anything, zone).Return(errors.New("tag error"))
+	jRepo.On("TagZone", mock.Anything, previous).Return(nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo, zRepo: zRepo})
+
+	assert.NoError(t, journeyUsecase.UpdateZone(&gin.Context{}, zone))
+
+	// The previous version of the zone is restored
+	err := journeyUsecase.UpdateZone(&gin.Context{}, zone)
+	assert.ErrorContains(t, err, "not saved")
+	jRepo.AssertCalled(t, "TagZone", mock.Anything, previous)
+
+	// The previous version of the zone can't be restored
+	err = journeyUsecase.UpdateZone(&gin.Context{}, zone)
+	assert.ErrorContains(t, err, "saved but its journeys are not tagged")
+	assert.ErrorContains(t, err, "tag error")
+}
+
+func TestDeleteZone(t *testing.T) {
+	zRepo := new(mocks.ZoneRepositoryInterface)
+	zRepo.On("Delete", mock.Anything, "vannes").Return(nil)
+	zRepo.On("Delete", mock.Anything, "lorient").Return(domain.ErrZoneNotFound)
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("UntagZone", mock.Anything, "vannes").Return(nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo, zRepo: zRepo})
+
+	assert.NoError(t, journeyUsecase.DeleteZone(&gin.Context{}, "vannes"))
+	assert.ErrorIs(t, journeyUsecase.DeleteZone(&gin.Context{}, "lorient"), domain.ErrZoneNotFound)
+	jRepo.AssertExpectations(t)
+	jRepo.AssertNumberOfCalls(t, "UntagZone", 1)
+}
+
+func TestHeatmap(t *testing.T) {
+	request := &domain.HeatmapRequest{
+		Grid:       domain.HeatmapGridGeohash,
+		Side:       domain.LocationEnd,
+		Resolution: 4,
+		Bound:      orb.Bound{Min: orb.Point{-2, 47}, Max: orb.Point{-1, 48.5}},
+	}
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("FindLocations", mock.Anything, &domain.JourneyFilter{
+		Departments: []string{"35"},
+		EndBbox:     &domain.GeoBound{Bound: request.Bound},
+	}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			locationChan := args.Get(2).(chan<- *domain.JourneyLocation)
+			locationChan <- &domain.JourneyLocation{End: &orb.Point{-1.68, 48.11}}
+			locationChan <- &domain.JourneyLocation{Start: &orb.Point{-1.55, 47.21}, End: &orb.Point{-1.681, 48.112}}
+			locationChan <- &domain.JourneyLocation{End: &orb.Point{-1.55, 47.21}}
+			locationChan <- &domain.JourneyLocation{Start: &orb.Point{-1.68, 48.11}}
+			locationChan <- &domain.JourneyLocation{End: &orb.Point{2.35, 48.85}}
+			close(locationChan)
+		}).
+		Return(nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+
+	cells, err := journeyUsecase.Heatmap(&gin.Context{}, request, &domain.JourneyFilter{Departments: []string{"35"}})
+	assert.NoError(t, err)
+	assert.Len(t, cells, 2)
+	assert.Equal(t, "gbqu", cells[0].Cell)
+	assert.Equal(t, int64(1), cells[0].NbJourneys)
+	assert.Equal(t, "gbwc", cells[1].Cell)
+	assert.Equal(t, int64(2), cells[1].NbJourneys)
+
+	request.Resolution = 7
+	var tooLarge *domain.HeatmapTooLargeError
+	_, err = journeyUsecase.Heatmap(&gin.Context{}, request, nil)
+	assert.ErrorAs(t, err, &tooLarge)
+	jRepo.AssertNumberOfCalls(t, "FindLocations", 1)
+}
+
+func TestTile(t *testing.T) {
+	request := &domain.TileRequest{Layer: domain.TileLayerOD, Tile: maptile.At(orb.Point{-1.68, 48.11}, 10)}
+	bound := &domain.GeoBound{Bound: request.Tile.Bound()}
+	sendLocations := func(locations ...*domain.JourneyLocation) func(args mock.Arguments) {
+		return func(args mock.Arguments) {
+			locationChan := args.Get(2).(chan<- *domain.JourneyLocation)
+			for _, location := range locations {
+				locationChan <- location
+			}
+			close(locationChan)
+		}
+	}
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("FindLocations", mock.Anything, &domain.JourneyFilter{StartBbox: bound}, mock.Anything).
+		Run(sendLocations(&domain.JourneyLocation{Start: &orb.Point{-1.68, 48.11}, End: &orb.Point{-1.55, 47.21}})).
+		Return(nil)
+	jRepo.On("FindLocations", mock.Anything, &domain.JourneyFilter{EndBbox: bound}, mock.Anything).
+		Run(sendLocations(
+			&domain.JourneyLocation{Start: &orb.Point{-1.68, 48.11}, End: &orb.Point{-1.681, 48.111}},
+			&domain.JourneyLocation{Start: &orb.Point{-1.55, 47.21}, End: &orb.Point{-1.68, 48.11}},
+		)).
+		Return(nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+
+	content, err := journeyUsecase.Tile(&gin.Context{}, request, &domain.JourneyFilter{})
+	assert.NoError(t, err)
+	layers, err := mvt.Unmarshal(content)
+	assert.NoError(t, err)
+	assert.Len(t, layers, 1)
+	assert.Equal(t, domain.TileLayerOD, layers[0].Name)
+	assert.Len(t, layers[0].Features, 2)
+
+	cached, err := journeyUsecase.Tile(&gin.Context{}, request, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, content, cached)
+	jRepo.AssertNumberOfCalls(t, "FindLocations", 2)
+
+	_, err = journeyUsecase.Tile(&gin.Context{}, &domain.TileRequest{Layer: "trips", Tile: request.Tile}, nil)
+	assert.Error(t, err)
+}
+
+func TestTile_odJourneysCountedOnce(t *testing.T) {
+	request := &domain.TileRequest{Layer: domain.TileLayerOD, Tile: maptile.At(orb.Point{-1.68, 48.11}, 10)}
+	// Read with their start, then with their end: each of them is clustered once
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("FindLocations", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			locationChan := args.Get(2).(chan<- *domain.JourneyLocation)
+			for i := 0; i < 3; i++ {
+				locationChan <- &domain.JourneyLocation{Start: &orb.Point{-1.68, 48.11}, End: &orb.Point{-1.681, 48.111}}
+			}
+			close(locationChan)
+		}).
+		Return(nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+
+	_, err := journeyUsecase.Tile(&gin.Context{}, request, nil)
+	assert.NoError(t, err)
+	jRepo.AssertNumberOfCalls(t, "FindLocations", 2)
+}
+
+func TestNewJourneyUsecase_wrongTileSettings(t *testing.T) {
+	var tests = []struct {
+		setting string
+		change  func(cfg *configuration.Config)
+	}{
+		{"cluster-depth", func(cfg *configuration.Config) { cfg.Journey.Tiles.ClusterDepth = -1 }},
+		{"max-journeys", func(cfg *configuration.Config) { cfg.Journey.Tiles.MaxJourneys = 0 }},
+		{"cache-size", func(cfg *configuration.Config) { cfg.Journey.Tiles.CacheSize = -1 }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.setting, func(t *testing.T) {
+			cfg := *config
+			test.change(&cfg)
+
+			journeyUsecase, err := usecase.NewJourneyUsecase(
+				&logger,
+				&cfg,
+				new(mocks.JourneyRepositoryInterface),
+				dailyStatsRepo(),
+				zoneRepo(),
+				journeyParsers(),
+				journeyExporters(),
+				tripExporters(),
+				journeySplitter(),
+				domain.NewJourneyProcessorChain(),
+			)
+			assert.Nil(t, journeyUsecase)
+			assert.ErrorContains(t, err, test.setting)
+		})
+	}
+}
+
+func TestTile_cacheClearedByZonesAndAnomalies(t *testing.T) {
+	request := &domain.TileRequest{Layer: domain.TileLayerOrigins, Tile: maptile.At(orb.Point{-1.68, 48.11}, 10)}
+	zone := &domain.Zone{Name: "rennes", Geometry: orb.Polygon{{{-1.8, 48}, {-1.5, 48}, {-1.5, 48.2}, {-1.8, 48.2}, {-1.8, 48}}}}
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("FindLocations", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { close(args.Get(2).(chan<- *domain.JourneyLocation)) }).
+		Return(nil)
+	jRepo.On("TagZone", mock.Anything, zone).Return(nil)
+	jRepo.On("UntagZone", mock.Anything, zone.Name).Return(nil)
+	jRepo.On("Find", mock.Anything, (*domain.JourneyFilter)(nil), mock.Anything).
+		Run(func(args mock.Arguments) { close(args.Get(2).(chan<- *domain.Journey)) }).
+		Return(nil)
+	jRepo.On("ReplaceAnomalies", mock.Anything, (*domain.JourneyFilter)(nil), mock.Anything).Return(nil)
+	zRepo := zoneRepo()
+	zRepo.On("Create", mock.Anything, zone).Return(nil)
+	zRepo.On("Get", mock.Anything, zone.Name).Return(zone, nil)
+	zRepo.On("Update", mock.Anything, zone).Return(nil)
+	zRepo.On("Delete", mock.Anything, zone.Name).Return(nil)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo, zRepo: zRepo})
+	generateTile := func() {
+		_, err := journeyUsecase.Tile(&gin.Context{}, request, nil)
+		assert.NoError(t, err)
+	}
+
+	generateTile()
+	generateTile()
+	jRepo.AssertNumberOfCalls(t, "FindLocations", 1)
+
+	changes := []struct {
+		name   string
+		change func() error
+	}{
+		{"create_zone", func() error { return journeyUsecase.CreateZone(&gin.Context{}, zone) }},
+		{"update_zone", func() error { return journeyUsecase.UpdateZone(&gin.Context{}, zone) }},
+		{"delete_zone", func() error { return journeyUsecase.DeleteZone(&gin.Context{}, zone.Name) }},
+		{"detect_anomalies", func() error {
+			_, err := journeyUsecase.DetectAnomalies(&gin.Context{}, nil)
+			return err
+		}},
+	}
+	for i, test := range changes {
+		assert.NoError(t, test.change(), test.name)
+		generateTile()
+		jRepo.AssertNumberOfCalls(t, "FindLocations", i+2)
+	}
+}
+
+func TestTile_tooManyJourneys(t *testing.T) {
+	request := &domain.TileRequest{Layer: domain.TileLayerOrigins, Tile: maptile.At(orb.Point{-1.68, 48.11}, 10)}
+	jRepo := new(mocks.JourneyRepositoryInterface)
+	jRepo.On("FindLocations", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			locationChan := args.Get(2).(chan<- *domain.JourneyLocation)
+			for i := 0; i < 4; i++ {
+				locationChan <- &domain.JourneyLocation{Start: &orb.Point{-1.68, 48.11}}
+			}
+			close(locationChan)
+		}).
+		Return(context.Canceled)
+
+	journeyUsecase := newJourneyUsecase(t, journeyUsecaseDeps{jRepo: jRepo})
+
+	_, err := journeyUsecase.Tile(&gin.Context{}, request, nil)
+	var tooLarge *domain.TileTooLargeError
+	assert.ErrorAs(t, err, &tooLarge)
+	assert.Equal(t, int64(3), tooLarge.MaxJourneys)
+}
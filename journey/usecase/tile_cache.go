@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Tile kept in the cache, with the time it was generated
+type cachedTile struct {
+	key     string
+	content []byte
+	created time.Time
+}
+
+// tileCache keeps the last generated vector tiles in memory. The least recently used tile is
+// dropped when the cache is full, and tiles older than the time to live are generated again.
+type tileCache struct {
+	mutex sync.Mutex
+	size  int
+	ttl   time.Duration
+	order *list.List
+	tiles map[string]*list.Element
+}
+
+// newTileCache creates an empty cache
+//
+// @param size - Number of tiles kept, 0 disables the cache
+// @param ttl - Time a tile is kept, 0 keeps it until it is dropped
+func newTileCache(size int, ttl time.Duration) *tileCache {
+	return &tileCache{
+		size:  size,
+		ttl:   ttl,
+		order: list.New(),
+		tiles: map[string]*list.Element{},
+	}
+}
+
+// get returns a cached tile, if it is still fresh
+func (t *tileCache) get(key string) ([]byte, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	element, ok := t.tiles[key]
+	if !ok {
+		return nil, false
+	}
+	tile := element.Value.(*cachedTile)
+	if t.ttl > 0 && time.Since(tile.created) > t.ttl {
+		t.order.Remove(element)
+		delete(t.tiles, key)
+		return nil, false
+	}
+	t.order.MoveToFront(element)
+	return tile.content, true
+}
+
+// set caches a tile, dropping the least recently used one when the cache is full
+func (t *tileCache) set(key string, content []byte) {
+	if t.size <= 0 {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if element, ok := t.tiles[key]; ok {
+		t.order.Remove(element)
+	}
+	t.tiles[key] = t.order.PushFront(&cachedTile{key: key, content: content, created: time.Now()})
+	for t.order.Len() > t.size {
+		oldest := t.order.Back()
+		t.order.Remove(oldest)
+		delete(t.tiles, oldest.Value.(*cachedTile).key)
+	}
+}
+
+// clear drops all the tiles, after the stored journeys have changed
+func (t *tileCache) clear() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.order.Init()
+	t.tiles = map[string]*list.Element{}
+}
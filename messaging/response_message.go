@@ -1,18 +1,35 @@
 // Package messaging defines messages sended in http response
 package messaging
 
+import (
+	"time"
+
+	"github.com/paulmach/orb/geojson"
+)
+
 // Default response message
 type SingleResponseMessage struct {
 	Message string
 	Errors  []string
 }
 
+// Counters of a processing step
+type ProcessorResponseMessage struct {
+	Name        string
+	NbProcessed int
+	NbDropped   int
+	NbErrors    int
+}
+
 // File import description
 type FileImportResponseMessage struct {
-	Filename       string
-	Imported       bool
-	NbLineImported int
-	Errors         []string
+	Filename          string
+	Format            string
+	Imported          bool
+	NbLineImported    int
+	NbLineFilteredOut int
+	Processors        []ProcessorResponseMessage
+	Errors            []string
 }
 
 // Import description
@@ -21,6 +38,7 @@ type FileImportData struct {
 	NbFilesWithErrors  int
 	NbFilesSucceded    int
 	NbLineImported     int
+	NbLineFilteredOut  int
 }
 
 // Message used when files are imported
@@ -28,3 +46,137 @@ type MultipleResponseMessage struct {
 	Files []FileImportResponseMessage
 	Data  FileImportData
 }
+
+// Flow of journeys between an origin and a destination
+type ODPairResponseMessage struct {
+	Origin          string
+	Destination     string
+	NbJourneys      int64
+	PassengerSeats  int64
+	AverageDistance float64
+	AverageDuration float64
+}
+
+// Message used to send an origin-destination matrix
+type ODMatrixResponseMessage struct {
+	Level string
+	Pairs []ODPairResponseMessage
+}
+
+// Number of journeys of a bucket of a statistic
+type StatBucketResponseMessage struct {
+	Key        string
+	NbJourneys int64
+	Share      float64
+}
+
+// Message used to send a statistic
+type StatsResponseMessage struct {
+	Stat    string
+	Buckets []StatBucketResponseMessage
+}
+
+// Message used to list the available statistics
+type StatsListResponseMessage struct {
+	Stats []string
+}
+
+// Trip gathering the journeys of its passengers. Unknown datetimes are nulls
+type TripResponseMessage struct {
+	TripId         string
+	NbPassengers   int64
+	PassengerSeats int64
+	StartDatetime  *time.Time
+	EndDatetime    *time.Time
+	JourneyIds     []int64
+	Warnings       []string
+}
+
+// Message used to send a page of trips
+type TripsResponseMessage struct {
+	Offset int64
+	Limit  int64
+	Trips  []TripResponseMessage
+}
+
+// Estimates of the emissions avoided by the journeys of a bucket
+type Co2BucketResponseMessage struct {
+	Key              string
+	NbJourneys       int64
+	PassengerKm      float64
+	VehicleKmAvoided float64
+	Co2Saved         float64
+}
+
+// Message used to send the estimates of the emissions avoided, with the emission factors used
+type Co2ResponseMessage struct {
+	Version     string
+	CarEmission float64
+	ModalShift  float64
+	Buckets     []Co2BucketResponseMessage
+}
+
+// Number of paid journeys and cost of a bucket of an incentive simulation
+type IncentiveBreakdownResponseMessage struct {
+	Key        string
+	NbJourneys int64
+	Cost       float64
+}
+
+// Journey paid by a simulated incentive policy
+type IncentiveJourneyResponseMessage struct {
+	JourneyId     int64
+	TripId        string
+	StartDatetime *time.Time
+	Day           string
+	Commune       string
+	Amount        float64
+}
+
+// Message used to send the result of the simulation of an incentive policy
+type IncentiveSimulationResponseMessage struct {
+	Policy     string
+	NbJourneys int64
+	NbEligible int64
+	NbCapped   int64
+	NbPaid     int64
+	TotalCost  float64
+	ByMonth    []IncentiveBreakdownResponseMessage
+	ByCommune  []IncentiveBreakdownResponseMessage
+	// Page of the paid journeys
+	Offset   int64
+	Limit    int64
+	Journeys []IncentiveJourneyResponseMessage
+}
+
+// Journey flagged by the anomaly detection. Unknown datetimes are nulls
+type AnomalyResponseMessage struct {
+	JourneyId       int64
+	TripId          string
+	StartDatetime   *time.Time
+	StartTown       string
+	EndTown         string
+	JourneyDistance int64
+	JourneyDuration int64
+	Anomalies       []string
+	Score           float64
+}
+
+// Message used to send a page of the journeys flagged by the anomaly detection
+type AnomaliesResponseMessage struct {
+	Offset   int64
+	Limit    int64
+	Journeys []AnomalyResponseMessage
+}
+
+// Zone defined by a client, with its geometry in GeoJSON
+type ZoneResponseMessage struct {
+	Name        string
+	Description string
+	Geometry    *geojson.Geometry
+}
+
+// Message used to list the zones
+type ZonesResponseMessage struct {
+	Zones []ZoneResponseMessage
+}
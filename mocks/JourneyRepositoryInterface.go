@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	domain "github.com/coutcout/covoiturage-csvreader/domain"
 	gin "github.com/gin-gonic/gin"
 
@@ -15,18 +17,20 @@ type JourneyRepositoryInterface struct {
 }
 
 // Add provides a mock function with given fields: c, journeys
-func (_m *JourneyRepositoryInterface) Add(c *gin.Context, journeys []domain.Journey) (int, error) {
+func (_m *JourneyRepositoryInterface) Add(c *gin.Context, journeys []domain.Journey) ([]domain.Journey, error) {
 	ret := _m.Called(c, journeys)
 
-	var r0 int
+	var r0 []domain.Journey
 	var r1 error
-	if rf, ok := ret.Get(0).(func(*gin.Context, []domain.Journey) (int, error)); ok {
+	if rf, ok := ret.Get(0).(func(*gin.Context, []domain.Journey) ([]domain.Journey, error)); ok {
 		return rf(c, journeys)
 	}
-	if rf, ok := ret.Get(0).(func(*gin.Context, []domain.Journey) int); ok {
+	if rf, ok := ret.Get(0).(func(*gin.Context, []domain.Journey) []domain.Journey); ok {
 		r0 = rf(c, journeys)
 	} else {
-		r0 = ret.Get(0).(int)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Journey)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(*gin.Context, []domain.Journey) error); ok {
@@ -38,6 +42,258 @@ func (_m *JourneyRepositoryInterface) Add(c *gin.Context, journeys []domain.Jour
 	return r0, r1
 }
 
+// BackfillLocations provides a mock function with given fields: ctx
+func (_m *JourneyRepositoryInterface) BackfillLocations(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Co2 provides a mock function with given fields: ctx, dimension, filter
+func (_m *JourneyRepositoryInterface) Co2(ctx context.Context, dimension string, filter *domain.JourneyFilter) ([]domain.Co2Bucket, error) {
+	ret := _m.Called(ctx, dimension, filter)
+
+	var r0 []domain.Co2Bucket
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.JourneyFilter) ([]domain.Co2Bucket, error)); ok {
+		return rf(ctx, dimension, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.JourneyFilter) []domain.Co2Bucket); ok {
+		r0 = rf(ctx, dimension, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Co2Bucket)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *domain.JourneyFilter) error); ok {
+		r1 = rf(ctx, dimension, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Count provides a mock function with given fields: ctx, filter
+func (_m *JourneyRepositoryInterface) Count(ctx context.Context, filter *domain.JourneyFilter) (int64, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.JourneyFilter) (int64, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.JourneyFilter) int64); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.JourneyFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Find provides a mock function with given fields: ctx, filter, journeyChan
+func (_m *JourneyRepositoryInterface) Find(ctx context.Context, filter *domain.JourneyFilter, journeyChan chan<- *domain.Journey) error {
+	ret := _m.Called(ctx, filter, journeyChan)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.JourneyFilter, chan<- *domain.Journey) error); ok {
+		r0 = rf(ctx, filter, journeyChan)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindAnomalies provides a mock function with given fields: ctx, filter, anomaly, offset, limit
+func (_m *JourneyRepositoryInterface) FindAnomalies(ctx context.Context, filter *domain.JourneyFilter, anomaly string, offset int64, limit int64) ([]domain.Journey, error) {
+	ret := _m.Called(ctx, filter, anomaly, offset, limit)
+
+	var r0 []domain.Journey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.JourneyFilter, string, int64, int64) ([]domain.Journey, error)); ok {
+		return rf(ctx, filter, anomaly, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.JourneyFilter, string, int64, int64) []domain.Journey); ok {
+		r0 = rf(ctx, filter, anomaly, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Journey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.JourneyFilter, string, int64, int64) error); ok {
+		r1 = rf(ctx, filter, anomaly, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByStart provides a mock function with given fields: ctx, filter, journeyChan
+func (_m *JourneyRepositoryInterface) FindByStart(ctx context.Context, filter *domain.JourneyFilter, journeyChan chan<- *domain.Journey) error {
+	ret := _m.Called(ctx, filter, journeyChan)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.JourneyFilter, chan<- *domain.Journey) error); ok {
+		r0 = rf(ctx, filter, journeyChan)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindLocations provides a mock function with given fields: ctx, filter, locationChan
+func (_m *JourneyRepositoryInterface) FindLocations(ctx context.Context, filter *domain.JourneyFilter, locationChan chan<- *domain.JourneyLocation) error {
+	ret := _m.Called(ctx, filter, locationChan)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.JourneyFilter, chan<- *domain.JourneyLocation) error); ok {
+		r0 = rf(ctx, filter, locationChan)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindTrips provides a mock function with given fields: ctx, filter, offset, limit, tripChan
+func (_m *JourneyRepositoryInterface) FindTrips(ctx context.Context, filter *domain.JourneyFilter, offset int64, limit int64, tripChan chan<- *domain.Trip) error {
+	ret := _m.Called(ctx, filter, offset, limit, tripChan)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.JourneyFilter, int64, int64, chan<- *domain.Trip) error); ok {
+		r0 = rf(ctx, filter, offset, limit, tripChan)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ODMatrix provides a mock function with given fields: ctx, level, filter
+func (_m *JourneyRepositoryInterface) ODMatrix(ctx context.Context, level string, filter *domain.JourneyFilter) ([]domain.ODPair, error) {
+	ret := _m.Called(ctx, level, filter)
+
+	var r0 []domain.ODPair
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.JourneyFilter) ([]domain.ODPair, error)); ok {
+		return rf(ctx, level, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.JourneyFilter) []domain.ODPair); ok {
+		r0 = rf(ctx, level, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.ODPair)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *domain.JourneyFilter) error); ok {
+		r1 = rf(ctx, level, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplaceAnomalies provides a mock function with given fields: ctx, filter, anomalies
+func (_m *JourneyRepositoryInterface) ReplaceAnomalies(ctx context.Context, filter *domain.JourneyFilter, anomalies []domain.JourneyAnomalies) error {
+	ret := _m.Called(ctx, filter, anomalies)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.JourneyFilter, []domain.JourneyAnomalies) error); ok {
+		r0 = rf(ctx, filter, anomalies)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Stats provides a mock function with given fields: ctx, stat, filter
+func (_m *JourneyRepositoryInterface) Stats(ctx context.Context, stat string, filter *domain.JourneyFilter) ([]domain.StatBucket, error) {
+	ret := _m.Called(ctx, stat, filter)
+
+	var r0 []domain.StatBucket
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.JourneyFilter) ([]domain.StatBucket, error)); ok {
+		return rf(ctx, stat, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.JourneyFilter) []domain.StatBucket); ok {
+		r0 = rf(ctx, stat, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.StatBucket)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *domain.JourneyFilter) error); ok {
+		r1 = rf(ctx, stat, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TagZone provides a mock function with given fields: ctx, zone
+func (_m *JourneyRepositoryInterface) TagZone(ctx context.Context, zone *domain.Zone) error {
+	ret := _m.Called(ctx, zone)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Zone) error); ok {
+		r0 = rf(ctx, zone)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UntagZone provides a mock function with given fields: ctx, name
+func (_m *JourneyRepositoryInterface) UntagZone(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewJourneyRepositoryInterface interface {
 	mock.TestingT
 	Cleanup(func())
@@ -3,10 +3,12 @@
 package mocks
 
 import (
-	io "io"
+	domain "github.com/coutcout/covoiturage-csvreader/domain"
 
 	gin "github.com/gin-gonic/gin"
 
+	io "io"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -15,23 +17,231 @@ type JourneyUsecase struct {
 	mock.Mock
 }
 
-// ImportFromCSVFile provides a mock function with given fields: c, reader
-func (_m *JourneyUsecase) ImportFromCSVFile(c *gin.Context, reader io.Reader) (int64, []string) {
-	ret := _m.Called(c, reader)
+// Anomalies provides a mock function with given fields: c, filter, anomaly, offset, limit
+func (_m *JourneyUsecase) Anomalies(c *gin.Context, filter *domain.JourneyFilter, anomaly string, offset int64, limit int64) ([]domain.Journey, error) {
+	ret := _m.Called(c, filter, anomaly, offset, limit)
+
+	var r0 []domain.Journey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.JourneyFilter, string, int64, int64) ([]domain.Journey, error)); ok {
+		return rf(c, filter, anomaly, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.JourneyFilter, string, int64, int64) []domain.Journey); ok {
+		r0 = rf(c, filter, anomaly, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Journey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gin.Context, *domain.JourneyFilter, string, int64, int64) error); ok {
+		r1 = rf(c, filter, anomaly, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Co2 provides a mock function with given fields: c, dimension, version, filter
+func (_m *JourneyUsecase) Co2(c *gin.Context, dimension string, version string, filter *domain.JourneyFilter) (*domain.Co2Report, error) {
+	ret := _m.Called(c, dimension, version, filter)
+
+	var r0 *domain.Co2Report
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, string, string, *domain.JourneyFilter) (*domain.Co2Report, error)); ok {
+		return rf(c, dimension, version, filter)
+	}
+	if rf, ok := ret.Get(0).(func(*gin.Context, string, string, *domain.JourneyFilter) *domain.Co2Report); ok {
+		r0 = rf(c, dimension, version, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Co2Report)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gin.Context, string, string, *domain.JourneyFilter) error); ok {
+		r1 = rf(c, dimension, version, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateZone provides a mock function with given fields: c, zone
+func (_m *JourneyUsecase) CreateZone(c *gin.Context, zone *domain.Zone) error {
+	ret := _m.Called(c, zone)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.Zone) error); ok {
+		r0 = rf(c, zone)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteZone provides a mock function with given fields: c, name
+func (_m *JourneyUsecase) DeleteZone(c *gin.Context, name string) error {
+	ret := _m.Called(c, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, string) error); ok {
+		r0 = rf(c, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DetectAnomalies provides a mock function with given fields: c, filter
+func (_m *JourneyUsecase) DetectAnomalies(c *gin.Context, filter *domain.JourneyFilter) (*domain.AnomalySummary, error) {
+	ret := _m.Called(c, filter)
+
+	var r0 *domain.AnomalySummary
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.JourneyFilter) (*domain.AnomalySummary, error)); ok {
+		return rf(c, filter)
+	}
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.JourneyFilter) *domain.AnomalySummary); ok {
+		r0 = rf(c, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.AnomalySummary)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gin.Context, *domain.JourneyFilter) error); ok {
+		r1 = rf(c, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Export provides a mock function with given fields: c, exporter, filter, writer
+func (_m *JourneyUsecase) Export(c *gin.Context, exporter domain.JourneyExporter, filter *domain.JourneyFilter, writer io.Writer) (int64, error) {
+	ret := _m.Called(c, exporter, filter, writer)
 
 	var r0 int64
-	var r1 []string
-	if rf, ok := ret.Get(0).(func(*gin.Context, io.Reader) (int64, []string)); ok {
-		return rf(c, reader)
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, domain.JourneyExporter, *domain.JourneyFilter, io.Writer) (int64, error)); ok {
+		return rf(c, exporter, filter, writer)
+	}
+	if rf, ok := ret.Get(0).(func(*gin.Context, domain.JourneyExporter, *domain.JourneyFilter, io.Writer) int64); ok {
+		r0 = rf(c, exporter, filter, writer)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(*gin.Context, domain.JourneyExporter, *domain.JourneyFilter, io.Writer) error); ok {
+		r1 = rf(c, exporter, filter, writer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportTrips provides a mock function with given fields: c, exporter, filter, writer
+func (_m *JourneyUsecase) ExportTrips(c *gin.Context, exporter domain.TripExporter, filter *domain.JourneyFilter, writer io.Writer) (int64, error) {
+	ret := _m.Called(c, exporter, filter, writer)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, domain.TripExporter, *domain.JourneyFilter, io.Writer) (int64, error)); ok {
+		return rf(c, exporter, filter, writer)
 	}
-	if rf, ok := ret.Get(0).(func(*gin.Context, io.Reader) int64); ok {
-		r0 = rf(c, reader)
+	if rf, ok := ret.Get(0).(func(*gin.Context, domain.TripExporter, *domain.JourneyFilter, io.Writer) int64); ok {
+		r0 = rf(c, exporter, filter, writer)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
-	if rf, ok := ret.Get(1).(func(*gin.Context, io.Reader) []string); ok {
-		r1 = rf(c, reader)
+	if rf, ok := ret.Get(1).(func(*gin.Context, domain.TripExporter, *domain.JourneyFilter, io.Writer) error); ok {
+		r1 = rf(c, exporter, filter, writer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Exporter provides a mock function with given fields: format
+func (_m *JourneyUsecase) Exporter(format string) (domain.JourneyExporter, error) {
+	ret := _m.Called(format)
+
+	var r0 domain.JourneyExporter
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (domain.JourneyExporter, error)); ok {
+		return rf(format)
+	}
+	if rf, ok := ret.Get(0).(func(string) domain.JourneyExporter); ok {
+		r0 = rf(format)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(domain.JourneyExporter)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(format)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Heatmap provides a mock function with given fields: c, request, filter
+func (_m *JourneyUsecase) Heatmap(c *gin.Context, request *domain.HeatmapRequest, filter *domain.JourneyFilter) ([]domain.HeatmapCell, error) {
+	ret := _m.Called(c, request, filter)
+
+	var r0 []domain.HeatmapCell
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.HeatmapRequest, *domain.JourneyFilter) ([]domain.HeatmapCell, error)); ok {
+		return rf(c, request, filter)
+	}
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.HeatmapRequest, *domain.JourneyFilter) []domain.HeatmapCell); ok {
+		r0 = rf(c, request, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.HeatmapCell)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gin.Context, *domain.HeatmapRequest, *domain.JourneyFilter) error); ok {
+		r1 = rf(c, request, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportFromFile provides a mock function with given fields: c, file, filter
+func (_m *JourneyUsecase) ImportFromFile(c *gin.Context, file *domain.JourneyFile, filter *domain.JourneyFilter) (*domain.ImportSummary, []string) {
+	ret := _m.Called(c, file, filter)
+
+	var r0 *domain.ImportSummary
+	var r1 []string
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.JourneyFile, *domain.JourneyFilter) (*domain.ImportSummary, []string)); ok {
+		return rf(c, file, filter)
+	}
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.JourneyFile, *domain.JourneyFilter) *domain.ImportSummary); ok {
+		r0 = rf(c, file, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.ImportSummary)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gin.Context, *domain.JourneyFile, *domain.JourneyFilter) []string); ok {
+		r1 = rf(c, file, filter)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).([]string)
@@ -41,6 +251,254 @@ func (_m *JourneyUsecase) ImportFromCSVFile(c *gin.Context, reader io.Reader) (i
 	return r0, r1
 }
 
+// ODMatrix provides a mock function with given fields: c, level, filter
+func (_m *JourneyUsecase) ODMatrix(c *gin.Context, level string, filter *domain.JourneyFilter) ([]domain.ODPair, error) {
+	ret := _m.Called(c, level, filter)
+
+	var r0 []domain.ODPair
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, string, *domain.JourneyFilter) ([]domain.ODPair, error)); ok {
+		return rf(c, level, filter)
+	}
+	if rf, ok := ret.Get(0).(func(*gin.Context, string, *domain.JourneyFilter) []domain.ODPair); ok {
+		r0 = rf(c, level, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.ODPair)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gin.Context, string, *domain.JourneyFilter) error); ok {
+		r1 = rf(c, level, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SimulateIncentive provides a mock function with given fields: c, policy, filter, offset, limit, paid
+func (_m *JourneyUsecase) SimulateIncentive(c *gin.Context, policy *domain.IncentivePolicy, filter *domain.JourneyFilter, offset int64, limit int64, paid func(*domain.IncentiveJourney) error) (*domain.IncentiveSimulationResult, error) {
+	ret := _m.Called(c, policy, filter, offset, limit, paid)
+
+	var r0 *domain.IncentiveSimulationResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.IncentivePolicy, *domain.JourneyFilter, int64, int64, func(*domain.IncentiveJourney) error) (*domain.IncentiveSimulationResult, error)); ok {
+		return rf(c, policy, filter, offset, limit, paid)
+	}
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.IncentivePolicy, *domain.JourneyFilter, int64, int64, func(*domain.IncentiveJourney) error) *domain.IncentiveSimulationResult); ok {
+		r0 = rf(c, policy, filter, offset, limit, paid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.IncentiveSimulationResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gin.Context, *domain.IncentivePolicy, *domain.JourneyFilter, int64, int64, func(*domain.IncentiveJourney) error) error); ok {
+		r1 = rf(c, policy, filter, offset, limit, paid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Split provides a mock function with given fields: c, file, dimension, exporter, writer
+func (_m *JourneyUsecase) Split(c *gin.Context, file *domain.JourneyFile, dimension string, exporter domain.JourneyExporter, writer io.Writer) (*domain.SplitSummary, error) {
+	ret := _m.Called(c, file, dimension, exporter, writer)
+
+	var r0 *domain.SplitSummary
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.JourneyFile, string, domain.JourneyExporter, io.Writer) (*domain.SplitSummary, error)); ok {
+		return rf(c, file, dimension, exporter, writer)
+	}
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.JourneyFile, string, domain.JourneyExporter, io.Writer) *domain.SplitSummary); ok {
+		r0 = rf(c, file, dimension, exporter, writer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.SplitSummary)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gin.Context, *domain.JourneyFile, string, domain.JourneyExporter, io.Writer) error); ok {
+		r1 = rf(c, file, dimension, exporter, writer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Stats provides a mock function with given fields: c, stat, filter
+func (_m *JourneyUsecase) Stats(c *gin.Context, stat string, filter *domain.JourneyFilter) ([]domain.StatBucket, error) {
+	ret := _m.Called(c, stat, filter)
+
+	var r0 []domain.StatBucket
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, string, *domain.JourneyFilter) ([]domain.StatBucket, error)); ok {
+		return rf(c, stat, filter)
+	}
+	if rf, ok := ret.Get(0).(func(*gin.Context, string, *domain.JourneyFilter) []domain.StatBucket); ok {
+		r0 = rf(c, stat, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.StatBucket)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gin.Context, string, *domain.JourneyFilter) error); ok {
+		r1 = rf(c, stat, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Tile provides a mock function with given fields: c, request, filter
+func (_m *JourneyUsecase) Tile(c *gin.Context, request *domain.TileRequest, filter *domain.JourneyFilter) ([]byte, error) {
+	ret := _m.Called(c, request, filter)
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.TileRequest, *domain.JourneyFilter) ([]byte, error)); ok {
+		return rf(c, request, filter)
+	}
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.TileRequest, *domain.JourneyFilter) []byte); ok {
+		r0 = rf(c, request, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gin.Context, *domain.TileRequest, *domain.JourneyFilter) error); ok {
+		r1 = rf(c, request, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TripExporter provides a mock function with given fields: format
+func (_m *JourneyUsecase) TripExporter(format string) (domain.TripExporter, error) {
+	ret := _m.Called(format)
+
+	var r0 domain.TripExporter
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (domain.TripExporter, error)); ok {
+		return rf(format)
+	}
+	if rf, ok := ret.Get(0).(func(string) domain.TripExporter); ok {
+		r0 = rf(format)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(domain.TripExporter)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(format)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Trips provides a mock function with given fields: c, filter, offset, limit
+func (_m *JourneyUsecase) Trips(c *gin.Context, filter *domain.JourneyFilter, offset int64, limit int64) ([]domain.Trip, error) {
+	ret := _m.Called(c, filter, offset, limit)
+
+	var r0 []domain.Trip
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.JourneyFilter, int64, int64) ([]domain.Trip, error)); ok {
+		return rf(c, filter, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.JourneyFilter, int64, int64) []domain.Trip); ok {
+		r0 = rf(c, filter, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Trip)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gin.Context, *domain.JourneyFilter, int64, int64) error); ok {
+		r1 = rf(c, filter, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateZone provides a mock function with given fields: c, zone
+func (_m *JourneyUsecase) UpdateZone(c *gin.Context, zone *domain.Zone) error {
+	ret := _m.Called(c, zone)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, *domain.Zone) error); ok {
+		r0 = rf(c, zone)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Zone provides a mock function with given fields: c, name
+func (_m *JourneyUsecase) Zone(c *gin.Context, name string) (*domain.Zone, error) {
+	ret := _m.Called(c, name)
+
+	var r0 *domain.Zone
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gin.Context, string) (*domain.Zone, error)); ok {
+		return rf(c, name)
+	}
+	if rf, ok := ret.Get(0).(func(*gin.Context, string) *domain.Zone); ok {
+		r0 = rf(c, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Zone)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gin.Context, string) error); ok {
+		r1 = rf(c, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Zones provides a mock function with given fields: c
+func (_m *JourneyUsecase) Zones(c *gin.Context) ([]domain.Zone, error) {
+	ret := _m.Called(c)
+
+	var r0 []domain.Zone
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gin.Context) ([]domain.Zone, error)); ok {
+		return rf(c)
+	}
+	if rf, ok := ret.Get(0).(func(*gin.Context) []domain.Zone); ok {
+		r0 = rf(c)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Zone)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gin.Context) error); ok {
+		r1 = rf(c)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewJourneyUsecase interface {
 	mock.TestingT
 	Cleanup(func())